@@ -1,100 +1,226 @@
 package http
 
 import (
+	"bytes"
+	ctx "context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"reflect"
+	"strconv"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
 	"github.com/mrdhira/warpin-test/api/Orders/entities"
-	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/api/Orders/usecases"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/logging"
 	log "github.com/sirupsen/logrus"
 )
 
-// AuthMiddleware func
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		// Validate Token
-		Authorization := req.Header.Get("Authorization")
-		TokenData := &entities.TokenClaim{}
-		Token, err := jwt.ParseWithClaims(Authorization, TokenData, func(token *jwt.Token) (interface{}, error) {
-			if jwt.GetSigningMethod("HS256") != token.Method {
-				return nil, fmt.Errorf("Unex[ected signing method: %v", token.Header["alg"])
+// AuthMiddleware func, the tokens of users services are checked with Verifier
+// and Revocations
+func AuthMiddleware(Verifier *auth.Verifier, Revocations *auth.Revocations) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			// Validate Token
+			Authorization := req.Header.Get("Authorization")
+			TokenData := &entities.TokenClaim{}
+			Token, err := Verifier.Parse(Authorization, TokenData)
+			if err == nil {
+				err = Revocations.CheckRevoked(TokenData.Id)
+			}
+
+			if Token != nil && err == nil {
+				TokenDataJSON, _ := json.Marshal(TokenData)
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"user_id":   TokenData.UserID,
+					"user_role": TokenData.UserRole,
+				}).Debug("token verified")
+				context.Set(req, "token", string(TokenDataJSON))
+			} else {
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"event": "unauthorized token",
+				}).Error(err)
+				apperr.Write(res, req, tokenError(err))
+				return
 			}
 
-			return []byte("secret"), nil
+			next.ServeHTTP(res, req)
 		})
+	}
+}
 
-		if Token != nil && err == nil {
-			TokenDataJSON, _ := json.Marshal(TokenData)
-			fmt.Println("Token Verified: ", string(TokenDataJSON))
-			context.Set(req, "token", string(TokenDataJSON))
-		} else {
-			log.WithFields(log.Fields{
-				"event": "unauthorized token",
-				"data":  Token,
-			}).Error(err)
-			pkg.Response(res, 401, pkg.JSONResponse{
-				Code:    401,
-				Message: "Unauthorized",
-				Error:   err.Error(),
-			})
-			return
-		}
-
-		next.ServeHTTP(res, req)
-	})
+// AuthAdmniMiddleware func, admin users only, the other services are refused
+func AuthAdmniMiddleware(Verifier *auth.Verifier, Revocations *auth.Revocations) mux.MiddlewareFunc {
+	return authAdmin(Verifier, Revocations, false)
 }
 
-// AuthAdmniMiddleware func
-func AuthAdmniMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		// Validate Token
-		Authorization := req.Header.Get("Authorization")
-		if Authorization == "INTERNAL-SERVICES" {
-			fmt.Println("coming request from internal services")
-			TokenData := &entities.TokenClaim{
-				UserID:   0,
-				UserRole: "ADMIN",
-			}
-			TokenDataJSON, _ := json.Marshal(TokenData)
-			context.Set(req, "token", string(TokenDataJSON))
-		} else {
-			TokenData := &entities.TokenClaim{}
-			Token, err := jwt.ParseWithClaims(Authorization, TokenData, func(token *jwt.Token) (interface{}, error) {
-				if jwt.GetSigningMethod("HS256") != token.Method {
-					return nil, fmt.Errorf("Unex[ected signing method: %v", token.Header["alg"])
-				}
+// AuthAdminOrServiceMiddleware func, admin users or the services of
+// auth.services.orders.allowed_callers. Only for the routes the other services
+// call, they act as the system there
+func AuthAdminOrServiceMiddleware(Verifier *auth.Verifier, Revocations *auth.Revocations) mux.MiddlewareFunc {
+	return authAdmin(Verifier, Revocations, true)
+}
 
-				return []byte("secret"), nil
-			})
+// authAdmin func
+func authAdmin(Verifier *auth.Verifier, Revocations *auth.Revocations, AllowServices bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			// Validate Token
+			Authorization := req.Header.Get("Authorization")
+			if auth.IsServiceToken(Authorization) {
+				if !AllowServices {
+					apperr.Write(res, req, apperr.Forbidden("admin_only"))
+					return
+				}
 
-			if Token != nil && err == nil {
-				TokenDataJSON, _ := json.Marshal(TokenData)
-				fmt.Println("Token Verified: ", string(TokenDataJSON))
-				if TokenData.UserRole != entities.Admin {
-					pkg.Response(res, 403, &pkg.JSONResponse{
-						Code:    403,
-						Message: "Forbidden Access",
-					})
+				ServiceClaim, err := auth.VerifyServiceToken(Authorization, "orders")
+				if err != nil {
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"event": "unauthorized service token",
+					}).Error(err)
+					apperr.Write(res, req, apperr.Unauthorized("service_token_invalid").Wrap(err))
 					return
 				}
+
+				TokenData := &entities.TokenClaim{
+					Service: ServiceClaim.Issuer,
+				}
+				TokenDataJSON, _ := json.Marshal(TokenData)
 				context.Set(req, "token", string(TokenDataJSON))
 			} else {
-				log.WithFields(log.Fields{
-					"event": "unauthorized token",
-					"data":  Token,
-				}).Error(err)
-				pkg.Response(res, 401, &pkg.JSONResponse{
-					Code:    401,
-					Message: "Unauthorized",
-					Error:   err.Error(),
-				})
+				TokenData := &entities.TokenClaim{}
+				Token, err := Verifier.Parse(Authorization, TokenData)
+				if err == nil {
+					err = Revocations.CheckRevoked(TokenData.Id)
+				}
+
+				if Token != nil && err == nil {
+					TokenDataJSON, _ := json.Marshal(TokenData)
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"user_id":   TokenData.UserID,
+						"user_role": TokenData.UserRole,
+					}).Debug("token verified")
+					if TokenData.UserRole != entities.Admin {
+						apperr.Write(res, req, apperr.Forbidden("admin_only"))
+						return
+					}
+					context.Set(req, "token", string(TokenDataJSON))
+				} else {
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"event": "unauthorized token",
+					}).Error(err)
+					apperr.Write(res, req, tokenError(err))
+					return
+				}
+			}
+
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// tokenError func, a token that is not valid is a 401 but a revocation list
+// that can not be read is a 503
+func tokenError(err error) *apperr.Error {
+	if errors.Is(err, auth.ErrRevocationsUnavailable) {
+		return apperr.Unavailable("service_unavailable", err)
+	}
+	return apperr.Unauthorized("token_invalid").Wrap(err)
+}
+
+// IdempotencyMiddleware func, a POST or PUT sent again with the same
+// Idempotency-Key get the response of the first request instead of running
+// twice. Must run after the auth middleware, the keys are apart per user
+func IdempotencyMiddleware(IdempotencyUsecase usecases.IIdempotencyUsecases) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			Key := req.Header.Get("Idempotency-Key")
+			if Key == "" || (req.Method != http.MethodPost && req.Method != http.MethodPut) {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			if len(Key) > 255 {
+				apperr.Write(res, req, apperr.Validation("idempotency_key_too_long", 255).WithDetails(
+					apperr.Field("Idempotency-Key", "max", reflect.String, "255"),
+				))
+				return
+			}
+
+			TokenData := &entities.TokenClaim{}
+			if TokenJSON, ok := context.Get(req, "token").(string); ok {
+				json.Unmarshal([]byte(TokenJSON), TokenData)
+			}
+			Scope := "user:" + strconv.Itoa(TokenData.UserID)
+			if TokenData.Service != "" {
+				Scope = "service:" + TokenData.Service
+			}
+
+			Body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				apperr.Write(res, req, apperr.Malformed(err))
+				return
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(Body))
+
+			Hash := sha256.New()
+			fmt.Fprintf(Hash, "%s %s\n", req.Method, req.URL.Path)
+			Hash.Write(Body)
+			Fingerprint := hex.EncodeToString(Hash.Sum(nil))
+
+			Replay, err := IdempotencyUsecase.IdempotencyBegin(req.Context(), Scope, Key, Fingerprint)
+			if err != nil {
+				apperr.Write(res, req, err)
+				return
+			}
+			if Replay != nil {
+				res.Header().Set("Content-Type", "application/json")
+				res.Header().Set("Idempotent-Replayed", "true")
+				res.WriteHeader(Replay.StatusCode)
+				res.Write([]byte(Replay.Body))
 				return
 			}
-		}
 
-		next.ServeHTTP(res, req)
-	})
+			// the key stay locked as long as the request run, even when it panic
+			Recorder := &responseRecorder{ResponseWriter: res, StatusCode: http.StatusOK}
+			func() {
+				Release := IdempotencyUsecase.IdempotencyHold(ctx.Background(), Scope, Key)
+				defer Release()
+				next.ServeHTTP(Recorder, req)
+			}()
+
+			// the client may be gone already, the response is kept for its retry
+			if err := IdempotencyUsecase.IdempotencyEnd(ctx.Background(), Scope, Key, Fingerprint, Recorder.StatusCode, Recorder.Body.Bytes()); err != nil {
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"event": "error when store idempotency response",
+				}).Error(err)
+			}
+		})
+	}
+}
+
+// responseRecorder struct copy the status and body written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	StatusCode int
+	Body       bytes.Buffer
+}
+
+// WriteHeader func
+func (r *responseRecorder) WriteHeader(StatusCode int) {
+	r.StatusCode = StatusCode
+	r.ResponseWriter.WriteHeader(StatusCode)
+}
+
+// Write func
+func (r *responseRecorder) Write(Data []byte) (int, error) {
+	r.Body.Write(Data)
+	return r.ResponseWriter.Write(Data)
 }
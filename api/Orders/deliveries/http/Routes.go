@@ -5,33 +5,74 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mrdhira/warpin-test/api/Orders/deliveries/http/controllers"
+	"github.com/mrdhira/warpin-test/api/Orders/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
 )
 
-// Route struct
-type Route struct{}
+// Route struct, the usecases are built by the caller (see container.New).
+// Health is the /readyz of the service, without it /readyz has no check.
+// Verifier and Revocations check the tokens of users services
+type Route struct {
+	Health             *health.Checker
+	Verifier           *auth.Verifier
+	Revocations        *auth.Revocations
+	OrdersUsecase      usecases.IOrdersUsecases
+	IdempotencyUsecase usecases.IIdempotencyUsecases
+}
 
 // Init func
 func (r *Route) Init() *mux.Router {
 	// Initialize Controllers
-	ordersControllers := controllers.InitOrdersControllers()
+	ordersControllers := controllers.InitOrdersControllers(r.OrdersUsecase)
+
+	// Initialize Middlewares
+	Auth := AuthMiddleware(r.Verifier, r.Revocations)
+	AuthAdmin := AuthAdmniMiddleware(r.Verifier, r.Revocations)
+	AuthAdminOrService := AuthAdminOrServiceMiddleware(r.Verifier, r.Revocations)
+	Idempotency := IdempotencyMiddleware(r.IdempotencyUsecase)
 
 	// Initialize Router
 	Router := mux.NewRouter().StrictSlash(true)
 
+	// Metrics, trace and log of every route
+	Router.Use(metrics.Middleware("orders"), tracing.Middleware("orders"), logging.Middleware("orders"), i18n.Middleware())
+	Router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	// Health Routes
+	Health := r.Health
+	if Health == nil {
+		Health = health.New("orders")
+	}
+	Router.HandleFunc("/healthz", Health.Liveness).Methods(http.MethodGet)
+	Router.HandleFunc("/readyz", Health.Readiness).Methods(http.MethodGet)
+
 	// Orders Routes with Auth
 	OrdersAuthRoutes := Router.PathPrefix("/orders").Subrouter()
-	OrdersAuthRoutes.Use(AuthMiddleware)
+	OrdersAuthRoutes.Use(Auth, Idempotency)
 	OrdersAuthRoutes.HandleFunc("/", ordersControllers.OrdersListUsers).Methods(http.MethodGet)
 	OrdersAuthRoutes.HandleFunc("/", ordersControllers.OrdersCreate).Methods(http.MethodPost)
 	OrdersAuthRoutes.HandleFunc("/{id}", ordersControllers.OrdersUpdate).Methods(http.MethodPut)
 	OrdersAuthRoutes.HandleFunc("/{id}/cancel", ordersControllers.OrdersCancel).Methods(http.MethodPut)
+	OrdersAuthRoutes.HandleFunc("/{id}/events/{event}", ordersControllers.OrdersTransitionUsers).Methods(http.MethodPut)
 
-	// Users Routes with Auth Admin
+	// Orders Routes with Auth Admin, the other services only get the routes of
+	// AuthAdminOrServiceMiddleware (products services list the pending orders)
+	Admin := func(Handler http.HandlerFunc) http.Handler {
+		return AuthAdmin(Idempotency(Handler))
+	}
+	AdminOrService := func(Handler http.HandlerFunc) http.Handler {
+		return AuthAdminOrService(Idempotency(Handler))
+	}
 	OrdersAuthAdminRoutes := Router.PathPrefix("/orders/internal").Subrouter()
-	OrdersAuthAdminRoutes.Use(AuthAdmniMiddleware)
-	OrdersAuthAdminRoutes.HandleFunc("/", ordersControllers.OrdersListAdmin).Methods(http.MethodGet)
-	OrdersAuthAdminRoutes.HandleFunc("/{id}/approve", ordersControllers.OrdersApprove).Methods(http.MethodPut)
-	OrdersAuthAdminRoutes.HandleFunc("/{id}/reject", ordersControllers.OrdersReject).Methods(http.MethodPut)
+	OrdersAuthAdminRoutes.Handle("/", AdminOrService(ordersControllers.OrdersListAdmin)).Methods(http.MethodGet)
+	OrdersAuthAdminRoutes.Handle("/{id}/approve", Admin(ordersControllers.OrdersApprove)).Methods(http.MethodPut)
+	OrdersAuthAdminRoutes.Handle("/{id}/reject", Admin(ordersControllers.OrdersReject)).Methods(http.MethodPut)
+	OrdersAuthAdminRoutes.Handle("/{id}/events/{event}", AdminOrService(ordersControllers.OrdersTransitionAdmin)).Methods(http.MethodPut)
 
 	return Router
 }
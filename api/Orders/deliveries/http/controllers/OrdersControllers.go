@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -15,6 +14,9 @@ import (
 	"github.com/mrdhira/warpin-test/api/Orders/entities"
 	"github.com/mrdhira/warpin-test/api/Orders/usecases"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/money"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,7 +28,7 @@ type OrdersControllers struct {
 }
 
 // InitOrdersControllers func
-func InitOrdersControllers() *OrdersControllers {
+func InitOrdersControllers(OrdersUsecase usecases.IOrdersUsecases) *OrdersControllers {
 	validate = validator.New()
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
@@ -35,12 +37,11 @@ func InitOrdersControllers() *OrdersControllers {
 		}
 		return name
 	})
-
-	// Init Usecase
-	ordersUsecases := usecases.InitOrdersUsecases()
+	// money is validated on its amount
+	validate.RegisterCustomTypeFunc(money.ValidateValuer, money.Money{})
 
 	return &OrdersControllers{
-		OrdersUsecase: ordersUsecases,
+		OrdersUsecase: OrdersUsecase,
 	}
 }
 
@@ -49,10 +50,10 @@ func (c *OrdersControllers) OrdersListUsers(res http.ResponseWriter, req *http.R
 	var requestBody *entities.OrdersListUsersRequest
 
 	if req.URL.Query().Get("limit") == "" && req.URL.Query().Get("offset") == "" {
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "Limit dan Offset tidak bisa kosong",
-		})
+		apperr.Write(res, req, apperr.Validation("pagination_required").WithDetails(
+			apperr.Field("limit", "required", reflect.Int, ""),
+			apperr.Field("offset", "required", reflect.Int, ""),
+		))
 		return
 	}
 
@@ -64,14 +65,10 @@ func (c *OrdersControllers) OrdersListUsers(res http.ResponseWriter, req *http.R
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -79,35 +76,13 @@ func (c *OrdersControllers) OrdersListUsers(res http.ResponseWriter, req *http.R
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersListUsers(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -118,39 +93,22 @@ func (c *OrdersControllers) OrdersListUsers(res http.ResponseWriter, req *http.R
 // OrdersCreate func
 func (c *OrdersControllers) OrdersCreate(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /orders payload body")
-
 	var requestBody *entities.OrdersCreateRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload orders create",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -158,35 +116,13 @@ func (c *OrdersControllers) OrdersCreate(res http.ResponseWriter, req *http.Requ
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersCreate(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -197,39 +133,22 @@ func (c *OrdersControllers) OrdersCreate(res http.ResponseWriter, req *http.Requ
 // OrdersUpdate func
 func (c *OrdersControllers) OrdersUpdate(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("PUT /orders/{id} payload body")
-
 	var requestBody *entities.OrdersUpdateRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload update products",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -237,14 +156,10 @@ func (c *OrdersControllers) OrdersUpdate(res http.ResponseWriter, req *http.Requ
 
 	OrderID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get order id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -252,35 +167,13 @@ func (c *OrdersControllers) OrdersUpdate(res http.ResponseWriter, req *http.Requ
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersUpdate(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -290,29 +183,15 @@ func (c *OrdersControllers) OrdersUpdate(res http.ResponseWriter, req *http.Requ
 
 // OrdersCancel func
 func (c *OrdersControllers) OrdersCancel(res http.ResponseWriter, req *http.Request) {
-	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("PUT /orders/{id}/cancel payload body")
-
 	var requestBody *entities.OrdersCancelRequest
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -322,14 +201,10 @@ func (c *OrdersControllers) OrdersCancel(res http.ResponseWriter, req *http.Requ
 
 	OrderID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get order id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -337,35 +212,13 @@ func (c *OrdersControllers) OrdersCancel(res http.ResponseWriter, req *http.Requ
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersCancel(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -378,10 +231,10 @@ func (c *OrdersControllers) OrdersListAdmin(res http.ResponseWriter, req *http.R
 	var requestBody *entities.OrdersListAdminRequest
 
 	if req.URL.Query().Get("limit") == "" && req.URL.Query().Get("offset") == "" {
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "Limit dan Offset tidak bisa kosong",
-		})
+		apperr.Write(res, req, apperr.Validation("pagination_required").WithDetails(
+			apperr.Field("limit", "required", reflect.Int, ""),
+			apperr.Field("offset", "required", reflect.Int, ""),
+		))
 		return
 	}
 
@@ -393,14 +246,10 @@ func (c *OrdersControllers) OrdersListAdmin(res http.ResponseWriter, req *http.R
 	if req.URL.Query().Get("status") != "" {
 		Status, err := strconv.Atoi(req.URL.Query().Get("status"))
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(req.Context()).WithFields(log.Fields{
 				"event": "error when parse to int for status query params",
 			}).Error(err)
-			pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-				Code:    400,
-				Message: "Terjadi kesalahan sistem",
-				Error:   err.Error(),
-			})
+			apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 			return
 		}
 		requestBody.Status = Status
@@ -409,50 +258,36 @@ func (c *OrdersControllers) OrdersListAdmin(res http.ResponseWriter, req *http.R
 	if req.URL.Query().Get("product_id") != "" {
 		ProductID, err := strconv.Atoi(req.URL.Query().Get("product_id"))
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(req.Context()).WithFields(log.Fields{
 				"event": "error when parse to int for product_id query params",
 			}).Error(err)
-			pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-				Code:    400,
-				Message: "Terjadi kesalahan sistem",
-				Error:   err.Error(),
-			})
+			apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 			return
 		}
 		requestBody.ProductID = ProductID
 	}
 
-	// Payload Validation
-	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
+	if req.URL.Query().Get("order_id") != "" {
+		OrderID, err := strconv.Atoi(req.URL.Query().Get("order_id"))
+		if err != nil {
+			logging.FromContext(req.Context()).WithFields(log.Fields{
+				"event": "error when parse to int for order_id query params",
+			}).Error(err)
+			apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
+			return
 		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
+		requestBody.OrderID = OrderID
+	}
 
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+	// Payload Validation
+	if err := validate.Struct(requestBody); err != nil {
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersListAdmin(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -462,46 +297,29 @@ func (c *OrdersControllers) OrdersListAdmin(res http.ResponseWriter, req *http.R
 
 // OrdersApprove func
 func (c *OrdersControllers) OrdersApprove(res http.ResponseWriter, req *http.Request) {
-	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("PUT /orders/internal/{id}/approve payload body")
-
 	var requestBody *entities.OrdersApproveRequest
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
 	requestBody = &entities.OrdersApproveRequest{
 		UserID: TokenData.UserID,
+		Actor:  ordersActor(TokenData),
 	}
 
 	OrderID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get order id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -509,35 +327,13 @@ func (c *OrdersControllers) OrdersApprove(res http.ResponseWriter, req *http.Req
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.OrdersUsecase.OrdersApprove(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -547,46 +343,29 @@ func (c *OrdersControllers) OrdersApprove(res http.ResponseWriter, req *http.Req
 
 // OrdersReject func
 func (c *OrdersControllers) OrdersReject(res http.ResponseWriter, req *http.Request) {
-	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("PUT /orders/internal/{id}/reject payload body")
-
 	var requestBody *entities.OrdersRejectRequest
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
 	requestBody = &entities.OrdersRejectRequest{
 		UserID: TokenData.UserID,
+		Actor:  ordersActor(TokenData),
 	}
 
 	OrderID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get order id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -594,38 +373,87 @@ func (c *OrdersControllers) OrdersReject(res http.ResponseWriter, req *http.Requ
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
+		apperr.Write(res, req, apperr.Payload(err))
+		return
+	}
 
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
+	Response, err := c.OrdersUsecase.OrdersReject(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
 
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
+	pkg.Response(res, Response.Code, Response)
+	return
+}
 
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+// OrdersTransitionUsers func, move the order with the event of the path as its owner
+func (c *OrdersControllers) OrdersTransitionUsers(res http.ResponseWriter, req *http.Request) {
+	c.ordersTransition(res, req, entities.ActorOwner)
+}
+
+// OrdersTransitionAdmin func, move the order with the event of the path as admin,
+// or as system when the request come from internal services
+func (c *OrdersControllers) OrdersTransitionAdmin(res http.ResponseWriter, req *http.Request) {
+	c.ordersTransition(res, req, entities.ActorAdmin)
+}
+
+// ordersTransition func
+func (c *OrdersControllers) ordersTransition(res http.ResponseWriter, req *http.Request, Actor entities.OrdersActor) {
+	var requestBody *entities.OrdersTransitionRequest
+
+	TokenJSON := context.Get(req, "token").(string)
+	var TokenData *entities.TokenClaim
+	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal token",
+		}).Error(err)
+		apperr.Write(res, req, err)
 		return
 	}
 
-	Response, err := c.OrdersUsecase.OrdersReject(req.Context(), requestBody)
+	if Actor == entities.ActorAdmin {
+		Actor = ordersActor(TokenData)
+	}
+
+	requestBody = &entities.OrdersTransitionRequest{
+		UserID: TokenData.UserID,
+		Event:  entities.OrdersEvent(strings.ToUpper(mux.Vars(req)["event"])),
+		Actor:  Actor,
+	}
+
+	OrderID, err := strconv.Atoi(mux.Vars(req)["id"])
+	if err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when get order id from params",
+		}).Error(err)
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
+		return
+	}
+
+	requestBody.OrderID = OrderID
+
+	// Payload Validation
+	if err := validate.Struct(requestBody); err != nil {
+		apperr.Write(res, req, apperr.Payload(err))
+		return
+	}
+
+	Response, err := c.OrdersUsecase.OrdersTransition(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
 	pkg.Response(res, Response.Code, Response)
 	return
 }
+
+// ordersActor func, the actor of the admin routes: other services act as the
+// system and never as an admin
+func ordersActor(TokenData *entities.TokenClaim) entities.OrdersActor {
+	if TokenData.Service != "" {
+		return entities.ActorSystem
+	}
+	return entities.ActorAdmin
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// IdempotencyStatus string
+type IdempotencyStatus string
+
+// IdempotencyStatus Master
+const (
+	IdempotencyProcessing IdempotencyStatus = "PROCESSING"
+	IdempotencyCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyRecords struct, the first response of a request sent with an
+// Idempotency-Key. Fingerprint is the hash of the method, path and body
+type IdempotencyRecords struct {
+	Key         string            `json:"key"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      IdempotencyStatus `json:"status"`
+	StatusCode  int               `json:"status_code"`
+	Body        string            `json:"body"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
@@ -1,7 +1,10 @@
 package entities
 
 import (
+	"strings"
 	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/money"
 )
 
 // OrdersStatus int
@@ -13,6 +16,11 @@ const (
 	Approve
 	Reject
 	Cancel
+	Paid
+	Shipped
+	Delivered
+	Completed
+	Expired
 )
 
 // OrdersEvent string
@@ -20,69 +28,138 @@ type OrdersEvent string
 
 // OrdersEvent Master
 const (
-	EventCreate  OrdersEvent = "CREATE"
-	EventApprove OrdersEvent = "APPROVE"
-	EventReject  OrdersEvent = "REJECT"
-	EventCancel  OrdersEvent = "CANCEL"
-	EventUpdate  OrdersEvent = "UPDATE"
+	EventCreate   OrdersEvent = "CREATE"
+	EventApprove  OrdersEvent = "APPROVE"
+	EventReject   OrdersEvent = "REJECT"
+	EventCancel   OrdersEvent = "CANCEL"
+	EventUpdate   OrdersEvent = "UPDATE"
+	EventPay      OrdersEvent = "PAY"
+	EventShip     OrdersEvent = "SHIP"
+	EventDeliver  OrdersEvent = "DELIVER"
+	EventComplete OrdersEvent = "COMPLETE"
+	EventExpire   OrdersEvent = "EXPIRE"
+)
+
+// OrdersDomainEvent string, domain event published through the outbox
+type OrdersDomainEvent string
+
+// OrdersDomainEvent Master
+const (
+	EventOrderCreated   OrdersDomainEvent = "OrderCreated"
+	EventOrderUpdated   OrdersDomainEvent = "OrderUpdated"
+	EventOrderApproved  OrdersDomainEvent = "OrderApproved"
+	EventOrderRejected  OrdersDomainEvent = "OrderRejected"
+	EventOrderCancelled OrdersDomainEvent = "OrderCancelled"
+	EventOrderPaid      OrdersDomainEvent = "OrderPaid"
+	EventOrderShipped   OrdersDomainEvent = "OrderShipped"
+	EventOrderDelivered OrdersDomainEvent = "OrderDelivered"
+	EventOrderCompleted OrdersDomainEvent = "OrderCompleted"
+	EventOrderExpired   OrdersDomainEvent = "OrderExpired"
 )
 
+// OrdersDomainEvents map the log event of an order to its domain event
+var OrdersDomainEvents = map[OrdersEvent]OrdersDomainEvent{
+	EventCreate:   EventOrderCreated,
+	EventUpdate:   EventOrderUpdated,
+	EventApprove:  EventOrderApproved,
+	EventReject:   EventOrderRejected,
+	EventCancel:   EventOrderCancelled,
+	EventPay:      EventOrderPaid,
+	EventShip:     EventOrderShipped,
+	EventDeliver:  EventOrderDelivered,
+	EventComplete: EventOrderCompleted,
+	EventExpire:   EventOrderExpired,
+}
+
+// OrdersAggregate for outbox
+const OrdersAggregate = "orders"
+
+// OrdersItemsStatus int
+type OrdersItemsStatus int
+
+// OrdersItemsStatus Master
+const (
+	ItemsPending OrdersItemsStatus = iota + 1
+	ItemsApprove
+	ItemsReject
+	ItemsCancel
+)
+
+// LegacyReservationPrefix of the reservation of the lines moved into
+// orders_items by the 00003 migration. Their stock was taken before the
+// reservations existed, products services do not know the reservation until
+// the first stock step on the line adopt it
+const LegacyReservationPrefix = "legacy-"
+
+// IsLegacyReservation func
+func IsLegacyReservation(ReservationID string) bool {
+	return strings.HasPrefix(ReservationID, LegacyReservationPrefix)
+}
+
 // Orders struct
 type Orders struct {
-	ID          int          `db:"id" json:"id"`
-	UserID      int          `db:"user_id" json:"user_id"`
-	ProductID   int          `db:"product_id" json:"product_id"`
-	ProductName string       `db:"product_name" json:"product_name"`
-	Price       float32      `db:"price" json:"price"`
-	Qty         int          `db:"qty" json:"qty"`
-	TotalPrice  float32      `db:"total_price" json:"total_price"`
-	Status      OrdersStatus `db:"status" json:"status"`
-	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	ID         int            `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"user_id"`
+	TotalPrice money.Money    `db:"total_price" json:"total_price"`
+	Status     OrdersStatus   `db:"status" json:"status"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+	Items      []*OrdersItems `db:"-" json:"items"`
 }
 
 // OrdersLog struct
 type OrdersLog struct {
-	ID          int          `db:"id" json:"id"`
-	OrderID     int          `db:"order_id" json:"order_id"`
-	UserID      int          `db:"user_id" json:"user_id"`
-	ProductID   int          `db:"product_id" json:"product_id"`
-	ProductName string       `db:"product_name" json:"product_name"`
-	Price       float32      `db:"price" json:"price"`
-	Qty         int          `db:"qty" json:"qty"`
-	TotalPrice  float32      `db:"total_price" json:"total_price"`
-	Status      OrdersStatus `db:"status" json:"status"`
-	Event       OrdersEvent  `db:"event" json:"event"`
-	AdminID     int          `db:"admin_id" json:"admin_id"`
-	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	ID         int          `db:"id" json:"id"`
+	OrderID    int          `db:"order_id" json:"order_id"`
+	UserID     int          `db:"user_id" json:"user_id"`
+	TotalPrice money.Money  `db:"total_price" json:"total_price"`
+	Status     OrdersStatus `db:"status" json:"status"`
+	Event      OrdersEvent  `db:"event" json:"event"`
+	AdminID    int          `db:"admin_id" json:"admin_id"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time    `db:"updated_at" json:"updated_at"`
 }
 
 // OrdersItems struct
-// type OrdersItems struct {
-// 	ID          int               `db:"id" json:"id"`
-// 	OrderID     int               `db:"order_id" json:"order_id"`
-// 	ProductID   int               `db:"product_id" json:"product_id"`
-// 	ProductName string            `db:"product_name" json:"product_name"`
-// 	Price       float32           `db:"price" json:"price"`
-// 	Qty         int               `db:"qty" json:"qty"`
-// 	TotalPrice  float32           `db:"total_price" json:"total_price"`
-// 	Status      OrdersItemsStatus `db:"status" json:"status"`
-// 	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
-// 	UpdatedAt   time.Time         `db:"updated_at" json:"updated_at"`
-// }
+type OrdersItems struct {
+	ID            int               `db:"id" json:"id"`
+	OrderID       int               `db:"order_id" json:"order_id"`
+	ProductID     int               `db:"product_id" json:"product_id"`
+	ReservationID string            `db:"reservation_id" json:"reservation_id"`
+	ProductName   string            `db:"product_name" json:"product_name"`
+	Price         money.Money       `db:"price" json:"price"`
+	Qty           int               `db:"qty" json:"qty"`
+	TotalPrice    money.Money       `db:"total_price" json:"total_price"`
+	Status        OrdersItemsStatus `db:"status" json:"status"`
+	CreatedAt     time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time         `db:"updated_at" json:"updated_at"`
+}
 
 // OrdersItemsLog struct
-// type OrdersItemsLog struct {
-// 	ID          int               `db:"id" json:"id"`
-// 	OrderItemID int               `db:"order_item_id" json:"order_item_id"`
-// 	OrderID     int               `db:"order_id" json:"order_id"`
-// 	ProductID   int               `db:"product_id" json:"product_id"`
-// 	ProductName string            `db:"product_name" json:"product_name"`
-// 	Price       float32           `db:"price" json:"price"`
-// 	Qty         int               `db:"qty" json:"qty"`
-// 	TotalPrice  float32           `db:"total_price" json:"total_price"`
-// 	Status      OrdersItemsStatus `db:"status" json:"status"`
-// 	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
-// 	UpdatedAt   time.Time         `db:"updated_at" json:"updated_at"`
-// }
+type OrdersItemsLog struct {
+	ID          int               `db:"id" json:"id"`
+	OrderItemID int               `db:"order_item_id" json:"order_item_id"`
+	OrderID     int               `db:"order_id" json:"order_id"`
+	ProductID   int               `db:"product_id" json:"product_id"`
+	ProductName string            `db:"product_name" json:"product_name"`
+	Price       money.Money       `db:"price" json:"price"`
+	Qty         int               `db:"qty" json:"qty"`
+	TotalPrice  money.Money       `db:"total_price" json:"total_price"`
+	Status      OrdersItemsStatus `db:"status" json:"status"`
+	Event       OrdersEvent       `db:"event" json:"event"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time         `db:"updated_at" json:"updated_at"`
+}
+
+// OrdersTotalPrice func sum total price of every line that is not cancelled
+func OrdersTotalPrice(Items []*OrdersItems) (TotalPrice money.Money, err error) {
+	for _, Item := range Items {
+		if Item.Status == ItemsCancel {
+			continue
+		}
+		if TotalPrice, err = TotalPrice.Add(Item.TotalPrice); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return
+}
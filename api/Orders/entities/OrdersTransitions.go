@@ -0,0 +1,114 @@
+package entities
+
+// OrdersActor string, who trigger a transition
+type OrdersActor string
+
+// OrdersActor Master
+const (
+	ActorOwner  OrdersActor = "OWNER"
+	ActorAdmin  OrdersActor = "ADMIN"
+	ActorSystem OrdersActor = "SYSTEM"
+)
+
+// OrdersTransition struct, one allowed move of an order triggered by Event.
+// ItemsStatus 0 keep the status of the lines, ReleaseStock give the qty of
+// every open line back to products services with the Sagas saga
+type OrdersTransition struct {
+	Event        OrdersEvent
+	From         []OrdersStatus
+	To           OrdersStatus
+	Actors       []OrdersActor
+	ItemsStatus  OrdersItemsStatus
+	ReleaseStock bool
+	Sagas        SagasName
+}
+
+// OrdersTransitions is the lifecycle of an order, a new status only need its
+// transitions here. UPDATE keep the order pending, it is listed so editing the
+// lines follow the same guards
+var OrdersTransitions = map[OrdersEvent]*OrdersTransition{
+	EventUpdate: {
+		Event:  EventUpdate,
+		From:   []OrdersStatus{Pending},
+		To:     Pending,
+		Actors: []OrdersActor{ActorOwner},
+	},
+	EventCancel: {
+		Event:        EventCancel,
+		From:         []OrdersStatus{Pending},
+		To:           Cancel,
+		Actors:       []OrdersActor{ActorOwner},
+		ItemsStatus:  ItemsCancel,
+		ReleaseStock: true,
+		Sagas:        SagaOrdersCancel,
+	},
+	EventApprove: {
+		Event:       EventApprove,
+		From:        []OrdersStatus{Pending},
+		To:          Approve,
+		Actors:      []OrdersActor{ActorAdmin},
+		ItemsStatus: ItemsApprove,
+	},
+	EventReject: {
+		Event:        EventReject,
+		From:         []OrdersStatus{Pending},
+		To:           Reject,
+		Actors:       []OrdersActor{ActorAdmin},
+		ItemsStatus:  ItemsReject,
+		ReleaseStock: true,
+		Sagas:        SagaOrdersReject,
+	},
+	EventPay: {
+		Event:  EventPay,
+		From:   []OrdersStatus{Approve},
+		To:     Paid,
+		Actors: []OrdersActor{ActorAdmin, ActorSystem},
+	},
+	EventShip: {
+		Event:  EventShip,
+		From:   []OrdersStatus{Paid},
+		To:     Shipped,
+		Actors: []OrdersActor{ActorAdmin},
+	},
+	EventDeliver: {
+		Event:  EventDeliver,
+		From:   []OrdersStatus{Shipped},
+		To:     Delivered,
+		Actors: []OrdersActor{ActorAdmin, ActorSystem},
+	},
+	EventComplete: {
+		Event:  EventComplete,
+		From:   []OrdersStatus{Delivered},
+		To:     Completed,
+		Actors: []OrdersActor{ActorOwner, ActorSystem},
+	},
+	EventExpire: {
+		Event:        EventExpire,
+		From:         []OrdersStatus{Pending},
+		To:           Expired,
+		Actors:       []OrdersActor{ActorSystem},
+		ItemsStatus:  ItemsCancel,
+		ReleaseStock: true,
+		Sagas:        SagaOrdersExpire,
+	},
+}
+
+// CanFrom func
+func (t *OrdersTransition) CanFrom(Status OrdersStatus) bool {
+	for _, From := range t.From {
+		if From == Status {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedActor func
+func (t *OrdersTransition) AllowedActor(Actor OrdersActor) bool {
+	for _, Allowed := range t.Actors {
+		if Allowed == Actor {
+			return true
+		}
+	}
+	return false
+}
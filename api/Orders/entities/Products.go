@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/money"
+)
 
 // ProductsStatus int
 type ProductsStatus int
@@ -15,9 +19,31 @@ const (
 type Products struct {
 	ID        int            `db:"id" json:"id"`
 	Name      string         `db:"name" json:"name"`
-	Price     float32        `db:"price" json:"price"`
+	Price     money.Money    `db:"price" json:"price"`
 	Qty       int            `db:"qty" json:"qty"`
 	Status    ProductsStatus `db:"status" json:"status"`
 	CreatedAt time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
 }
+
+// StockReservationsStatus int
+type StockReservationsStatus int
+
+// StockReservationsStatus Master
+const (
+	StockReserved StockReservationsStatus = iota + 1
+	StockCommitted
+	StockReleased
+	StockExpired
+)
+
+// StockReservations struct
+type StockReservations struct {
+	ID        string                  `json:"id"`
+	ProductID int                     `json:"product_id"`
+	Qty       int                     `json:"qty"`
+	Status    StockReservationsStatus `json:"status"`
+	ExpiredAt time.Time               `json:"expired_at"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
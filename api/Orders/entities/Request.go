@@ -1,5 +1,7 @@
 package entities
 
+import "github.com/mrdhira/warpin-test/pkg/money"
+
 // OrdersListUsersRequest struct
 type OrdersListUsersRequest struct {
 	UserID int    `json:"user_id" validate:"required"`
@@ -9,18 +11,42 @@ type OrdersListUsersRequest struct {
 
 // OrdersCreateRequest struct
 type OrdersCreateRequest struct {
-	UserID      int     `json:"user_id" validate:"required"`
-	ProductID   int     `json:"product_id" validate:"required"`
-	ProductName string  `json:"product_name" validate:"required"`
-	Price       float32 `json:"price" validate:"required"`
-	Qty         int     `json:"qty" validate:"required"`
+	UserID int                         `json:"user_id" validate:"required"`
+	Items  []*OrdersItemsCreateRequest `json:"items" validate:"required,min=1,dive,required"`
+}
+
+// OrdersItemsCreateRequest struct, the name and price are taken from products
+// services. ExpectedPrice is the price shown to the user, the order is
+// refused when the product price is not the same anymore
+type OrdersItemsCreateRequest struct {
+	ProductID     int          `json:"product_id" validate:"required"`
+	Qty           int          `json:"qty" validate:"required,min=1"`
+	ExpectedPrice *money.Money `json:"expected_price,omitempty" validate:"omitempty,gt=0"`
 }
 
 // OrdersUpdateRequest struct
 type OrdersUpdateRequest struct {
-	UserID  int `json:"user_id" validate:"required"`
-	OrderID int `json:"order_id" validate:"required"`
-	Qty     int `json:"qty" validate:"required"`
+	UserID  int                         `json:"user_id" validate:"required"`
+	OrderID int                         `json:"order_id" validate:"required"`
+	Items   []*OrdersItemsUpdateRequest `json:"items" validate:"required,min=1,dive,required"`
+}
+
+// OrdersItemsUpdateRequest struct, qty 0 remove the product from the order.
+// ExpectedPrice is checked against the current price of the product in
+// products services, a line already in the order keep its own price
+type OrdersItemsUpdateRequest struct {
+	ProductID     int          `json:"product_id" validate:"required"`
+	Qty           int          `json:"qty" validate:"min=0"`
+	ExpectedPrice *money.Money `json:"expected_price,omitempty" validate:"omitempty,gt=0"`
+}
+
+// PriceChangedResponse struct, data of the 409 when the expected price is not
+// the price of the product anymore
+type PriceChangedResponse struct {
+	ProductID     int         `json:"product_id"`
+	ProductName   string      `json:"product_name"`
+	ExpectedPrice money.Money `json:"expected_price"`
+	Price         money.Money `json:"price"`
 }
 
 // OrdersCancelRequest struct
@@ -35,18 +61,29 @@ type OrdersListAdminRequest struct {
 	Offset    string `json:"offset" validate:"required"`
 	Status    int    `json:"status" validate:"-"`
 	ProductID int    `json:"product_id" validate:"-"`
+	OrderID   int    `json:"order_id" validate:"-"`
 }
 
-// OrdersApproveRequest struct
+// OrdersApproveRequest struct, Actor is set by the route the request came from
 type OrdersApproveRequest struct {
-	UserID  int `json:"user_id" validate:"required"`
-	OrderID int `json:"order_id" validate:"required"`
+	UserID  int         `json:"user_id" validate:"-"`
+	OrderID int         `json:"order_id" validate:"required"`
+	Actor   OrdersActor `json:"actor" validate:"required"`
 }
 
-// OrdersRejectRequest struct
+// OrdersRejectRequest struct, Actor is set by the route the request came from
 type OrdersRejectRequest struct {
-	UserID  int `json:"user_id" validate:"required"`
-	OrderID int `json:"order_id" validate:"required"`
+	UserID  int         `json:"user_id" validate:"-"`
+	OrderID int         `json:"order_id" validate:"required"`
+	Actor   OrdersActor `json:"actor" validate:"required"`
+}
+
+// OrdersTransitionRequest struct, Actor is set by the route the request came from
+type OrdersTransitionRequest struct {
+	UserID  int         `json:"user_id" validate:"-"`
+	OrderID int         `json:"order_id" validate:"required"`
+	Event   OrdersEvent `json:"event" validate:"required"`
+	Actor   OrdersActor `json:"actor" validate:"required"`
 }
 
 // GetProductsByIDPayload struct
@@ -54,23 +91,11 @@ type GetProductsByIDPayload struct {
 	ProductID int `json:"product_id"`
 }
 
-// GetProductsByIDResponse struct
-type GetProductsByIDResponse struct {
-	Code    int       `json:"code"`
-	Message string    `json:"message"`
-	Error   string    `json:"error"`
-	Data    *Products `json:"data"`
-}
-
-// ProductsUpdatePayload struct
-type ProductsUpdatePayload struct {
-	UserID    int `json:"user_id" validate:"required"`
-	ProductID int `json:"product_id" validate:"required"`
-	Qty       int `json:"qty,omitempty" validate:"-"`
-}
-
-// ProductsUpdateResponse struct
-type ProductsUpdateResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+// ReserveStockPayload struct, Adopt record the stock a legacy line already
+// took instead of taking it (see LegacyReservationPrefix)
+type ReserveStockPayload struct {
+	ReservationID string `json:"reservation_id"`
+	ProductID     int    `json:"product_id"`
+	Qty           int    `json:"qty"`
+	Adopt         bool   `json:"adopt,omitempty"`
 }
@@ -0,0 +1,82 @@
+package entities
+
+import "time"
+
+// SagasName string
+type SagasName string
+
+// SagasName Master
+const (
+	SagaOrdersCreate SagasName = "ORDERS_CREATE"
+	SagaOrdersUpdate SagasName = "ORDERS_UPDATE"
+	SagaOrdersCancel SagasName = "ORDERS_CANCEL"
+	SagaOrdersReject SagasName = "ORDERS_REJECT"
+	SagaOrdersExpire SagasName = "ORDERS_EXPIRE"
+)
+
+// SagasStatus int
+type SagasStatus int
+
+// SagasStatus Master
+const (
+	SagasRunning SagasStatus = iota + 1
+	SagasCompleted
+	SagasCompensating
+	SagasCompensated
+	SagasFailed
+)
+
+// SagasStepsStatus int
+type SagasStepsStatus int
+
+// SagasStepsStatus Master
+const (
+	StepsPending SagasStepsStatus = iota + 1
+	StepsExecuting
+	StepsDone
+	StepsCompensating
+	StepsCompensated
+)
+
+// SagasAction string
+type SagasAction string
+
+// SagasAction Master
+const (
+	ActionStockReserve SagasAction = "STOCK_RESERVE"
+	ActionStockCommit  SagasAction = "STOCK_COMMIT"
+	ActionStockRelease SagasAction = "STOCK_RELEASE"
+)
+
+// Sagas struct
+type Sagas struct {
+	ID        int           `db:"id" json:"id"`
+	Name      SagasName     `db:"name" json:"name"`
+	OrderID   int           `db:"order_id" json:"order_id"`
+	Status    SagasStatus   `db:"status" json:"status"`
+	Error     string        `db:"error" json:"error"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `db:"updated_at" json:"updated_at"`
+	Steps     []*SagasSteps `db:"-" json:"steps"`
+}
+
+// SagasSteps struct, Payload is the JSON of the action payload
+type SagasSteps struct {
+	ID        int              `db:"id" json:"id"`
+	SagaID    int              `db:"saga_id" json:"saga_id"`
+	Sequence  int              `db:"sequence" json:"sequence"`
+	Action    SagasAction      `db:"action" json:"action"`
+	Payload   string           `db:"payload" json:"payload"`
+	Status    SagasStepsStatus `db:"status" json:"status"`
+	CreatedAt time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time        `db:"updated_at" json:"updated_at"`
+}
+
+// SagasStockPayload struct, Qty is the qty the reservation is moved to and
+// PreviousQty the qty it held before the step, so the step can be compensated
+type SagasStockPayload struct {
+	ReservationID string `json:"reservation_id"`
+	ProductID     int    `json:"product_id"`
+	Qty           int    `json:"qty"`
+	PreviousQty   int    `json:"previous_qty"`
+}
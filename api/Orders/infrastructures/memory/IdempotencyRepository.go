@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+)
+
+// IdempotencyRepository struct, repositories.IIdempotencyRepository kept in
+// memory for the tests
+type IdempotencyRepository struct {
+	mu        sync.Mutex
+	Records   map[string]*entities.IdempotencyRecords
+	ExpiredAt map[string]time.Time
+}
+
+// NewIdempotencyRepository func
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{
+		Records:   map[string]*entities.IdempotencyRecords{},
+		ExpiredAt: map[string]time.Time{},
+	}
+}
+
+// IdempotencyReserve func
+func (r *IdempotencyRepository) IdempotencyReserve(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (Existing *entities.IdempotencyRecords, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if Stored, ok := r.Records[Key]; ok && time.Now().Before(r.ExpiredAt[Key]) {
+		Copy := *Stored
+		return &Copy, nil
+	}
+
+	r.store(Key, Records, TTL)
+	return
+}
+
+// IdempotencyStore func
+func (r *IdempotencyRepository) IdempotencyStore(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store(Key, Records, TTL)
+	return
+}
+
+// IdempotencyRenew func
+func (r *IdempotencyRepository) IdempotencyRenew(ctx context.Context, Key string, TTL time.Duration) (Renewed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.Records[Key]; !ok || !time.Now().Before(r.ExpiredAt[Key]) {
+		return false, nil
+	}
+
+	r.ExpiredAt[Key] = time.Now().Add(TTL)
+	return true, nil
+}
+
+// IdempotencyDelete func
+func (r *IdempotencyRepository) IdempotencyDelete(ctx context.Context, Key string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Records, Key)
+	delete(r.ExpiredAt, Key)
+	return
+}
+
+// store func
+func (r *IdempotencyRepository) store(Key string, Records *entities.IdempotencyRecords, TTL time.Duration) {
+	Copy := *Records
+	r.Records[Key] = &Copy
+	r.ExpiredAt[Key] = time.Now().Add(TTL)
+}
@@ -0,0 +1,359 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// OrdersRepository struct, repositories.IOrdersRepository kept in memory for
+// the tests. Errors hold the error a method return by its name, to test the
+// paths where the database fail
+type OrdersRepository struct {
+	mu             sync.Mutex
+	Orders         map[int]*entities.Orders
+	OrdersLog      []*entities.OrdersLog
+	OrdersItems    map[int]*entities.OrdersItems
+	OrdersItemsLog []*entities.OrdersItemsLog
+	Outbox         []*outbox.Message
+	Errors         map[string]error
+
+	// the ids are sequences, a rollback does not give them back
+	lastOrdersID         int
+	lastOrdersLogID      int
+	lastOrdersItemsID    int
+	lastOrdersItemsLogID int
+	lastOutboxID         int
+}
+
+// NewOrdersRepository func
+func NewOrdersRepository() *OrdersRepository {
+	return &OrdersRepository{
+		Orders:      map[int]*entities.Orders{},
+		OrdersItems: map[int]*entities.OrdersItems{},
+		Errors:      map[string]error{},
+	}
+}
+
+// Tx func
+func (r *OrdersRepository) Tx() (Tx transaction.Tx, err error) {
+	if err = r.fail("Tx"); err != nil {
+		return
+	}
+	return transaction.NewMemory(), nil
+}
+
+// OrdersFind func, ordered by id. The product_id condition match the orders
+// with a line of the product
+func (r *OrdersRepository) OrdersFind(ctx context.Context, Limit int, Offset int, Condition map[string]interface{}) (Orders []*entities.Orders, err error) {
+	if err = r.fail("OrdersFind"); err != nil {
+		return
+	}
+
+	OrdersCondition := map[string]interface{}{}
+	ProductID, ByProduct := Condition["product_id"]
+	for Column, Value := range Condition {
+		if Column != "product_id" {
+			OrdersCondition[Column] = Value
+		}
+	}
+
+	return r.ordersFind(Limit, Offset, func(Stored *entities.Orders) bool {
+		if !memstore.Match(Stored, OrdersCondition) {
+			return false
+		}
+		if !ByProduct {
+			return true
+		}
+		for _, OrdersItem := range r.OrdersItems {
+			if OrdersItem.OrderID == Stored.ID && memstore.Match(OrdersItem, map[string]interface{}{"product_id": ProductID}) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// OrdersFindByUserID func
+func (r *OrdersRepository) OrdersFindByUserID(ctx context.Context, Limit int, Offset int, UserID int) (Orders []*entities.Orders, err error) {
+	if err = r.fail("OrdersFindByUserID"); err != nil {
+		return
+	}
+
+	return r.ordersFind(Limit, Offset, func(Stored *entities.Orders) bool {
+		return Stored.UserID == UserID
+	}), nil
+}
+
+// OrdersFindByID func
+func (r *OrdersRepository) OrdersFindByID(ctx context.Context, ID int) (Orders *entities.Orders, err error) {
+	if err = r.fail("OrdersFindByID"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if Stored, ok := r.Orders[ID]; ok {
+		Copy := *Stored
+		return &Copy, nil
+	}
+	return
+}
+
+// OrdersStore func, the lines are stored with OrdersItemsStore
+func (r *OrdersRepository) OrdersStore(ctx context.Context, Tx transaction.Tx, Orders *entities.Orders) (ID int, err error) {
+	if err = r.fail("OrdersStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOrdersID++
+	ID = r.lastOrdersID
+	Copy := *Orders
+	Copy.ID = ID
+	Copy.Items = nil
+	r.Orders[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.Orders, ID)
+	})
+	return
+}
+
+// OrdersLogStore func
+func (r *OrdersRepository) OrdersLogStore(ctx context.Context, Tx transaction.Tx, OrdersLog *entities.OrdersLog) (ID int, err error) {
+	if err = r.fail("OrdersLogStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOrdersLogID++
+	ID = r.lastOrdersLogID
+	Copy := *OrdersLog
+	Copy.ID = ID
+	r.OrdersLog = append(r.OrdersLog, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, Stored := range r.OrdersLog {
+			if Stored.ID == ID {
+				r.OrdersLog = append(r.OrdersLog[:i], r.OrdersLog[i+1:]...)
+				break
+			}
+		}
+	})
+	return
+}
+
+// OrdersUpdate func
+func (r *OrdersRepository) OrdersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("OrdersUpdate"); err != nil {
+		return
+	}
+
+	_, err = r.ordersUpdate(Tx, ID, nil, Payload)
+	return
+}
+
+// OrdersUpdateStatus func update the order only when it is still in From
+func (r *OrdersRepository) OrdersUpdateStatus(ctx context.Context, Tx transaction.Tx, ID int, From entities.OrdersStatus, Payload map[string]interface{}) (Updated bool, err error) {
+	if err = r.fail("OrdersUpdateStatus"); err != nil {
+		return
+	}
+
+	return r.ordersUpdate(Tx, ID, &From, Payload)
+}
+
+// OrdersFindStale func
+func (r *OrdersRepository) OrdersFindStale(ctx context.Context, Status entities.OrdersStatus, UpdatedBefore time.Time, Limit int) (Orders []*entities.Orders, err error) {
+	if err = r.fail("OrdersFindStale"); err != nil {
+		return
+	}
+
+	Orders = r.ordersFind(0, 0, func(Stored *entities.Orders) bool {
+		return Stored.Status == Status && Stored.UpdatedAt.Before(UpdatedBefore)
+	})
+	sort.SliceStable(Orders, func(i, j int) bool {
+		return Orders[i].UpdatedAt.Before(Orders[j].UpdatedAt)
+	})
+
+	From, To := memstore.Page(len(Orders), Limit, 0)
+	return Orders[From:To], nil
+}
+
+// OrdersItemsFindByOrderIDs func, ordered by id
+func (r *OrdersRepository) OrdersItemsFindByOrderIDs(ctx context.Context, OrderIDs []int) (OrdersItems []*entities.OrdersItems, err error) {
+	if err = r.fail("OrdersItemsFindByOrderIDs"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ID := 1; ID <= r.lastOrdersItemsID; ID++ {
+		Stored, ok := r.OrdersItems[ID]
+		if !ok {
+			continue
+		}
+		for _, OrderID := range OrderIDs {
+			if Stored.OrderID == OrderID {
+				Copy := *Stored
+				OrdersItems = append(OrdersItems, &Copy)
+				break
+			}
+		}
+	}
+	return
+}
+
+// OrdersItemsStore func
+func (r *OrdersRepository) OrdersItemsStore(ctx context.Context, Tx transaction.Tx, OrdersItems *entities.OrdersItems) (ID int, err error) {
+	if err = r.fail("OrdersItemsStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOrdersItemsID++
+	ID = r.lastOrdersItemsID
+	Copy := *OrdersItems
+	Copy.ID = ID
+	r.OrdersItems[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.OrdersItems, ID)
+	})
+	return
+}
+
+// OrdersItemsLogStore func
+func (r *OrdersRepository) OrdersItemsLogStore(ctx context.Context, Tx transaction.Tx, OrdersItemsLog *entities.OrdersItemsLog) (ID int, err error) {
+	if err = r.fail("OrdersItemsLogStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOrdersItemsLogID++
+	ID = r.lastOrdersItemsLogID
+	Copy := *OrdersItemsLog
+	Copy.ID = ID
+	r.OrdersItemsLog = append(r.OrdersItemsLog, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, Stored := range r.OrdersItemsLog {
+			if Stored.ID == ID {
+				r.OrdersItemsLog = append(r.OrdersItemsLog[:i], r.OrdersItemsLog[i+1:]...)
+				break
+			}
+		}
+	})
+	return
+}
+
+// OrdersItemsUpdate func
+func (r *OrdersRepository) OrdersItemsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("OrdersItemsUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.OrdersItems[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return
+}
+
+// OutboxStore func
+func (r *OrdersRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	if err = r.fail("OutboxStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOutboxID++
+	ID = r.lastOutboxID
+	Copy := *Message
+	Copy.ID = ID
+	r.Outbox = append(r.Outbox, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Outbox = outboxRemove(r.Outbox, ID)
+	})
+	return
+}
+
+// ordersFind func, the orders Match accept ordered by id
+func (r *OrdersRepository) ordersFind(Limit int, Offset int, Match func(Stored *entities.Orders) bool) []*entities.Orders {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Orders := []*entities.Orders{}
+	for ID := 1; ID <= r.lastOrdersID; ID++ {
+		if Stored, ok := r.Orders[ID]; ok && Match(Stored) {
+			Copy := *Stored
+			Orders = append(Orders, &Copy)
+		}
+	}
+
+	From, To := memstore.Page(len(Orders), Limit, Offset)
+	return Orders[From:To]
+}
+
+// ordersUpdate func, From nil update the order whatever its status
+func (r *OrdersRepository) ordersUpdate(Tx transaction.Tx, ID int, From *entities.OrdersStatus, Payload map[string]interface{}) (Updated bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Orders[ID]
+	if !ok || (From != nil && Stored.Status != *From) {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return true, nil
+}
+
+// fail func
+func (r *OrdersRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
@@ -0,0 +1,21 @@
+package memory
+
+import "github.com/mrdhira/warpin-test/pkg/outbox"
+
+// OutboxEvents func, the events stored in the outbox in order
+func OutboxEvents(Outbox []*outbox.Message) (Events []string) {
+	for _, Message := range Outbox {
+		Events = append(Events, Message.Event)
+	}
+	return
+}
+
+// outboxRemove func
+func outboxRemove(Outbox []*outbox.Message, ID int) []*outbox.Message {
+	for i, Message := range Outbox {
+		if Message.ID == ID {
+			return append(Outbox[:i], Outbox[i+1:]...)
+		}
+	}
+	return Outbox
+}
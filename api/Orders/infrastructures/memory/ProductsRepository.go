@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+)
+
+// ProductsRepository struct, products services seen by orders services kept in
+// memory for the tests. The reservations move the stock of Products like
+// products services do. Err is returned by every call when it is set, e.g.
+// serviceclient.ErrCircuitOpen
+type ProductsRepository struct {
+	mu           sync.Mutex
+	Products     map[int]*entities.Products
+	Reservations map[string]*entities.StockReservations
+	Err          error
+}
+
+// NewProductsRepository func
+func NewProductsRepository(Products ...*entities.Products) *ProductsRepository {
+	r := &ProductsRepository{
+		Products:     map[int]*entities.Products{},
+		Reservations: map[string]*entities.StockReservations{},
+	}
+	for _, Product := range Products {
+		Copy := *Product
+		r.Products[Copy.ID] = &Copy
+	}
+	return r
+}
+
+// GetProductsByID func, an unknown product is a 404 like the response of
+// products services
+func (r *ProductsRepository) GetProductsByID(ctx context.Context, Payload *entities.GetProductsByIDPayload) (Products *entities.Products, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	Stored, ok := r.Products[Payload.ProductID]
+	if !ok {
+		return nil, &serviceclient.ResponseError{
+			Service:    "products",
+			StatusCode: 404,
+			Code:       404,
+			Message:    "Product tidak ditemukan",
+			ErrorCode:  "product_not_found",
+		}
+	}
+
+	Copy := *Stored
+	return &Copy, nil
+}
+
+// ReserveStock func move the reservation to Payload.Qty
+func (r *ProductsRepository) ReserveStock(ctx context.Context, Payload *entities.ReserveStockPayload) (StockReservations *entities.StockReservations, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	Stored, ok := r.Reservations[Payload.ReservationID]
+	if Payload.Adopt {
+		if !ok {
+			Stored = &entities.StockReservations{
+				ID:        Payload.ReservationID,
+				ProductID: Payload.ProductID,
+				Qty:       Payload.Qty,
+				Status:    entities.StockCommitted,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			r.Reservations[Stored.ID] = Stored
+		}
+
+		Copy := *Stored
+		return &Copy, nil
+	}
+
+	if !ok || Stored.Status == entities.StockReleased || Stored.Status == entities.StockExpired {
+		Stored = &entities.StockReservations{
+			ID:        Payload.ReservationID,
+			ProductID: Payload.ProductID,
+			Status:    entities.StockReserved,
+			ExpiredAt: time.Now().Add(time.Minute * 15),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	Products, ok := r.Products[Payload.ProductID]
+	Delta := Payload.Qty - Stored.Qty
+	if !ok || (Delta > 0 && (Products.Status != entities.Active || Products.Qty < Delta)) {
+		return nil, &serviceclient.ResponseError{
+			Service:    "products",
+			StatusCode: 422,
+			Code:       422,
+			Message:    "Stok produk tidak mencukupi atau produk sedang tidak aktif",
+			ErrorCode:  "stock_insufficient",
+		}
+	}
+
+	Products.Qty -= Delta
+	Stored.Qty = Payload.Qty
+	Stored.UpdatedAt = time.Now()
+	if Stored.Qty == 0 {
+		Stored.Status = entities.StockReleased
+	}
+	r.Reservations[Stored.ID] = Stored
+
+	Copy := *Stored
+	return &Copy, nil
+}
+
+// ReleaseStock func
+func (r *ProductsRepository) ReleaseStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	Stored, ok := r.Reservations[ReservationID]
+	if !ok {
+		return nil, repositories.ErrStockReservationNotFound
+	}
+
+	if Stored.Status == entities.StockReserved || Stored.Status == entities.StockCommitted {
+		if Products, ok := r.Products[Stored.ProductID]; ok {
+			Products.Qty += Stored.Qty
+		}
+		Stored.Qty = 0
+		Stored.Status = entities.StockReleased
+		Stored.UpdatedAt = time.Now()
+	}
+
+	Copy := *Stored
+	return &Copy, nil
+}
+
+// CommitStock func
+func (r *ProductsRepository) CommitStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	Stored, ok := r.Reservations[ReservationID]
+	if !ok {
+		return nil, repositories.ErrStockReservationNotFound
+	}
+
+	if Stored.Status == entities.StockReleased || Stored.Status == entities.StockExpired {
+		return nil, &serviceclient.ResponseError{
+			Service:    "products",
+			StatusCode: 422,
+			Code:       422,
+			Message:    "Reservasi " + ReservationID + " sudah tidak berlaku",
+			ErrorCode:  "reservation_expired",
+		}
+	}
+
+	Stored.Status = entities.StockCommitted
+	Stored.UpdatedAt = time.Now()
+
+	Copy := *Stored
+	return &Copy, nil
+}
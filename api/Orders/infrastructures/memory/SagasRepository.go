@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// SagasRepository struct, repositories.ISagasRepository kept in memory for
+// the tests
+type SagasRepository struct {
+	mu         sync.Mutex
+	Sagas      map[int]*entities.Sagas
+	SagasSteps map[int]*entities.SagasSteps
+	Errors     map[string]error
+
+	// the ids are sequences, a rollback does not give them back
+	lastSagasID      int
+	lastSagasStepsID int
+}
+
+// NewSagasRepository func
+func NewSagasRepository() *SagasRepository {
+	return &SagasRepository{
+		Sagas:      map[int]*entities.Sagas{},
+		SagasSteps: map[int]*entities.SagasSteps{},
+		Errors:     map[string]error{},
+	}
+}
+
+// Tx func
+func (r *SagasRepository) Tx() (Tx transaction.Tx, err error) {
+	if err = r.fail("Tx"); err != nil {
+		return
+	}
+	return transaction.NewMemory(), nil
+}
+
+// SagasFindStale func, ordered by id
+func (r *SagasRepository) SagasFindStale(ctx context.Context, Status []entities.SagasStatus, UpdatedBefore time.Time, Limit int) (Sagas []*entities.Sagas, err error) {
+	if err = r.fail("SagasFindStale"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ID := 1; ID <= r.lastSagasID; ID++ {
+		Stored, ok := r.Sagas[ID]
+		if !ok || !Stored.UpdatedAt.Before(UpdatedBefore) {
+			continue
+		}
+		for _, Want := range Status {
+			if Stored.Status == Want {
+				Copy := *Stored
+				Sagas = append(Sagas, &Copy)
+				break
+			}
+		}
+	}
+
+	From, To := memstore.Page(len(Sagas), Limit, 0)
+	return Sagas[From:To], nil
+}
+
+// SagasStepsFindBySagaID func, ordered by sequence
+func (r *SagasRepository) SagasStepsFindBySagaID(ctx context.Context, SagaID int) (SagasSteps []*entities.SagasSteps, err error) {
+	if err = r.fail("SagasStepsFindBySagaID"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, Stored := range r.SagasSteps {
+		if Stored.SagaID == SagaID {
+			Copy := *Stored
+			SagasSteps = append(SagasSteps, &Copy)
+		}
+	}
+	sort.Slice(SagasSteps, func(i, j int) bool {
+		return SagasSteps[i].Sequence < SagasSteps[j].Sequence
+	})
+	return
+}
+
+// SagasStore func
+func (r *SagasRepository) SagasStore(ctx context.Context, Tx transaction.Tx, Sagas *entities.Sagas) (ID int, err error) {
+	if err = r.fail("SagasStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSagasID++
+	ID = r.lastSagasID
+	Copy := *Sagas
+	Copy.ID = ID
+	Copy.Steps = nil
+	r.Sagas[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.Sagas, ID)
+	})
+	return
+}
+
+// SagasStepsStore func
+func (r *SagasRepository) SagasStepsStore(ctx context.Context, Tx transaction.Tx, SagasSteps *entities.SagasSteps) (ID int, err error) {
+	if err = r.fail("SagasStepsStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSagasStepsID++
+	ID = r.lastSagasStepsID
+	Copy := *SagasSteps
+	Copy.ID = ID
+	r.SagasSteps[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.SagasSteps, ID)
+	})
+	return
+}
+
+// SagasUpdate func
+func (r *SagasRepository) SagasUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("SagasUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Sagas[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return
+}
+
+// SagasStepsUpdate func
+func (r *SagasRepository) SagasStepsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("SagasStepsUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.SagasSteps[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return
+}
+
+// fail func
+func (r *SagasRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// IIdempotencyRepository interface
+type IIdempotencyRepository interface {
+	IdempotencyReserve(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (Existing *entities.IdempotencyRecords, err error)
+	IdempotencyStore(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (err error)
+	IdempotencyRenew(ctx context.Context, Key string, TTL time.Duration) (Renewed bool, err error)
+	IdempotencyDelete(ctx context.Context, Key string) (err error)
+}
+
+// IdempotencyRepository struct, the records are only kept in redis
+type IdempotencyRepository struct {
+	Redis database.IRedisConnection
+}
+
+// IdempotencyReserveAttempts is how many times IdempotencyReserve try a key
+// that expire between its SETNX and its GET
+const IdempotencyReserveAttempts = 3
+
+// ErrIdempotencyKeyBusy returned when the key still change after every attempt
+var ErrIdempotencyKeyBusy = errors.New("idempotency key is busy")
+
+// IdempotencyReserve func store Records for TTL when Key is free, otherwise the
+// record already stored under Key is returned and nothing is changed
+func (r *IdempotencyRepository) IdempotencyReserve(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (Existing *entities.IdempotencyRecords, err error) {
+	Client := r.Redis.Client().WithContext(ctx)
+
+	RecordsJSON, err := json.Marshal(Records)
+	if err != nil {
+		return
+	}
+
+	for Attempt := 0; Attempt < IdempotencyReserveAttempts; Attempt++ {
+		Reserved, err := Client.SetNX(Key, RecordsJSON, TTL).Result()
+		if err != nil {
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event": "error when reserve idempotency key",
+			}).Error(err)
+			return nil, err
+		}
+
+		if Reserved {
+			return nil, nil
+		}
+
+		Value, err := Client.Get(Key).Result()
+		if err == redis.Nil {
+			// expired between the two calls, try again
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event": "error when get idempotency key",
+			}).Error(err)
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(Value), &Existing)
+		return Existing, err
+	}
+
+	return nil, ErrIdempotencyKeyBusy
+}
+
+// IdempotencyStore func
+func (r *IdempotencyRepository) IdempotencyStore(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (err error) {
+	RecordsJSON, err := json.Marshal(Records)
+	if err != nil {
+		return
+	}
+
+	err = r.Redis.Client().WithContext(ctx).Set(Key, RecordsJSON, TTL).Err()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store idempotency key",
+		}).Error(err)
+	}
+
+	return
+}
+
+// IdempotencyRenew func keep Key for TTL from now, Renewed is false when the
+// key is already gone
+func (r *IdempotencyRepository) IdempotencyRenew(ctx context.Context, Key string, TTL time.Duration) (Renewed bool, err error) {
+	Renewed, err = r.Redis.Client().WithContext(ctx).Expire(Key, TTL).Result()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when renew idempotency key",
+		}).Error(err)
+	}
+
+	return
+}
+
+// IdempotencyDelete func
+func (r *IdempotencyRepository) IdempotencyDelete(ctx context.Context, Key string) (err error) {
+	err = r.Redis.Client().WithContext(ctx).Del(Key).Err()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when delete idempotency key",
+		}).Error(err)
+	}
+
+	return
+}
@@ -7,21 +7,31 @@ import (
 	"time"
 
 	redis "github.com/go-redis/redis/v7"
-	dbr "github.com/gocraft/dbr/v2"
 	"github.com/mrdhira/warpin-test/api/Orders/entities"
 	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
 )
 
 // IOrdersRepository interface
 type IOrdersRepository interface {
-	Tx() (tx *dbr.Tx, err error)
+	Tx() (Tx transaction.Tx, err error)
 	OrdersFind(ctx context.Context, Limit int, Offset int, Condition map[string]interface{}) (Orders []*entities.Orders, err error)
 	OrdersFindByUserID(ctx context.Context, Limit int, Offset int, UserID int) (Orders []*entities.Orders, err error)
 	OrdersFindByID(ctx context.Context, ID int) (Orders *entities.Orders, err error)
-	OrdersStore(ctx context.Context, db *dbr.Tx, Orders *entities.Orders) (ID int, err error)
-	OrdersLogStore(ctx context.Context, db *dbr.Tx, OrdersLog *entities.OrdersLog) (ID int, err error)
-	OrdersUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error)
+	OrdersStore(ctx context.Context, Tx transaction.Tx, Orders *entities.Orders) (ID int, err error)
+	OrdersLogStore(ctx context.Context, Tx transaction.Tx, OrdersLog *entities.OrdersLog) (ID int, err error)
+	OrdersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+	OrdersUpdateStatus(ctx context.Context, Tx transaction.Tx, ID int, From entities.OrdersStatus, Payload map[string]interface{}) (Updated bool, err error)
+	OrdersFindStale(ctx context.Context, Status entities.OrdersStatus, UpdatedBefore time.Time, Limit int) (Orders []*entities.Orders, err error)
+	OrdersItemsFindByOrderIDs(ctx context.Context, OrderIDs []int) (OrdersItems []*entities.OrdersItems, err error)
+	OrdersItemsStore(ctx context.Context, Tx transaction.Tx, OrdersItems *entities.OrdersItems) (ID int, err error)
+	OrdersItemsLogStore(ctx context.Context, Tx transaction.Tx, OrdersItemsLog *entities.OrdersItemsLog) (ID int, err error)
+	OrdersItemsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+	OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error)
 }
 
 // OrdersRepository struct
@@ -31,17 +41,18 @@ type OrdersRepository struct {
 }
 
 // Tx func to create new transaction
-func (r *OrdersRepository) Tx() (tx *dbr.Tx, err error) {
+func (r *OrdersRepository) Tx() (Tx transaction.Tx, err error) {
 	db := r.PG.PostgresTrade()
 
-	tx, err = db.Begin()
+	tx, err := db.Begin()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"event": "error when begin transaction in postgres",
 		}).Error(err)
+		return
 	}
 
-	return
+	return tx, nil
 }
 
 // OrdersFind func
@@ -51,6 +62,11 @@ func (r *OrdersRepository) OrdersFind(ctx context.Context, Limit int, Offset int
 	Query := db.Select("*").From("orders")
 
 	for key, val := range Condition {
+		// product lives on the order lines
+		if key == "product_id" {
+			Query.Where("id IN (SELECT order_id FROM orders_items WHERE product_id = ?)", val)
+			continue
+		}
 		Query.Where(key+" = ?", val)
 	}
 
@@ -59,7 +75,7 @@ func (r *OrdersRepository) OrdersFind(ctx context.Context, Limit int, Offset int
 		Offset(uint64(Offset)).
 		LoadContext(ctx, &Orders)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when query orders find",
 		}).Error(err)
 		return
@@ -76,7 +92,7 @@ func (r *OrdersRepository) OrdersFindByUserID(ctx context.Context, Limit int, Of
 	CacheKey := fmt.Sprintf("orders:users:%d:%d:%d", UserID, Limit, Offset)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache orders by user id",
 		}).Error(err)
 	}
@@ -92,7 +108,7 @@ func (r *OrdersRepository) OrdersFindByUserID(ctx context.Context, Limit int, Of
 			Offset(uint64(Offset)).
 			LoadContext(ctx, &Orders)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query orders find by user id",
 			}).Error(err)
 			return
@@ -102,7 +118,7 @@ func (r *OrdersRepository) OrdersFindByUserID(ctx context.Context, Limit int, Of
 		OrdersJSON, _ := json.Marshal(Orders)
 		err = r.Redis.Client().Set(CacheKey, OrdersJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for orders by user id",
 			}).Error(err)
 		}
@@ -120,8 +136,9 @@ func (r *OrdersRepository) OrdersFindByID(ctx context.Context, ID int) (Orders *
 	// Check Cache
 	CacheKey := fmt.Sprintf("orders:id:%d", ID)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
+	metrics.Cache("orders", "orders:id", err)
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache orders by id",
 		}).Error(err)
 	}
@@ -136,7 +153,7 @@ func (r *OrdersRepository) OrdersFindByID(ctx context.Context, ID int) (Orders *
 			Limit(1).
 			LoadContext(ctx, &Orders)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query orders find by id",
 			}).Error(err)
 			return
@@ -146,7 +163,7 @@ func (r *OrdersRepository) OrdersFindByID(ctx context.Context, ID int) (Orders *
 		OrdersJSON, _ := json.Marshal(Orders)
 		err = r.Redis.Client().Set(CacheKey, OrdersJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for orders by id",
 			}).Error(err)
 		}
@@ -158,14 +175,12 @@ func (r *OrdersRepository) OrdersFindByID(ctx context.Context, ID int) (Orders *
 }
 
 // OrdersStore func
-func (r *OrdersRepository) OrdersStore(ctx context.Context, db *dbr.Tx, Orders *entities.Orders) (ID int, err error) {
+func (r *OrdersRepository) OrdersStore(ctx context.Context, Tx transaction.Tx, Orders *entities.Orders) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("orders").
 		Columns(
 			"user_id",
-			"product_id",
-			"product_name",
-			"price",
-			"qty",
 			"total_price",
 			"status",
 			"created_at",
@@ -174,7 +189,7 @@ func (r *OrdersRepository) OrdersStore(ctx context.Context, db *dbr.Tx, Orders *
 		Record(Orders).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store orders",
 		}).Error(err)
 	}
@@ -183,14 +198,13 @@ func (r *OrdersRepository) OrdersStore(ctx context.Context, db *dbr.Tx, Orders *
 }
 
 // OrdersLogStore func
-func (r *OrdersRepository) OrdersLogStore(ctx context.Context, db *dbr.Tx, OrdersLog *entities.OrdersLog) (ID int, err error) {
+func (r *OrdersRepository) OrdersLogStore(ctx context.Context, Tx transaction.Tx, OrdersLog *entities.OrdersLog) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("orders_log").
 		Columns(
+			"order_id",
 			"user_id",
-			"product_id",
-			"product_name",
-			"price",
-			"qty",
 			"total_price",
 			"status",
 			"event",
@@ -201,7 +215,7 @@ func (r *OrdersRepository) OrdersLogStore(ctx context.Context, db *dbr.Tx, Order
 		Record(OrdersLog).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store orders log",
 		}).Error(err)
 	}
@@ -210,16 +224,169 @@ func (r *OrdersRepository) OrdersLogStore(ctx context.Context, db *dbr.Tx, Order
 }
 
 // OrdersUpdate func
-func (r *OrdersRepository) OrdersUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error) {
+func (r *OrdersRepository) OrdersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
 	_, err = db.Update("orders").
 		Where("id = ?", ID).
 		SetMap(Payload).
 		ExecContext(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when update orders",
 		}).Error(err)
 	}
 
 	return
 }
+
+// OrdersUpdateStatus func update the order only when it is still in From,
+// Updated is false when another request moved it first
+func (r *OrdersRepository) OrdersUpdateStatus(ctx context.Context, Tx transaction.Tx, ID int, From entities.OrdersStatus, Payload map[string]interface{}) (Updated bool, err error) {
+	db := transaction.Dbr(Tx)
+
+	Result, err := db.Update("orders").
+		Where("id = ? AND status = ?", ID, From).
+		SetMap(Payload).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when update orders status",
+		}).Error(err)
+		return
+	}
+
+	RowsAffected, err := Result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when get rows affected of orders status",
+		}).Error(err)
+		return
+	}
+
+	return RowsAffected > 0, nil
+}
+
+// OrdersFindStale func find the orders in Status that did not change since UpdatedBefore
+func (r *OrdersRepository) OrdersFindStale(ctx context.Context, Status entities.OrdersStatus, UpdatedBefore time.Time, Limit int) (Orders []*entities.Orders, err error) {
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("orders").
+		Where("status = ? AND updated_at < ?", Status, UpdatedBefore).
+		OrderAsc("updated_at").
+		Limit(uint64(Limit)).
+		LoadContext(ctx, &Orders)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query stale orders",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OrdersItemsFindByOrderIDs func
+func (r *OrdersRepository) OrdersItemsFindByOrderIDs(ctx context.Context, OrderIDs []int) (OrdersItems []*entities.OrdersItems, err error) {
+	if len(OrderIDs) == 0 {
+		return
+	}
+
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("orders_items").
+		Where("order_id IN ?", OrderIDs).
+		OrderAsc("id").
+		LoadContext(ctx, &OrdersItems)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query orders items find by order ids",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OrdersItemsStore func
+func (r *OrdersRepository) OrdersItemsStore(ctx context.Context, Tx transaction.Tx, OrdersItems *entities.OrdersItems) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	if err = db.InsertInto("orders_items").
+		Columns(
+			"order_id",
+			"product_id",
+			"reservation_id",
+			"product_name",
+			"price",
+			"qty",
+			"total_price",
+			"status",
+			"created_at",
+			"updated_at",
+		).
+		Record(OrdersItems).
+		Returning("id").
+		LoadContext(ctx, &ID); err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store orders items",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OrdersItemsLogStore func
+func (r *OrdersRepository) OrdersItemsLogStore(ctx context.Context, Tx transaction.Tx, OrdersItemsLog *entities.OrdersItemsLog) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	if err = db.InsertInto("orders_items_log").
+		Columns(
+			"order_item_id",
+			"order_id",
+			"product_id",
+			"product_name",
+			"price",
+			"qty",
+			"total_price",
+			"status",
+			"event",
+			"created_at",
+			"updated_at",
+		).
+		Record(OrdersItemsLog).
+		Returning("id").
+		LoadContext(ctx, &ID); err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store orders items log",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OrdersItemsUpdate func
+func (r *OrdersRepository) OrdersItemsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.Update("orders_items").
+		Where("id = ?", ID).
+		SetMap(Payload).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when update orders items",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OutboxStore func
+func (r *OrdersRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	return outbox.Store(ctx, db, Message)
+}
@@ -1,137 +1,91 @@
 package repositories
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"io/ioutil"
-	"net"
 	"net/http"
+	"net/url"
 	"strconv"
-	"time"
 
 	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // IProductsrepository interface
 type IProductsrepository interface {
 	GetProductsByID(ctx context.Context, Payload *entities.GetProductsByIDPayload) (Products *entities.Products, err error)
-	ProductsUpdate(ctx context.Context, Payload *entities.ProductsUpdatePayload) (err error)
+	ReserveStock(ctx context.Context, Payload *entities.ReserveStockPayload) (StockReservations *entities.StockReservations, err error)
+	ReleaseStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error)
+	CommitStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error)
 }
 
+// ErrStockReservationNotFound error
+var ErrStockReservationNotFound = errors.New("stock reservation not found")
+
 // ProductsRepository struct
 type ProductsRepository struct {
+	Client *serviceclient.Client
 }
 
-// GetProductsByID func
-func (r *ProductsRepository) GetProductsByID(ctx context.Context, Payload *entities.GetProductsByIDPayload) (Products *entities.Products, err error) {
-	var netTransport = &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: time.Second * 60,
-		}).Dial,
-		TLSHandshakeTimeout: time.Second * 60,
-	}
-
-	var Client = &http.Client{
-		Timeout:   time.Second * 60,
-		Transport: netTransport,
-	}
-
-	BaseURL := viper.GetString("services.products.url")
-	PathURL := "/products/" + strconv.Itoa(Payload.ProductID)
-
-	RequestHTTP, err := http.NewRequest("GET", BaseURL+PathURL, nil)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err creating new request get product by id to product service",
-		}).Error(err)
-		return
+// InitProductsRepository func, the client of products services signed as orders services.
+// The GET calls are retried, every call go through the breaker of products services
+// and is traced and recorded in the metrics
+func InitProductsRepository() *ProductsRepository {
+	return &ProductsRepository{
+		Client: serviceclient.New("products",
+			serviceclient.Metrics("orders", "products"),
+			serviceclient.Tracing("products"),
+			serviceclient.Retry(serviceclient.RetryPolicyFor("products")),
+			serviceclient.CircuitBreaker(serviceclient.BreakerFor("products")),
+			serviceclient.ServiceAuth("orders", "products"),
+			serviceclient.Logging("products"),
+		),
 	}
+}
 
-	RequestHTTP.Header.Set("Authorization", "INTERNAL-SERVICES")
-
-	ResponseHTTP, err := Client.Do(RequestHTTP)
+// GetProductsByID func
+func (r *ProductsRepository) GetProductsByID(ctx context.Context, Payload *entities.GetProductsByIDPayload) (Products *entities.Products, err error) {
+	Products = &entities.Products{}
+	err = r.Client.Get(ctx, "/products/"+strconv.Itoa(Payload.ProductID), nil, Products)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err performing request get product by id to product service",
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "err request get product by id to product service",
 		}).Error(err)
-		return
-	}
-	defer ResponseHTTP.Body.Close()
-
-	ResponseBody, _ := ioutil.ReadAll(ResponseHTTP.Body)
-	var GetProductsByIDResponse *entities.GetProductsByIDResponse
-	json.Unmarshal(ResponseBody, &GetProductsByIDResponse)
-
-	log.WithFields(log.Fields{
-		"event": "response from product service for get products by id",
-		"data":  string(ResponseBody),
-	})
-
-	if GetProductsByIDResponse.Code != 200 {
-		return nil, errors.New(GetProductsByIDResponse.Message)
+		return nil, err
 	}
-	return GetProductsByIDResponse.Data, nil
+	return
 }
 
-// ProductsUpdate func
-func (r *ProductsRepository) ProductsUpdate(ctx context.Context, Payload *entities.ProductsUpdatePayload) (err error) {
-	var netTransport = &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: time.Second * 60,
-		}).Dial,
-		TLSHandshakeTimeout: time.Second * 60,
-	}
-
-	var Client = &http.Client{
-		Timeout:   time.Second * 60,
-		Transport: netTransport,
-	}
+// ReserveStock func take the stock of the reservation in products service,
+// reserving the same reservation again move it to the new qty
+func (r *ProductsRepository) ReserveStock(ctx context.Context, Payload *entities.ReserveStockPayload) (StockReservations *entities.StockReservations, err error) {
+	return r.stockReservationsRequest(ctx, http.MethodPost, "/products/internal/reservations", Payload)
+}
 
-	BaseURL := viper.GetString("services.products.url")
-	PathURL := "/products/internal/" + strconv.Itoa(Payload.ProductID)
+// ReleaseStock func
+func (r *ProductsRepository) ReleaseStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error) {
+	return r.stockReservationsRequest(ctx, http.MethodPut, "/products/internal/reservations/"+url.PathEscape(ReservationID)+"/release", nil)
+}
 
-	RequestBody, err := json.Marshal(Payload)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err when marshal payload products update to product service",
-		}).Error(err)
-		return
-	}
+// CommitStock func
+func (r *ProductsRepository) CommitStock(ctx context.Context, ReservationID string) (StockReservations *entities.StockReservations, err error) {
+	return r.stockReservationsRequest(ctx, http.MethodPut, "/products/internal/reservations/"+url.PathEscape(ReservationID)+"/commit", nil)
+}
 
-	RequestHTTP, err := http.NewRequest("PUT", BaseURL+PathURL, bytes.NewBuffer(RequestBody))
-	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err creating new request products update to product service",
-		}).Error(err)
-		return
+// stockReservationsRequest func
+func (r *ProductsRepository) stockReservationsRequest(ctx context.Context, Method string, PathURL string, Payload interface{}) (StockReservations *entities.StockReservations, err error) {
+	StockReservations = &entities.StockReservations{}
+	err = r.Client.Do(ctx, Method, PathURL, nil, Payload, StockReservations)
+	if serviceclient.IsStatus(err, 404) {
+		return nil, ErrStockReservationNotFound
 	}
-
-	RequestHTTP.Header.Set("Authorization", "INTERNAL-SERVICES")
-
-	ResponseHTTP, err := Client.Do(RequestHTTP)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err performing request products update to product service",
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "err request stock reservations to product service",
 		}).Error(err)
-		return
-	}
-	defer ResponseHTTP.Body.Close()
-
-	ResponseBody, _ := ioutil.ReadAll(ResponseHTTP.Body)
-	var ProductsUpdateResponse *entities.ProductsUpdateResponse
-	json.Unmarshal(ResponseBody, &ProductsUpdateResponse)
-
-	log.WithFields(log.Fields{
-		"event": "response from product service for update products",
-		"data":  string(ResponseBody),
-	})
-
-	if ProductsUpdateResponse.Code != 200 {
-		return errors.New(ProductsUpdateResponse.Message)
+		return nil, err
 	}
 	return
 }
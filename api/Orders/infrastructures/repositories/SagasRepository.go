@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+// ISagasRepository interface
+type ISagasRepository interface {
+	Tx() (Tx transaction.Tx, err error)
+	SagasFindStale(ctx context.Context, Status []entities.SagasStatus, UpdatedBefore time.Time, Limit int) (Sagas []*entities.Sagas, err error)
+	SagasStepsFindBySagaID(ctx context.Context, SagaID int) (SagasSteps []*entities.SagasSteps, err error)
+	SagasStore(ctx context.Context, Tx transaction.Tx, Sagas *entities.Sagas) (ID int, err error)
+	SagasStepsStore(ctx context.Context, Tx transaction.Tx, SagasSteps *entities.SagasSteps) (ID int, err error)
+	SagasUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+	SagasStepsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+}
+
+// SagasRepository struct
+type SagasRepository struct {
+	PG database.IPostgresConnection
+}
+
+// Tx func to create new transaction
+func (r *SagasRepository) Tx() (Tx transaction.Tx, err error) {
+	db := r.PG.PostgresTrade()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when begin transaction in postgres",
+		}).Error(err)
+		return
+	}
+
+	return tx, nil
+}
+
+// SagasFindStale func find sagas that did not move since UpdatedBefore
+func (r *SagasRepository) SagasFindStale(ctx context.Context, Status []entities.SagasStatus, UpdatedBefore time.Time, Limit int) (Sagas []*entities.Sagas, err error) {
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("sagas").
+		Where("status IN ?", Status).
+		Where("updated_at < ?", UpdatedBefore).
+		OrderAsc("id").
+		Limit(uint64(Limit)).
+		LoadContext(ctx, &Sagas)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query stale sagas",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasStepsFindBySagaID func
+func (r *SagasRepository) SagasStepsFindBySagaID(ctx context.Context, SagaID int) (SagasSteps []*entities.SagasSteps, err error) {
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("sagas_steps").
+		Where("saga_id = ?", SagaID).
+		OrderAsc("sequence").
+		LoadContext(ctx, &SagasSteps)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query sagas steps by saga id",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasStore func
+func (r *SagasRepository) SagasStore(ctx context.Context, Tx transaction.Tx, Sagas *entities.Sagas) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	if err = db.InsertInto("sagas").
+		Columns(
+			"name",
+			"order_id",
+			"status",
+			"error",
+			"created_at",
+			"updated_at",
+		).
+		Record(Sagas).
+		Returning("id").
+		LoadContext(ctx, &ID); err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store sagas",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasStepsStore func
+func (r *SagasRepository) SagasStepsStore(ctx context.Context, Tx transaction.Tx, SagasSteps *entities.SagasSteps) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	if err = db.InsertInto("sagas_steps").
+		Columns(
+			"saga_id",
+			"sequence",
+			"action",
+			"payload",
+			"status",
+			"created_at",
+			"updated_at",
+		).
+		Record(SagasSteps).
+		Returning("id").
+		LoadContext(ctx, &ID); err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store sagas steps",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasUpdate func
+func (r *SagasRepository) SagasUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.Update("sagas").
+		Where("id = ?", ID).
+		SetMap(Payload).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when update sagas",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasStepsUpdate func
+func (r *SagasRepository) SagasStepsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.Update("sagas_steps").
+		Where("id = ?", ID).
+		SetMap(Payload).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when update sagas steps",
+		}).Error(err)
+	}
+
+	return
+}
@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// IIdempotencyUsecases interface
+type IIdempotencyUsecases interface {
+	IdempotencyBegin(ctx context.Context, Scope string, Key string, Fingerprint string) (Replay *entities.IdempotencyRecords, err error)
+	IdempotencyHold(ctx context.Context, Scope string, Key string) (Release func())
+	IdempotencyEnd(ctx context.Context, Scope string, Key string, Fingerprint string, StatusCode int, Body []byte) (err error)
+}
+
+// IdempotencyUsecases struct, a key is locked for LockTTL and renewed while its
+// first request run (see IdempotencyHold), its response is kept for TTL
+type IdempotencyUsecases struct {
+	IdempotencyRepository repositories.IIdempotencyRepository
+	TTL                   time.Duration
+	LockTTL               time.Duration
+}
+
+// InitIdempotencyUsecases func
+func InitIdempotencyUsecases(IdempotencyRepository repositories.IIdempotencyRepository) *IdempotencyUsecases {
+	TTL := viper.GetDuration("ordersServices.idempotency.ttl")
+	if TTL == 0 {
+		TTL = time.Hour * 24
+	}
+	LockTTL := viper.GetDuration("ordersServices.idempotency.lock_ttl")
+	if LockTTL == 0 {
+		LockTTL = time.Minute
+	}
+
+	return &IdempotencyUsecases{
+		IdempotencyRepository: IdempotencyRepository,
+		TTL:                   TTL,
+		LockTTL:               LockTTL,
+	}
+}
+
+// IdempotencyBegin func hold Key for the request. Replay is the stored response
+// when the key was already used by the same request, err is an *apperr.Error
+// when the request can not run now
+func (u *IdempotencyUsecases) IdempotencyBegin(ctx context.Context, Scope string, Key string, Fingerprint string) (Replay *entities.IdempotencyRecords, err error) {
+	ctx, Span := tracing.Start(ctx, "IdempotencyUsecases.IdempotencyBegin")
+	defer Span.Finish(&err)
+
+	Existing, err := u.IdempotencyRepository.IdempotencyReserve(ctx, idempotencyKey(Scope, Key), &entities.IdempotencyRecords{
+		Key:         Key,
+		Fingerprint: Fingerprint,
+		Status:      entities.IdempotencyProcessing,
+		CreatedAt:   time.Now(),
+	}, u.LockTTL)
+	if errors.Is(err, repositories.ErrIdempotencyKeyBusy) {
+		return nil, apperr.Conflict("idempotency_in_progress").Wrap(err)
+	}
+	if err != nil || Existing == nil {
+		return
+	}
+
+	if Existing.Fingerprint != Fingerprint {
+		return nil, apperr.Validation("idempotency_key_reused")
+	}
+
+	if Existing.Status != entities.IdempotencyCompleted {
+		return nil, apperr.Conflict("idempotency_in_progress")
+	}
+
+	return Existing, nil
+}
+
+// IdempotencyHold func renew the lock of Key every third of LockTTL until
+// Release is called, a request can run longer than LockTTL (an order saga that
+// retry and wait for the breakers) without its key being taken by a retry. A
+// process that die stop renewing and the key is free again after LockTTL
+func (u *IdempotencyUsecases) IdempotencyHold(ctx context.Context, Scope string, Key string) (Release func()) {
+	Done := make(chan struct{})
+	Stopped := make(chan struct{})
+
+	go func() {
+		defer close(Stopped)
+
+		Ticker := time.NewTicker(u.LockTTL / 3)
+		defer Ticker.Stop()
+		for {
+			select {
+			case <-Done:
+				return
+			case <-Ticker.C:
+				Renewed, err := u.IdempotencyRepository.IdempotencyRenew(ctx, idempotencyKey(Scope, Key), u.LockTTL)
+				if err != nil {
+					logging.FromContext(ctx).WithFields(log.Fields{
+						"event": "error when renew idempotency lock",
+					}).Error(err)
+					continue
+				}
+				if !Renewed {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(Done)
+		<-Stopped
+	}
+}
+
+// IdempotencyEnd func keep the response of the request for the retries. A
+// server error is not kept so the retry run the request again
+func (u *IdempotencyUsecases) IdempotencyEnd(ctx context.Context, Scope string, Key string, Fingerprint string, StatusCode int, Body []byte) (err error) {
+	ctx, Span := tracing.Start(ctx, "IdempotencyUsecases.IdempotencyEnd")
+	defer Span.Finish(&err)
+
+	if StatusCode >= 500 {
+		return u.IdempotencyRepository.IdempotencyDelete(ctx, idempotencyKey(Scope, Key))
+	}
+
+	return u.IdempotencyRepository.IdempotencyStore(ctx, idempotencyKey(Scope, Key), &entities.IdempotencyRecords{
+		Key:         Key,
+		Fingerprint: Fingerprint,
+		Status:      entities.IdempotencyCompleted,
+		StatusCode:  StatusCode,
+		Body:        string(Body),
+		CreatedAt:   time.Now(),
+	}, u.TTL)
+}
+
+// idempotencyKey func, the keys of every user (or service) are apart
+func idempotencyKey(Scope string, Key string) string {
+	return "orders:idempotency:" + Scope + ":" + Key
+}
@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/memory"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+)
+
+// busyIdempotencyRepository struct, the key keep expiring while it is reserved
+type busyIdempotencyRepository struct {
+	*memory.IdempotencyRepository
+}
+
+// IdempotencyReserve func
+func (r *busyIdempotencyRepository) IdempotencyReserve(ctx context.Context, Key string, Records *entities.IdempotencyRecords, TTL time.Duration) (*entities.IdempotencyRecords, error) {
+	return nil, repositories.ErrIdempotencyKeyBusy
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	Tests := []struct {
+		Name        string
+		Busy        bool
+		First       string
+		Completed   bool
+		Fingerprint string
+		Code        int
+		ErrorCode   string
+		Replay      bool
+	}{
+		{Name: "new key", Fingerprint: "a"},
+		{Name: "same request in progress", First: "a", Fingerprint: "a", Code: 409, ErrorCode: "idempotency_in_progress"},
+		{Name: "same request done", First: "a", Completed: true, Fingerprint: "a", Replay: true},
+		{Name: "another request", First: "a", Completed: true, Fingerprint: "b", Code: 422, ErrorCode: "idempotency_key_reused"},
+		{Name: "key keep expiring", Busy: true, Fingerprint: "a", Code: 409, ErrorCode: "idempotency_in_progress"},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			var IdempotencyRepository repositories.IIdempotencyRepository = memory.NewIdempotencyRepository()
+			if Test.Busy {
+				IdempotencyRepository = &busyIdempotencyRepository{memory.NewIdempotencyRepository()}
+			}
+			u := InitIdempotencyUsecases(IdempotencyRepository)
+
+			if Test.First != "" {
+				if _, err := u.IdempotencyBegin(context.Background(), "user:1", "key", Test.First); err != nil {
+					t.Fatal(err)
+				}
+				if Test.Completed {
+					if err := u.IdempotencyEnd(context.Background(), "user:1", "key", Test.First, 200, []byte(`{}`)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			Replay, err := u.IdempotencyBegin(context.Background(), "user:1", "key", Test.Fingerprint)
+			if Test.ErrorCode != "" {
+				if apperr.Status(err) != Test.Code || apperr.CodeOf(err) != Test.ErrorCode {
+					t.Fatalf("err = %v (%d), want %s (%d)", err, apperr.Status(err), Test.ErrorCode, Test.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (Replay != nil) != Test.Replay {
+				t.Fatalf("replay = %+v, want a replay %v", Replay, Test.Replay)
+			}
+		})
+	}
+}
+
+func TestIdempotencyHold(t *testing.T) {
+	u := InitIdempotencyUsecases(memory.NewIdempotencyRepository())
+	u.LockTTL = time.Millisecond * 30
+
+	if _, err := u.IdempotencyBegin(context.Background(), "user:1", "key", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the request run longer than the lock, a retry still find it in progress
+	Release := u.IdempotencyHold(context.Background(), "user:1", "key")
+	time.Sleep(u.LockTTL * 4)
+	if _, err := u.IdempotencyBegin(context.Background(), "user:1", "key", "a"); apperr.CodeOf(err) != "idempotency_in_progress" {
+		t.Fatalf("err = %v while held, want idempotency_in_progress", err)
+	}
+
+	// a request that is gone without its response free the key after LockTTL
+	Release()
+	time.Sleep(u.LockTTL * 2)
+	if Replay, err := u.IdempotencyBegin(context.Background(), "user:1", "key", "a"); err != nil || Replay != nil {
+		t.Fatalf("replay = %+v %v after the release, want the key free", Replay, err)
+	}
+}
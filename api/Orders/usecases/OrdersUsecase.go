@@ -2,13 +2,27 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mrdhira/warpin-test/api/Orders/entities"
-	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/database"
 	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/money"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -21,34 +35,51 @@ type IOrdersUsecases interface {
 	OrdersListAdmin(ctx context.Context, Data *entities.OrdersListAdminRequest) (Response *pkg.JSONResponse, err error)
 	OrdersApprove(ctx context.Context, Data *entities.OrdersApproveRequest) (Response *pkg.JSONResponse, err error)
 	OrdersReject(ctx context.Context, Data *entities.OrdersRejectRequest) (Response *pkg.JSONResponse, err error)
+	OrdersTransition(ctx context.Context, Data *entities.OrdersTransitionRequest) (Response *pkg.JSONResponse, err error)
+	OrdersExpire(ctx context.Context, PendingFor time.Duration, Limit int) (Expired int, err error)
+}
+
+// errOrdersStatusChanged func, the error returned when the order was moved by
+// another request during a transition. A new one every time since the
+// *apperr.Error is changed by Wrap and WithData, match it with errors.Is
+func errOrdersStatusChanged() *apperr.Error {
+	return apperr.Conflict("order_status_changed")
 }
 
 // OrdersUsecases struct
 type OrdersUsecases struct {
 	OrdersRepository   repositories.IOrdersRepository
 	ProductsRepository repositories.IProductsrepository
+	SagasUsecase       ISagasUsecases
 }
 
 // InitOrdersUsecases func
-func InitOrdersUsecases() *OrdersUsecases {
-	// Init Repositories
-	ordersRepository := new(repositories.OrdersRepository)
-	ordersRepository.PG = &database.PostgresConnection{}
-	ordersRepository.Redis = &database.RedisConnection{}
-
-	productsRepository := new(repositories.ProductsRepository)
+func InitOrdersUsecases(OrdersRepository repositories.IOrdersRepository, ProductsRepository repositories.IProductsrepository, SagasUsecase ISagasUsecases) *OrdersUsecases {
+	u := &OrdersUsecases{
+		OrdersRepository:   OrdersRepository,
+		ProductsRepository: ProductsRepository,
+		SagasUsecase:       SagasUsecase,
+	}
 
-	return &OrdersUsecases{
-		OrdersRepository:   ordersRepository,
-		ProductsRepository: productsRepository,
+	// The stock of a transition is released before its status is stored, a
+	// crash between them is finished by the saga recovery
+	for _, Transition := range entities.OrdersTransitions {
+		if Transition.Sagas != "" {
+			SagasUsecase.SagasRollForwardRegister(Transition.Sagas, u.ordersRollForward(Transition))
+		}
 	}
+
+	return u
 }
 
 // OrdersListUsers func
 func (u *OrdersUsecases) OrdersListUsers(ctx context.Context, Data *entities.OrdersListUsersRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersListUsers")
+	defer Span.Finish(&err)
+
 	Limit, err := strconv.Atoi(Data.Limit)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for limit",
 		}).Error(err)
 		return
@@ -56,7 +87,7 @@ func (u *OrdersUsecases) OrdersListUsers(ctx context.Context, Data *entities.Ord
 
 	Offset, err := strconv.Atoi(Data.Offset)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for offset",
 		}).Error(err)
 		return
@@ -67,36 +98,103 @@ func (u *OrdersUsecases) OrdersListUsers(ctx context.Context, Data *entities.Ord
 		return
 	}
 
+	err = u.ordersAttachItems(ctx, Orders)
+	if err != nil {
+		return
+	}
+
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "OK",
+		Message: i18n.T(ctx, "ok"),
 		Data:    Orders,
 	}, nil
 }
 
 // OrdersCreate func
 func (u *OrdersUsecases) OrdersCreate(ctx context.Context, Data *entities.OrdersCreateRequest) (Response *pkg.JSONResponse, err error) {
-	GetProductsByIDPayload := &entities.GetProductsByIDPayload{
-		ProductID: Data.ProductID,
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersCreate")
+	defer Span.Finish(&err)
+
+	// Merge the same product into one line
+	OrdersItems := []*entities.OrdersItems{}
+	OrdersItemsByProduct := map[int]*entities.OrdersItems{}
+	ExpectedPrices := map[int][]*money.Money{}
+	for _, Item := range Data.Items {
+		ExpectedPrices[Item.ProductID] = append(ExpectedPrices[Item.ProductID], Item.ExpectedPrice)
+		if OrdersItem, ok := OrdersItemsByProduct[Item.ProductID]; ok {
+			OrdersItem.Qty += Item.Qty
+			continue
+		}
+
+		OrdersItem := &entities.OrdersItems{
+			ProductID: Item.ProductID,
+			Qty:       Item.Qty,
+			Status:    entities.ItemsPending,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		OrdersItems = append(OrdersItems, OrdersItem)
+		OrdersItemsByProduct[Item.ProductID] = OrdersItem
 	}
-	Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
+
+	// The name and price are a snapshot of products services, never the client ones
+	for _, OrdersItem := range OrdersItems {
+		GetProductsByIDPayload := &entities.GetProductsByIDPayload{
+			ProductID: OrdersItem.ProductID,
+		}
+		Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
+		if err != nil {
+			return nil, productsUnavailable(err)
+		}
+
+		OrdersItem.ProductName = Products.Name
+		OrdersItem.Price = Products.Price
+		OrdersItem.TotalPrice, err = OrdersItem.Price.Mul(OrdersItem.Qty)
+		if err != nil {
+			return nil, moneyError(err)
+		}
+
+		if err = ordersPriceChanged(Products, ExpectedPrices[OrdersItem.ProductID]); err != nil {
+			return nil, err
+		}
+
+		if Products.Status != entities.Active {
+			return nil, apperr.Validation("product_inactive", OrdersItem.ProductName)
+		}
+
+		if OrdersItem.Qty > Products.Qty {
+			return nil, apperr.Validation("stock_insufficient", OrdersItem.ProductName)
+		}
+	}
+
+	TotalPrice, err := entities.OrdersTotalPrice(OrdersItems)
 	if err != nil {
-		return
+		return nil, moneyError(err)
 	}
 
-	if Products.Status != entities.Active {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Product sedang tidak aktif, silahkan hubungi cs",
-		}, nil
+	// Reserve the stock first, the order is only stored when every product is reserved
+	Steps := []*entities.SagasSteps{}
+	for _, OrdersItem := range OrdersItems {
+		OrdersItem.ReservationID, err = stockReservationID()
+		if err != nil {
+			return
+		}
+		Steps = append(Steps, StockReserveStep(OrdersItem.ReservationID, OrdersItem.ProductID, OrdersItem.Qty, 0))
+	}
+	for _, OrdersItem := range OrdersItems {
+		Steps = append(Steps, StockCommitStep(OrdersItem.ReservationID, OrdersItem.ProductID, OrdersItem.Qty))
 	}
 
-	if Data.Qty > Products.Qty {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Kuantitas yang di order lebih banyak daripada stok yang tersedia",
-		}, nil
+	Sagas, err := u.SagasUsecase.SagasStart(ctx, entities.SagaOrdersCreate, 0, Steps)
+	if err != nil {
+		return
+	}
+
+	err = u.SagasUsecase.SagasRun(ctx, Sagas)
+	if err != nil {
+		return nil, productsUnavailable(err)
 	}
+	defer u.sagasCompensateOnError(ctx, Sagas, &err)
 
 	Tx, err := u.OrdersRepository.Tx()
 	if err != nil {
@@ -105,15 +203,12 @@ func (u *OrdersUsecases) OrdersCreate(ctx context.Context, Data *entities.Orders
 	defer Tx.RollbackUnlessCommitted()
 
 	Orders := &entities.Orders{
-		UserID:      Data.UserID,
-		ProductID:   Data.ProductID,
-		ProductName: Data.ProductName,
-		Price:       Data.Price,
-		Qty:         Data.Qty,
-		TotalPrice:  Data.Price * float32(Data.Qty),
-		Status:      entities.Pending,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		UserID:     Data.UserID,
+		TotalPrice: TotalPrice,
+		Status:     entities.Pending,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Items:      OrdersItems,
 	}
 
 	Orders.ID, err = u.OrdersRepository.OrdersStore(ctx, Tx, Orders)
@@ -123,17 +218,13 @@ func (u *OrdersUsecases) OrdersCreate(ctx context.Context, Data *entities.Orders
 	}
 
 	OrdersLog := &entities.OrdersLog{
-		OrderID:     Orders.ID,
-		UserID:      Orders.UserID,
-		ProductID:   Orders.ProductID,
-		ProductName: Orders.ProductName,
-		Price:       Orders.Price,
-		Qty:         Orders.Qty,
-		TotalPrice:  Orders.TotalPrice,
-		Status:      entities.Pending,
-		Event:       entities.EventCreate,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		OrderID:    Orders.ID,
+		UserID:     Orders.UserID,
+		TotalPrice: Orders.TotalPrice,
+		Status:     entities.Pending,
+		Event:      entities.EventCreate,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
 	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
@@ -142,204 +233,311 @@ func (u *OrdersUsecases) OrdersCreate(ctx context.Context, Data *entities.Orders
 		return
 	}
 
-	ProductsUpdatePayload := &entities.ProductsUpdatePayload{
-		UserID:    0,
-		ProductID: Data.ProductID,
-		Qty:       Products.Qty - Data.Qty,
+	for _, OrdersItem := range OrdersItems {
+		OrdersItem.OrderID = Orders.ID
+		OrdersItem.ID, err = u.OrdersRepository.OrdersItemsStore(ctx, Tx, OrdersItem)
+		if err != nil {
+			defer Tx.Rollback()
+			return
+		}
+
+		_, err = u.OrdersRepository.OrdersItemsLogStore(ctx, Tx, ordersItemsLog(OrdersItem, entities.EventCreate))
+		if err != nil {
+			defer Tx.Rollback()
+			return
+		}
 	}
-	err = u.ProductsRepository.ProductsUpdate(ctx, ProductsUpdatePayload)
+
+	err = u.ordersOutboxStore(ctx, Tx, Orders, OrdersLog.Event)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	defer Tx.Commit()
+	err = u.SagasUsecase.SagasComplete(ctx, Tx, Sagas, Orders.ID)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.OrdersEvent(string(OrdersLog.Event))
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Orders berhasil dibuat",
+		Message: i18n.T(ctx, string(entities.EventOrderCreated)),
+		Data:    Orders,
 	}, nil
 }
 
-// OrdersUpdate func
+// OrdersUpdate func, items work like a cart: listed products are set to the
+// given qty, qty 0 remove the product and products not listed stay as is
 func (u *OrdersUsecases) OrdersUpdate(ctx context.Context, Data *entities.OrdersUpdateRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersUpdate")
+	defer Span.Finish(&err)
+
 	Orders, err := u.OrdersRepository.OrdersFindByID(ctx, Data.OrderID)
 	if err != nil {
 		return
 	}
 
-	if Orders.Status != entities.Pending {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Order tidak sedang pending",
-		}, nil
+	if Orders == nil {
+		return nil, apperr.NotFound("order_not_found")
 	}
 
-	if Orders.UserID != Data.UserID {
-		return &pkg.JSONResponse{
-			Code:    403,
-			Message: "Order bukan milik users",
-		}, nil
+	if _, err = ordersTransitionGuard(Orders, entities.EventUpdate, entities.ActorOwner, Data.UserID); err != nil {
+		return nil, err
 	}
 
-	Tx, err := u.OrdersRepository.Tx()
+	Orders.Items, err = u.OrdersRepository.OrdersItemsFindByOrderIDs(ctx, []int{Orders.ID})
 	if err != nil {
 		return
 	}
-	defer Tx.RollbackUnlessCommitted()
 
-	OrdersLog := &entities.OrdersLog{
-		OrderID:     Orders.ID,
-		UserID:      Orders.UserID,
-		ProductID:   Orders.ProductID,
-		ProductName: Orders.ProductName,
-		Price:       Orders.Price,
-		Qty:         Data.Qty,
-		TotalPrice:  Orders.Price * float32(Data.Qty),
-		Status:      entities.Pending,
-		Event:       entities.EventUpdate,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	OrdersItemsByProduct := map[int]*entities.OrdersItems{}
+	for _, OrdersItem := range Orders.Items {
+		if OrdersItem.Status != entities.ItemsCancel {
+			OrdersItemsByProduct[OrdersItem.ProductID] = OrdersItem
+		}
 	}
 
-	UpdatePayload := map[string]interface{}{
-		"qty":         Data.Qty,
-		"total_price": Orders.Price * float32(Data.Qty),
-		"updated_at":  time.Now(),
+	// Merge the same product into one requested line
+	Requests := []*entities.OrdersItemsUpdateRequest{}
+	RequestsByProduct := map[int]*entities.OrdersItemsUpdateRequest{}
+	ExpectedPrices := map[int][]*money.Money{}
+	for _, Item := range Data.Items {
+		ExpectedPrices[Item.ProductID] = append(ExpectedPrices[Item.ProductID], Item.ExpectedPrice)
+		if Request, ok := RequestsByProduct[Item.ProductID]; ok {
+			Request.Qty += Item.Qty
+			continue
+		}
+		Request := *Item
+		Requests = append(Requests, &Request)
+		RequestsByProduct[Item.ProductID] = &Request
 	}
 
-	err = u.OrdersRepository.OrdersUpdate(ctx, Tx, Data.OrderID, UpdatePayload)
-	if err != nil {
-		defer Tx.Rollback()
-		return
+	type ordersItemsChange struct {
+		OrdersItem *entities.OrdersItems
+		IsNew      bool
+		Delta      int
 	}
 
-	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
-	if err != nil {
-		defer Tx.Rollback()
-		return
-	}
+	Changes := []*ordersItemsChange{}
+	for _, Request := range Requests {
+		OrdersItem, Exists := OrdersItemsByProduct[Request.ProductID]
 
-	GetProductsByIDPayload := &entities.GetProductsByIDPayload{
-		ProductID: Orders.ProductID,
-	}
-	Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
-	if err != nil {
-		return
-	}
+		Delta := Request.Qty
+		if Exists {
+			Delta = Request.Qty - OrdersItem.Qty
+		}
+		if Delta == 0 {
+			continue
+		}
 
-	ProductsUpdatePayload := &entities.ProductsUpdatePayload{
-		UserID:    0,
-		ProductID: Orders.ProductID,
-		// Qty:       Products.Qty + Orders.Qty,
-	}
+		if !Exists {
+			ReservationID, err := stockReservationID()
+			if err != nil {
+				return nil, err
+			}
 
-	if Data.Qty >= Orders.Qty {
-		ProductsUpdatePayload.Qty = Products.Qty - (Data.Qty - Orders.Qty)
-	} else {
-		ProductsUpdatePayload.Qty = Products.Qty + (Orders.Qty - Data.Qty)
-	}
-	err = u.ProductsRepository.ProductsUpdate(ctx, ProductsUpdatePayload)
-	if err != nil {
-		defer Tx.Rollback()
-		return
-	}
+			OrdersItem = &entities.OrdersItems{
+				OrderID:       Orders.ID,
+				ProductID:     Request.ProductID,
+				ReservationID: ReservationID,
+				Status:        entities.ItemsPending,
+				CreatedAt:     time.Now(),
+			}
+			Orders.Items = append(Orders.Items, OrdersItem)
+		}
 
-	defer Tx.Commit()
+		GetProductsByIDPayload := &entities.GetProductsByIDPayload{
+			ProductID: Request.ProductID,
+		}
+		Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
+		if err != nil {
+			return nil, productsUnavailable(err)
+		}
 
-	return &pkg.JSONResponse{
-		Code:    200,
-		Message: "Orders berhasil di update",
-	}, nil
-}
+		// A new line take the current name and price, the others keep their
+		// snapshot. The client expect the current price either way
+		if !Exists {
+			OrdersItem.ProductName = Products.Name
+			OrdersItem.Price = Products.Price
+		}
 
-// OrdersCancel func
-func (u *OrdersUsecases) OrdersCancel(ctx context.Context, Data *entities.OrdersCancelRequest) (Response *pkg.JSONResponse, err error) {
-	Orders, err := u.OrdersRepository.OrdersFindByID(ctx, Data.OrderID)
-	if err != nil {
-		return
-	}
+		if err = ordersPriceChanged(Products, ExpectedPrices[Request.ProductID]); err != nil {
+			return nil, err
+		}
 
-	if Orders.Status != entities.Pending {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Order tidak sedang pending",
-		}, nil
-	}
+		if Delta > 0 && Products.Status != entities.Active {
+			return nil, apperr.Validation("product_inactive", OrdersItem.ProductName)
+		}
+
+		if Delta > Products.Qty {
+			return nil, apperr.Validation("stock_insufficient", OrdersItem.ProductName)
+		}
+
+		OrdersItem.Qty = Request.Qty
+		OrdersItem.TotalPrice, err = OrdersItem.Price.Mul(OrdersItem.Qty)
+		if err != nil {
+			return nil, moneyError(err)
+		}
+		OrdersItem.UpdatedAt = time.Now()
+		if OrdersItem.Qty == 0 {
+			OrdersItem.Status = entities.ItemsCancel
+		}
 
-	if Orders.UserID != Data.UserID {
-		return &pkg.JSONResponse{
-			Code:    403,
-			Message: "Order bukan milik users",
-		}, nil
+		Changes = append(Changes, &ordersItemsChange{
+			OrdersItem: OrdersItem,
+			IsNew:      !Exists,
+			Delta:      Delta,
+		})
+	}
+
+	ActiveItems := 0
+	for _, OrdersItem := range Orders.Items {
+		if OrdersItem.Status != entities.ItemsCancel {
+			ActiveItems++
+		}
+	}
+	if ActiveItems == 0 {
+		return nil, apperr.Validation("order_items_empty").WithDetails(
+			apperr.Field("items", "min", reflect.Slice, "1"),
+		)
+	}
+
+	Steps := []*entities.SagasSteps{}
+	for _, Change := range Changes {
+		OrdersItem := Change.OrdersItem
+		Steps = append(Steps, StockReserveStep(OrdersItem.ReservationID, OrdersItem.ProductID, OrdersItem.Qty, OrdersItem.Qty-Change.Delta))
+		if Change.IsNew {
+			Steps = append(Steps, StockCommitStep(OrdersItem.ReservationID, OrdersItem.ProductID, OrdersItem.Qty))
+		}
 	}
 
+	Sagas, err := u.SagasUsecase.SagasStart(ctx, entities.SagaOrdersUpdate, Orders.ID, Steps)
+	if err != nil {
+		return
+	}
+
+	err = u.SagasUsecase.SagasRun(ctx, Sagas)
+	if err != nil {
+		return nil, productsUnavailable(err)
+	}
+	defer u.sagasCompensateOnError(ctx, Sagas, &err)
+
 	Tx, err := u.OrdersRepository.Tx()
 	if err != nil {
 		return
 	}
 	defer Tx.RollbackUnlessCommitted()
 
-	OrdersLog := &entities.OrdersLog{
-		OrderID:     Orders.ID,
-		UserID:      Orders.UserID,
-		ProductID:   Orders.ProductID,
-		ProductName: Orders.ProductName,
-		Price:       Orders.Price,
-		Qty:         Orders.Qty,
-		TotalPrice:  Orders.TotalPrice,
-		Status:      entities.Cancel,
-		Event:       entities.EventCancel,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	for _, Change := range Changes {
+		if Change.IsNew {
+			Change.OrdersItem.ID, err = u.OrdersRepository.OrdersItemsStore(ctx, Tx, Change.OrdersItem)
+		} else {
+			err = u.OrdersRepository.OrdersItemsUpdate(ctx, Tx, Change.OrdersItem.ID, map[string]interface{}{
+				"qty":         Change.OrdersItem.Qty,
+				"total_price": Change.OrdersItem.TotalPrice,
+				"status":      Change.OrdersItem.Status,
+				"updated_at":  Change.OrdersItem.UpdatedAt,
+			})
+		}
+		if err != nil {
+			defer Tx.Rollback()
+			return
+		}
+
+		_, err = u.OrdersRepository.OrdersItemsLogStore(ctx, Tx, ordersItemsLog(Change.OrdersItem, entities.EventUpdate))
+		if err != nil {
+			defer Tx.Rollback()
+			return
+		}
 	}
 
+	Orders.TotalPrice, err = entities.OrdersTotalPrice(Orders.Items)
+	if err != nil {
+		Tx.Rollback()
+		return nil, moneyError(err)
+	}
+	Orders.UpdatedAt = time.Now()
+
 	UpdatePayload := map[string]interface{}{
-		"status":     entities.Cancel,
-		"updated_at": time.Now(),
+		"total_price": Orders.TotalPrice,
+		"updated_at":  Orders.UpdatedAt,
 	}
 
-	err = u.OrdersRepository.OrdersUpdate(ctx, Tx, Data.OrderID, UpdatePayload)
+	// The order could be cancelled or approved while the stock was reserved
+	Updated, err := u.OrdersRepository.OrdersUpdateStatus(ctx, Tx, Data.OrderID, entities.Pending, UpdatePayload)
 	if err != nil {
 		defer Tx.Rollback()
 		return
 	}
 
+	// the deferred sagasCompensateOnError give the stock back
+	if !Updated {
+		Tx.Rollback()
+		return nil, errOrdersStatusChanged()
+	}
+
+	OrdersLog := &entities.OrdersLog{
+		OrderID:    Orders.ID,
+		UserID:     Orders.UserID,
+		TotalPrice: Orders.TotalPrice,
+		Status:     entities.Pending,
+		Event:      entities.EventUpdate,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
 	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
 	if err != nil {
 		defer Tx.Rollback()
 		return
 	}
 
-	GetProductsByIDPayload := &entities.GetProductsByIDPayload{
-		ProductID: Orders.ProductID,
-	}
-	Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
+	err = u.ordersOutboxStore(ctx, Tx, Orders, OrdersLog.Event)
 	if err != nil {
 		return
 	}
 
-	ProductsUpdatePayload := &entities.ProductsUpdatePayload{
-		UserID:    0,
-		ProductID: Orders.ProductID,
-		Qty:       Products.Qty + Orders.Qty,
-	}
-	err = u.ProductsRepository.ProductsUpdate(ctx, ProductsUpdatePayload)
+	err = u.SagasUsecase.SagasComplete(ctx, Tx, Sagas, Orders.ID)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	defer Tx.Commit()
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.OrdersEvent(string(OrdersLog.Event))
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Orders berhasil di cancel",
+		Message: i18n.T(ctx, string(entities.EventOrderUpdated)),
+		Data:    Orders,
 	}, nil
 }
 
+// OrdersCancel func
+func (u *OrdersUsecases) OrdersCancel(ctx context.Context, Data *entities.OrdersCancelRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersCancel")
+	defer Span.Finish(&err)
+
+	return u.OrdersTransition(ctx, &entities.OrdersTransitionRequest{
+		UserID:  Data.UserID,
+		OrderID: Data.OrderID,
+		Event:   entities.EventCancel,
+		Actor:   entities.ActorOwner,
+	})
+}
+
 // OrdersListAdmin func
 func (u *OrdersUsecases) OrdersListAdmin(ctx context.Context, Data *entities.OrdersListAdminRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersListAdmin")
+	defer Span.Finish(&err)
+
 	Condition := map[string]interface{}{}
 	if Data.Status != 0 {
 		Condition["status"] = Data.Status
@@ -349,16 +547,20 @@ func (u *OrdersUsecases) OrdersListAdmin(ctx context.Context, Data *entities.Ord
 		Condition["product_id"] = Data.ProductID
 	}
 
+	if Data.OrderID != 0 {
+		Condition["id"] = Data.OrderID
+	}
+
 	Limit, err := strconv.Atoi(Data.Limit)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for limit",
 		}).Error(err)
 		return
 	}
 	Offset, err := strconv.Atoi(Data.Offset)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for offset",
 		}).Error(err)
 		return
@@ -369,148 +571,440 @@ func (u *OrdersUsecases) OrdersListAdmin(ctx context.Context, Data *entities.Ord
 		return
 	}
 
+	err = u.ordersAttachItems(ctx, Orders)
+	if err != nil {
+		return
+	}
+
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "OK",
+		Message: i18n.T(ctx, "ok"),
 		Data:    Orders,
 	}, nil
 }
 
 // OrdersApprove func
 func (u *OrdersUsecases) OrdersApprove(ctx context.Context, Data *entities.OrdersApproveRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersApprove")
+	defer Span.Finish(&err)
+
+	return u.OrdersTransition(ctx, &entities.OrdersTransitionRequest{
+		UserID:  Data.UserID,
+		OrderID: Data.OrderID,
+		Event:   entities.EventApprove,
+		Actor:   Data.Actor,
+	})
+}
+
+// OrdersReject func
+func (u *OrdersUsecases) OrdersReject(ctx context.Context, Data *entities.OrdersRejectRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersReject")
+	defer Span.Finish(&err)
+
+	return u.OrdersTransition(ctx, &entities.OrdersTransitionRequest{
+		UserID:  Data.UserID,
+		OrderID: Data.OrderID,
+		Event:   entities.EventReject,
+		Actor:   Data.Actor,
+	})
+}
+
+// OrdersTransition func move the order with Data.Event when entities.OrdersTransitions
+// allow it from the current status for Data.Actor
+func (u *OrdersUsecases) OrdersTransition(ctx context.Context, Data *entities.OrdersTransitionRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersTransition")
+	defer Span.Finish(&err)
+
 	Orders, err := u.OrdersRepository.OrdersFindByID(ctx, Data.OrderID)
 	if err != nil {
 		return
 	}
 
-	if Orders.Status != entities.Pending {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Order tidak sedang pending",
-		}, nil
+	if Orders == nil {
+		return nil, apperr.NotFound("order_not_found")
 	}
 
-	Tx, err := u.OrdersRepository.Tx()
+	Transition, err := ordersTransitionGuard(Orders, Data.Event, Data.Actor, Data.UserID)
 	if err != nil {
 		return
 	}
-	defer Tx.RollbackUnlessCommitted()
 
-	OrdersLog := &entities.OrdersLog{
-		OrderID:     Orders.ID,
-		UserID:      Orders.UserID,
-		ProductID:   Orders.ProductID,
-		ProductName: Orders.ProductName,
-		Price:       Orders.Price,
-		Qty:         Orders.Qty,
-		TotalPrice:  Orders.TotalPrice,
-		Status:      entities.Approve,
-		Event:       entities.EventApprove,
-		AdminID:     Data.UserID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	AdminID := 0
+	if Data.Actor == entities.ActorAdmin {
+		AdminID = Data.UserID
 	}
 
-	UpdatePayload := map[string]interface{}{
-		"status":     entities.Approve,
-		"updated_at": time.Now(),
+	err = u.ordersChangeStatus(ctx, Orders, Transition, AdminID)
+	if err != nil {
+		return nil, productsUnavailable(err)
 	}
 
-	err = u.OrdersRepository.OrdersUpdate(ctx, Tx, Data.OrderID, UpdatePayload)
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, string(entities.OrdersDomainEvents[Transition.Event])),
+	}, nil
+}
+
+// OrdersExpire func expire the orders that stay pending longer than PendingFor
+func (u *OrdersUsecases) OrdersExpire(ctx context.Context, PendingFor time.Duration, Limit int) (Expired int, err error) {
+	ctx, Span := tracing.Start(ctx, "OrdersUsecases.OrdersExpire")
+	defer Span.Finish(&err)
+
+	Orders, err := u.OrdersRepository.OrdersFindStale(ctx, entities.Pending, time.Now().Add(-PendingFor), Limit)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
+	for _, Order := range Orders {
+		_, TransitionErr := u.OrdersTransition(ctx, &entities.OrdersTransitionRequest{
+			OrderID: Order.ID,
+			Event:   entities.EventExpire,
+			Actor:   entities.ActorSystem,
+		})
+
+		// An order moved by its owner or an admin in the meantime is not stale
+		// anymore, it must not hold back the orders after it
+		switch apperr.KindOf(TransitionErr) {
+		case "":
+			Expired++
+		case apperr.KindConflict, apperr.KindNotFound, apperr.KindValidation:
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event":    "skip order that can not expire",
+				"order_id": Order.ID,
+			}).Warn(TransitionErr)
+		default:
+			return Expired, TransitionErr
+		}
+	}
+
+	return
+}
+
+// ordersChangeStatus func move the order and every open line with Transition,
+// the qty of the open lines is given back to products services when the
+// transition release the stock
+func (u *OrdersUsecases) ordersChangeStatus(ctx context.Context, Orders *entities.Orders, Transition *entities.OrdersTransition, AdminID int) (err error) {
+	OrdersItems, err := u.OrdersRepository.OrdersItemsFindByOrderIDs(ctx, []int{Orders.ID})
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
+	Orders.Items = OrdersItems
 
-	defer Tx.Commit()
+	OpenItems := []*entities.OrdersItems{}
+	for _, OrdersItem := range OrdersItems {
+		if OrdersItem.Status != entities.ItemsCancel {
+			OpenItems = append(OpenItems, OrdersItem)
+		}
+	}
 
-	return &pkg.JSONResponse{
-		Code:    200,
-		Message: "Orders berhasil di approve",
-	}, nil
+	if Transition.ReleaseStock {
+		Steps := []*entities.SagasSteps{}
+		for _, OrdersItem := range OpenItems {
+			Steps = append(Steps, StockReleaseStep(OrdersItem.ReservationID, OrdersItem.ProductID, OrdersItem.Qty))
+		}
+
+		Sagas, err := u.SagasUsecase.SagasStart(ctx, Transition.Sagas, Orders.ID, Steps)
+		if err != nil {
+			return err
+		}
+
+		err = u.SagasUsecase.SagasRun(ctx, Sagas)
+		if err != nil {
+			return err
+		}
+
+		return u.ordersStoreStatus(ctx, Orders, OpenItems, Transition, AdminID, Sagas)
+	}
+
+	return u.ordersStoreStatus(ctx, Orders, OpenItems, Transition, AdminID, nil)
 }
 
-// OrdersReject func
-func (u *OrdersUsecases) OrdersReject(ctx context.Context, Data *entities.OrdersRejectRequest) (Response *pkg.JSONResponse, err error) {
-	Orders, err := u.OrdersRepository.OrdersFindByID(ctx, Data.OrderID)
+// ordersStoreStatus func store the new status of the order and its open lines,
+// Sagas is completed in the same transaction when the stock was released
+func (u *OrdersUsecases) ordersStoreStatus(ctx context.Context, Orders *entities.Orders, OpenItems []*entities.OrdersItems, Transition *entities.OrdersTransition, AdminID int, Sagas *entities.Sagas) (err error) {
+	if Sagas != nil {
+		defer u.sagasCompensateOnError(ctx, Sagas, &err)
+	}
+
+	Tx, err := u.OrdersRepository.Tx()
 	if err != nil {
 		return
 	}
+	defer Tx.RollbackUnlessCommitted()
 
-	if Orders.Status != entities.Pending {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Order tidak sedang pending",
-		}, nil
+	UpdatePayload := map[string]interface{}{
+		"status":     Transition.To,
+		"updated_at": time.Now(),
 	}
 
-	Tx, err := u.OrdersRepository.Tx()
+	Updated, err := u.OrdersRepository.OrdersUpdateStatus(ctx, Tx, Orders.ID, Orders.Status, UpdatePayload)
 	if err != nil {
 		return
 	}
-	defer Tx.RollbackUnlessCommitted()
+
+	if !Updated {
+		return errOrdersStatusChanged()
+	}
 
 	OrdersLog := &entities.OrdersLog{
-		OrderID:     Orders.ID,
-		UserID:      Orders.UserID,
-		ProductID:   Orders.ProductID,
-		ProductName: Orders.ProductName,
-		Price:       Orders.Price,
-		Qty:         Orders.Qty,
-		TotalPrice:  Orders.TotalPrice,
-		Status:      entities.Reject,
-		Event:       entities.EventReject,
-		AdminID:     Data.UserID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		OrderID:    Orders.ID,
+		UserID:     Orders.UserID,
+		TotalPrice: Orders.TotalPrice,
+		Status:     Transition.To,
+		Event:      Transition.Event,
+		AdminID:    AdminID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
-	UpdatePayload := map[string]interface{}{
-		"status":     entities.Reject,
-		"updated_at": time.Now(),
+	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
+	if err != nil {
+		return
 	}
 
-	err = u.OrdersRepository.OrdersUpdate(ctx, Tx, Data.OrderID, UpdatePayload)
+	// The lines keep their status when the transition does not set one
+	if Transition.ItemsStatus != 0 {
+		for _, OrdersItem := range OpenItems {
+			OrdersItem.Status = Transition.ItemsStatus
+			OrdersItem.UpdatedAt = time.Now()
+
+			err = u.OrdersRepository.OrdersItemsUpdate(ctx, Tx, OrdersItem.ID, map[string]interface{}{
+				"status":     OrdersItem.Status,
+				"updated_at": OrdersItem.UpdatedAt,
+			})
+			if err != nil {
+				return
+			}
+
+			_, err = u.OrdersRepository.OrdersItemsLogStore(ctx, Tx, ordersItemsLog(OrdersItem, Transition.Event))
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	Orders.Status = Transition.To
+	Orders.UpdatedAt = OrdersLog.UpdatedAt
+
+	err = u.ordersOutboxStore(ctx, Tx, Orders, Transition.Event)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	OrdersLog.ID, err = u.OrdersRepository.OrdersLogStore(ctx, Tx, OrdersLog)
+	if Sagas != nil {
+		err = u.SagasUsecase.SagasComplete(ctx, Tx, Sagas, Orders.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	err = Tx.Commit()
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
+	metrics.OrdersEvent(string(Transition.Event))
+
+	return
+}
+
+// ordersTransitionGuard func check the order can move with Event from its
+// status and Actor may trigger it, err is the rejection when it can not
+func ordersTransitionGuard(Orders *entities.Orders, Event entities.OrdersEvent, Actor entities.OrdersActor, UserID int) (Transition *entities.OrdersTransition, err error) {
+	Transition = entities.OrdersTransitions[Event]
+	if Transition == nil {
+		return nil, apperr.Validation("order_event_unknown", string(Event))
+	}
+
+	if !Transition.CanFrom(Orders.Status) {
+		AppErr := apperr.Validation("order_status_invalid", strings.ToLower(string(Event)))
+		if len(Transition.From) == 1 && Transition.From[0] == entities.Pending {
+			AppErr.WithKey("order_not_pending")
+		}
+
+		return nil, AppErr
+	}
 
-	GetProductsByIDPayload := &entities.GetProductsByIDPayload{
-		ProductID: Orders.ProductID,
+	if !Transition.AllowedActor(Actor) {
+		return nil, apperr.Forbidden("order_actor_forbidden")
 	}
-	Products, err := u.ProductsRepository.GetProductsByID(ctx, GetProductsByIDPayload)
+
+	if Actor == entities.ActorOwner && Orders.UserID != UserID {
+		return nil, apperr.Forbidden("order_not_owned")
+	}
+
+	return Transition, nil
+}
+
+// ordersOutboxStore store the domain event of the order with its lines in the outbox
+func (u *OrdersUsecases) ordersOutboxStore(ctx context.Context, Tx transaction.Tx, Orders *entities.Orders, Event entities.OrdersEvent) (err error) {
+	Message, err := outbox.New(entities.OrdersAggregate, Orders.ID, string(entities.OrdersDomainEvents[Event]), Orders)
 	if err != nil {
 		return
 	}
 
-	ProductsUpdatePayload := &entities.ProductsUpdatePayload{
-		UserID:    0,
-		ProductID: Orders.ProductID,
-		Qty:       Products.Qty + Orders.Qty,
+	_, err = u.OrdersRepository.OutboxStore(ctx, Tx, Message)
+	return
+}
+
+// ordersRollForward func store the status of Transition for its saga left
+// running with every release done. The saga is compensated when the order moved
+// or got other lines in the meantime, the stock is taken back for them
+func (u *OrdersUsecases) ordersRollForward(Transition *entities.OrdersTransition) SagasRollForward {
+	return func(ctx context.Context, Sagas *entities.Sagas) (err error) {
+		Orders, err := u.OrdersRepository.OrdersFindByID(ctx, Sagas.OrderID)
+		if err != nil {
+			return
+		}
+
+		OpenItems := []*entities.OrdersItems{}
+		if Orders != nil && Transition.CanFrom(Orders.Status) {
+			Orders.Items, err = u.OrdersRepository.OrdersItemsFindByOrderIDs(ctx, []int{Orders.ID})
+			if err != nil {
+				return
+			}
+
+			for _, OrdersItem := range Orders.Items {
+				if OrdersItem.Status != entities.ItemsCancel {
+					OpenItems = append(OpenItems, OrdersItem)
+				}
+			}
+		}
+
+		if Orders == nil || !Transition.CanFrom(Orders.Status) || !sagasReleaseAll(Sagas, OpenItems) {
+			return u.SagasUsecase.SagasCompensate(ctx, Sagas, "the order moved before the saga was rolled forward")
+		}
+
+		return u.ordersStoreStatus(ctx, Orders, OpenItems, Transition, 0, Sagas)
+	}
+}
+
+// sagasReleaseAll func, the release steps of Sagas are the reservations of
+// OpenItems, no more and no less
+func sagasReleaseAll(Sagas *entities.Sagas, OpenItems []*entities.OrdersItems) bool {
+	Released := map[string]bool{}
+	for _, Step := range Sagas.Steps {
+		Payload := &entities.SagasStockPayload{}
+		if Step.Action != entities.ActionStockRelease || json.Unmarshal([]byte(Step.Payload), Payload) != nil {
+			return false
+		}
+		Released[Payload.ReservationID] = true
+	}
+
+	if len(Released) != len(OpenItems) {
+		return false
+	}
+	for _, OrdersItem := range OpenItems {
+		if !Released[OrdersItem.ReservationID] {
+			return false
+		}
+	}
+	return true
+}
+
+// sagasCompensateOnError func compensate the remote steps of a saga that ran
+// when the local change after it fail
+func (u *OrdersUsecases) sagasCompensateOnError(ctx context.Context, Sagas *entities.Sagas, err *error) {
+	if *err == nil || Sagas.Status != entities.SagasRunning {
+		return
+	}
+
+	if CompensateErr := u.SagasUsecase.SagasCompensate(ctx, Sagas, (*err).Error()); CompensateErr != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event":   "error when compensate saga after local change failed",
+			"saga_id": Sagas.ID,
+		}).Error(CompensateErr)
+	}
+}
+
+// ordersPriceChanged func, the 409 when one of the prices the client expected
+// for the product is not its current price in products services
+func ordersPriceChanged(Products *entities.Products, ExpectedPrices []*money.Money) error {
+	for _, ExpectedPrice := range ExpectedPrices {
+		if ExpectedPrice == nil || ExpectedPrice.Equal(Products.Price) {
+			continue
+		}
+
+		return apperr.Conflict("price_changed", Products.Name).WithData(&entities.PriceChangedResponse{
+			ProductID:     Products.ID,
+			ProductName:   Products.Name,
+			ExpectedPrice: *ExpectedPrice,
+			Price:         Products.Price,
+		})
 	}
-	err = u.ProductsRepository.ProductsUpdate(ctx, ProductsUpdatePayload)
+
+	return nil
+}
+
+// productsUnavailable func, the 503 when products services is not called
+// because its circuit breaker is open, any other err is returned as is
+func productsUnavailable(err error) error {
+	if !errors.Is(err, serviceclient.ErrCircuitOpen) {
+		return err
+	}
+
+	return apperr.Unavailable("products_unavailable", err)
+}
+
+// moneyError func, the 422 when a total does not fit in money.Money. Two
+// currencies in one order is a bug, it stay an internal error
+func moneyError(err error) error {
+	if !errors.Is(err, money.ErrOverflow) {
+		return err
+	}
+
+	return apperr.Validation("amount_too_large").Wrap(err)
+}
+
+// ordersAttachItems func load the lines of every order in one query
+func (u *OrdersUsecases) ordersAttachItems(ctx context.Context, Orders []*entities.Orders) (err error) {
+	OrderIDs := []int{}
+	OrdersByID := map[int]*entities.Orders{}
+	for _, Order := range Orders {
+		Order.Items = []*entities.OrdersItems{}
+		OrderIDs = append(OrderIDs, Order.ID)
+		OrdersByID[Order.ID] = Order
+	}
+
+	OrdersItems, err := u.OrdersRepository.OrdersItemsFindByOrderIDs(ctx, OrderIDs)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	defer Tx.Commit()
+	for _, OrdersItem := range OrdersItems {
+		if Order, ok := OrdersByID[OrdersItem.OrderID]; ok {
+			Order.Items = append(Order.Items, OrdersItem)
+		}
+	}
+
+	return
+}
 
-	return &pkg.JSONResponse{
-		Code:    200,
-		Message: "Orders berhasil di reject",
-	}, nil
+// ordersItemsLog func
+func ordersItemsLog(OrdersItem *entities.OrdersItems, Event entities.OrdersEvent) *entities.OrdersItemsLog {
+	return &entities.OrdersItemsLog{
+		OrderItemID: OrdersItem.ID,
+		OrderID:     OrdersItem.OrderID,
+		ProductID:   OrdersItem.ProductID,
+		ProductName: OrdersItem.ProductName,
+		Price:       OrdersItem.Price,
+		Qty:         OrdersItem.Qty,
+		TotalPrice:  OrdersItem.TotalPrice,
+		Status:      OrdersItem.Status,
+		Event:       Event,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// stockReservationID func
+func stockReservationID() (ID string, err error) {
+	Random := make([]byte, 16)
+	if _, err = rand.Read(Random); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when generate stock reservation id",
+		}).Error(err)
+		return
+	}
+
+	return hex.EncodeToString(Random), nil
 }
@@ -0,0 +1,652 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/memory"
+	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/money"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+)
+
+// ordersTest struct, the usecases with their in memory storage
+type ordersTest struct {
+	Usecase            *OrdersUsecases
+	OrdersRepository   *memory.OrdersRepository
+	ProductsRepository *memory.ProductsRepository
+	SagasRepository    *memory.SagasRepository
+}
+
+// newOrdersTest func, products 1 and 2 are active and product 3 is not
+func newOrdersTest() *ordersTest {
+	OrdersRepository := memory.NewOrdersRepository()
+	ProductsRepository := memory.NewProductsRepository(
+		&entities.Products{ID: 1, Name: "Kopi", Price: money.FromMajor(15000, "IDR"), Qty: 10, Status: entities.Active},
+		&entities.Products{ID: 2, Name: "Teh", Price: money.FromMajor(5000, "IDR"), Qty: 3, Status: entities.Active},
+		&entities.Products{ID: 3, Name: "Susu", Price: money.FromMajor(8000, "IDR"), Qty: 5, Status: entities.InActive},
+	)
+	SagasRepository := memory.NewSagasRepository()
+
+	return &ordersTest{
+		Usecase:            InitOrdersUsecases(OrdersRepository, ProductsRepository, InitSagasUsecases(SagasRepository, ProductsRepository)),
+		OrdersRepository:   OrdersRepository,
+		ProductsRepository: ProductsRepository,
+		SagasRepository:    SagasRepository,
+	}
+}
+
+// create func create an order of UserID with Qty of product 1
+func (o *ordersTest) create(t *testing.T, UserID int, Qty int) *entities.Orders {
+	t.Helper()
+	Response, err := o.Usecase.OrdersCreate(context.Background(), &entities.OrdersCreateRequest{
+		UserID: UserID,
+		Items:  []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: Qty}},
+	})
+	if err != nil || Response.Code != 200 {
+		t.Fatalf("create order: %v %+v", err, Response)
+	}
+	return Response.Data.(*entities.Orders)
+}
+
+// ordersTestStatus func, the status of Response or the one err is sent with
+func ordersTestStatus(Response *pkg.JSONResponse, err error) int {
+	if err != nil {
+		return apperr.Status(err)
+	}
+	return Response.Code
+}
+
+// check func compare the stock of the products and the events of the outbox,
+// no saga may be left running
+func (o *ordersTest) check(t *testing.T, Stock map[int]int, Events []string) {
+	t.Helper()
+	for ProductID, Qty := range Stock {
+		if Got := o.ProductsRepository.Products[ProductID].Qty; Got != Qty {
+			t.Errorf("stock of product %d = %d, want %d", ProductID, Got, Qty)
+		}
+	}
+	if Got := memory.OutboxEvents(o.OrdersRepository.Outbox); !reflect.DeepEqual(Got, Events) {
+		t.Errorf("outbox = %v, want %v", Got, Events)
+	}
+	for _, Sagas := range o.SagasRepository.Sagas {
+		if Sagas.Status == entities.SagasRunning {
+			t.Errorf("saga %d %s is left with status %d", Sagas.ID, Sagas.Name, Sagas.Status)
+		}
+	}
+}
+
+func TestOrdersCreate(t *testing.T) {
+	Failure := errors.New("database is down")
+	Price := money.FromMajor(15000, "IDR")
+	OldPrice := money.FromMajor(12000, "IDR")
+	Created := []string{string(entities.EventOrderCreated)}
+
+	Tests := []struct {
+		Name        string
+		Items       []*entities.OrdersItemsCreateRequest
+		ProductsErr error
+		Errors      map[string]error
+		Code        int
+		Err         error
+		TotalPrice  money.Money
+		Stock       map[int]int
+		Events      []string
+	}{
+		{
+			Name:       "reserve and commit the stock",
+			Items:      []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 2}, {ProductID: 2, Qty: 1}},
+			Code:       200,
+			TotalPrice: money.FromMajor(35000, "IDR"),
+			Stock:      map[int]int{1: 8, 2: 2},
+			Events:     Created,
+		},
+		{
+			Name:       "same product is merged into one line",
+			Items:      []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1}, {ProductID: 1, Qty: 2}},
+			Code:       200,
+			TotalPrice: money.FromMajor(45000, "IDR"),
+			Stock:      map[int]int{1: 7},
+			Events:     Created,
+		},
+		{
+			Name:       "expected price is the price",
+			Items:      []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1, ExpectedPrice: &Price}},
+			Code:       200,
+			TotalPrice: Price,
+			Stock:      map[int]int{1: 9},
+			Events:     Created,
+		},
+		{
+			Name:  "price changed",
+			Items: []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1, ExpectedPrice: &OldPrice}},
+			Code:  409,
+			Stock: map[int]int{1: 10},
+		},
+		{
+			Name:  "inactive product",
+			Items: []*entities.OrdersItemsCreateRequest{{ProductID: 3, Qty: 1}},
+			Code:  422,
+			Stock: map[int]int{3: 5},
+		},
+		{
+			Name:  "unknown product",
+			Items: []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1}, {ProductID: 99, Qty: 1}},
+			Code:  404,
+			Stock: map[int]int{1: 10},
+		},
+		{
+			Name:  "qty more than the stock",
+			Items: []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1}, {ProductID: 2, Qty: 4}},
+			Code:  422,
+			Stock: map[int]int{1: 10, 2: 3},
+		},
+		{
+			Name:        "products services breaker is open",
+			Items:       []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 1}},
+			ProductsErr: serviceclient.ErrCircuitOpen,
+			Code:        503,
+		},
+		{
+			Name:   "orders log fail give the stock back",
+			Items:  []*entities.OrdersItemsCreateRequest{{ProductID: 1, Qty: 2}},
+			Errors: map[string]error{"OrdersLogStore": Failure},
+			Err:    Failure,
+			Stock:  map[int]int{1: 10},
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			o := newOrdersTest()
+			o.ProductsRepository.Err = Test.ProductsErr
+			for Method, err := range Test.Errors {
+				o.OrdersRepository.Errors[Method] = err
+			}
+
+			Response, err := o.Usecase.OrdersCreate(context.Background(), &entities.OrdersCreateRequest{
+				UserID: 1,
+				Items:  Test.Items,
+			})
+			if Test.Err != nil && !errors.Is(err, Test.Err) {
+				t.Fatalf("err = %v, want %v", err, Test.Err)
+			}
+			if Code := ordersTestStatus(Response, err); Test.Err == nil && Code != Test.Code {
+				t.Fatalf("code = %d, want %d (%v)", Code, Test.Code, err)
+			}
+
+			o.check(t, Test.Stock, Test.Events)
+			if Test.Code != 200 {
+				if len(o.OrdersRepository.Orders) != 0 || len(o.OrdersRepository.OrdersItems) != 0 {
+					t.Errorf("order is stored by a failed create")
+				}
+				return
+			}
+
+			Orders := o.OrdersRepository.Orders[Response.Data.(*entities.Orders).ID]
+			if Orders.Status != entities.Pending || !Orders.TotalPrice.Equal(Test.TotalPrice) {
+				t.Errorf("order = %+v, want pending with total price %s", Orders, Test.TotalPrice)
+			}
+			for _, Reservation := range o.ProductsRepository.Reservations {
+				if Reservation.Status != entities.StockCommitted {
+					t.Errorf("reservation %s is not committed", Reservation.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdersUpdate(t *testing.T) {
+	Failure := errors.New("database is down")
+	OldPrice := money.FromMajor(4000, "IDR")
+	SnapshotPrice := money.FromMajor(15000, "IDR")
+	NewPrice := money.FromMajor(16000, "IDR")
+	Created := string(entities.EventOrderCreated)
+	Updated := string(entities.EventOrderUpdated)
+
+	Tests := []struct {
+		Name        string
+		UserID      int
+		OrderID     int
+		Approved    bool
+		Items       []*entities.OrdersItemsUpdateRequest
+		Price       *money.Money
+		ProductsErr error
+		Errors      map[string]error
+		Code        int
+		Err         error
+		TotalPrice  money.Money
+		Stock       map[int]int
+		Events      []string
+	}{
+		{
+			Name:       "more qty reserve more stock",
+			Items:      []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			Code:       200,
+			TotalPrice: money.FromMajor(75000, "IDR"),
+			Stock:      map[int]int{1: 5},
+			Events:     []string{Created, Updated},
+		},
+		{
+			Name:       "less qty give the stock back",
+			Items:      []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 1}},
+			Code:       200,
+			TotalPrice: money.FromMajor(15000, "IDR"),
+			Stock:      map[int]int{1: 9},
+			Events:     []string{Created, Updated},
+		},
+		{
+			Name:       "new product is added",
+			Items:      []*entities.OrdersItemsUpdateRequest{{ProductID: 2, Qty: 1}},
+			Code:       200,
+			TotalPrice: money.FromMajor(35000, "IDR"),
+			Stock:      map[int]int{1: 8, 2: 2},
+			Events:     []string{Created, Updated},
+		},
+		{
+			Name:   "removing every product",
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 0}},
+			Code:   422,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+		{
+			Name:   "price of the new product changed",
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 2, Qty: 1, ExpectedPrice: &OldPrice}},
+			Code:   409,
+			Stock:  map[int]int{1: 8, 2: 3},
+			Events: []string{Created},
+		},
+		{
+			Name:   "price of an existing product changed",
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5, ExpectedPrice: &SnapshotPrice}},
+			Price:  &NewPrice,
+			Code:   409,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+		{
+			Name:       "existing product keep its price",
+			Items:      []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5, ExpectedPrice: &NewPrice}},
+			Price:      &NewPrice,
+			Code:       200,
+			TotalPrice: money.FromMajor(75000, "IDR"),
+			Stock:      map[int]int{1: 5},
+			Events:     []string{Created, Updated},
+		},
+		{
+			Name:   "qty more than the stock",
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 20}},
+			Code:   422,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+		{
+			Name:   "order of another users",
+			UserID: 2,
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			Code:   403,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+		{
+			Name:     "order is not pending",
+			Approved: true,
+			Items:    []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			Code:     422,
+			Stock:    map[int]int{1: 8},
+			Events:   []string{Created, string(entities.EventOrderApproved)},
+		},
+		{
+			Name:    "unknown order",
+			OrderID: 99,
+			Items:   []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			Code:    404,
+			Stock:   map[int]int{1: 8},
+			Events:  []string{Created},
+		},
+		{
+			Name:        "products services breaker is open",
+			Items:       []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			ProductsErr: serviceclient.ErrCircuitOpen,
+			Code:        503,
+			Stock:       map[int]int{1: 8},
+			Events:      []string{Created},
+		},
+		{
+			Name:   "orders items fail give the stock back",
+			Items:  []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 5}},
+			Errors: map[string]error{"OrdersItemsUpdate": Failure},
+			Err:    Failure,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			o := newOrdersTest()
+			Orders := o.create(t, 1, 2)
+			if Test.Approved {
+				if Response, err := o.Usecase.OrdersApprove(context.Background(), &entities.OrdersApproveRequest{UserID: 9, OrderID: Orders.ID, Actor: entities.ActorAdmin}); err != nil || Response.Code != 200 {
+					t.Fatalf("approve order: %v %+v", err, Response)
+				}
+			}
+
+			if Test.Price != nil {
+				o.ProductsRepository.Products[1].Price = *Test.Price
+			}
+			o.ProductsRepository.Err = Test.ProductsErr
+			for Method, err := range Test.Errors {
+				o.OrdersRepository.Errors[Method] = err
+			}
+
+			UserID, OrderID := 1, Orders.ID
+			if Test.UserID != 0 {
+				UserID = Test.UserID
+			}
+			if Test.OrderID != 0 {
+				OrderID = Test.OrderID
+			}
+
+			Response, err := o.Usecase.OrdersUpdate(context.Background(), &entities.OrdersUpdateRequest{
+				UserID:  UserID,
+				OrderID: OrderID,
+				Items:   Test.Items,
+			})
+			if Test.Err != nil && !errors.Is(err, Test.Err) {
+				t.Fatalf("err = %v, want %v", err, Test.Err)
+			}
+			if Code := ordersTestStatus(Response, err); Test.Err == nil && Code != Test.Code {
+				t.Fatalf("code = %d, want %d (%v)", Code, Test.Code, err)
+			}
+
+			o.check(t, Test.Stock, Test.Events)
+			if Test.Code == 200 {
+				if Stored := o.OrdersRepository.Orders[Orders.ID]; !Stored.TotalPrice.Equal(Test.TotalPrice) {
+					t.Errorf("total price = %s, want %s", Stored.TotalPrice, Test.TotalPrice)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdersTransition(t *testing.T) {
+	Created := string(entities.EventOrderCreated)
+
+	Tests := []struct {
+		Name        string
+		Before      []entities.OrdersEvent
+		Event       entities.OrdersEvent
+		UserID      int
+		ProductsErr error
+		Code        int
+		Status      entities.OrdersStatus
+		ItemsStatus entities.OrdersItemsStatus
+		Stock       int
+		Events      []string
+	}{
+		{
+			Name:        "owner cancel give the stock back",
+			Event:       entities.EventCancel,
+			UserID:      1,
+			Code:        200,
+			Status:      entities.Cancel,
+			ItemsStatus: entities.ItemsCancel,
+			Stock:       10,
+			Events:      []string{Created, string(entities.EventOrderCancelled)},
+		},
+		{
+			Name:        "cancel order of another users",
+			Event:       entities.EventCancel,
+			UserID:      2,
+			Code:        403,
+			Status:      entities.Pending,
+			ItemsStatus: entities.ItemsPending,
+			Stock:       8,
+			Events:      []string{Created},
+		},
+		{
+			Name:        "admin approve keep the stock",
+			Event:       entities.EventApprove,
+			UserID:      9,
+			Code:        200,
+			Status:      entities.Approve,
+			ItemsStatus: entities.ItemsApprove,
+			Stock:       8,
+			Events:      []string{Created, string(entities.EventOrderApproved)},
+		},
+		{
+			Name:        "admin reject give the stock back",
+			Event:       entities.EventReject,
+			UserID:      9,
+			Code:        200,
+			Status:      entities.Reject,
+			ItemsStatus: entities.ItemsReject,
+			Stock:       10,
+			Events:      []string{Created, string(entities.EventOrderRejected)},
+		},
+		{
+			Name:        "cancel approved order",
+			Before:      []entities.OrdersEvent{entities.EventApprove},
+			Event:       entities.EventCancel,
+			UserID:      1,
+			Code:        422,
+			Status:      entities.Approve,
+			ItemsStatus: entities.ItemsApprove,
+			Stock:       8,
+			Events:      []string{Created, string(entities.EventOrderApproved)},
+		},
+		{
+			Name:        "reject cancelled order",
+			Before:      []entities.OrdersEvent{entities.EventCancel},
+			Event:       entities.EventReject,
+			UserID:      9,
+			Code:        422,
+			Status:      entities.Cancel,
+			ItemsStatus: entities.ItemsCancel,
+			Stock:       10,
+			Events:      []string{Created, string(entities.EventOrderCancelled)},
+		},
+		{
+			Name:        "approve twice",
+			Before:      []entities.OrdersEvent{entities.EventApprove},
+			Event:       entities.EventApprove,
+			UserID:      9,
+			Code:        422,
+			Status:      entities.Approve,
+			ItemsStatus: entities.ItemsApprove,
+			Stock:       8,
+			Events:      []string{Created, string(entities.EventOrderApproved)},
+		},
+		{
+			Name:        "service can not approve",
+			Event:       entities.EventApprove,
+			UserID:      0,
+			Code:        403,
+			Status:      entities.Pending,
+			ItemsStatus: entities.ItemsPending,
+			Stock:       8,
+			Events:      []string{Created},
+		},
+		{
+			Name:        "service can not reject",
+			Event:       entities.EventReject,
+			UserID:      0,
+			Code:        403,
+			Status:      entities.Pending,
+			ItemsStatus: entities.ItemsPending,
+			Stock:       8,
+			Events:      []string{Created},
+		},
+		{
+			Name:        "reject when products services breaker is open",
+			Event:       entities.EventReject,
+			UserID:      9,
+			ProductsErr: serviceclient.ErrCircuitOpen,
+			Code:        503,
+			Status:      entities.Pending,
+			ItemsStatus: entities.ItemsPending,
+			Stock:       8,
+			Events:      []string{Created},
+		},
+	}
+
+	// request func run Event on the order the way its route does
+	request := func(o *ordersTest, Event entities.OrdersEvent, UserID int, OrderID int) (*pkg.JSONResponse, error) {
+		// user 0 is another services, it act as the system on the admin routes
+		Actor := entities.ActorAdmin
+		if UserID == 0 {
+			Actor = entities.ActorSystem
+		}
+
+		switch Event {
+		case entities.EventCancel:
+			return o.Usecase.OrdersCancel(context.Background(), &entities.OrdersCancelRequest{UserID: UserID, OrderID: OrderID})
+		case entities.EventApprove:
+			return o.Usecase.OrdersApprove(context.Background(), &entities.OrdersApproveRequest{UserID: UserID, OrderID: OrderID, Actor: Actor})
+		default:
+			return o.Usecase.OrdersReject(context.Background(), &entities.OrdersRejectRequest{UserID: UserID, OrderID: OrderID, Actor: Actor})
+		}
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			o := newOrdersTest()
+			Orders := o.create(t, 1, 2)
+			for _, Event := range Test.Before {
+				UserID := 9
+				if Event == entities.EventCancel {
+					UserID = 1
+				}
+				if Response, err := request(o, Event, UserID, Orders.ID); err != nil || Response.Code != 200 {
+					t.Fatalf("%s order: %v %+v", Event, err, Response)
+				}
+			}
+
+			o.ProductsRepository.Err = Test.ProductsErr
+			Response, err := request(o, Test.Event, Test.UserID, Orders.ID)
+			if Code := ordersTestStatus(Response, err); Code != Test.Code {
+				t.Fatalf("code = %d, want %d (%v)", Code, Test.Code, err)
+			}
+
+			o.ProductsRepository.Err = nil
+			o.check(t, map[int]int{1: Test.Stock}, Test.Events)
+			if Status := o.OrdersRepository.Orders[Orders.ID].Status; Status != Test.Status {
+				t.Errorf("status = %d, want %d", Status, Test.Status)
+			}
+			for _, OrdersItem := range o.OrdersRepository.OrdersItems {
+				if OrdersItem.Status != Test.ItemsStatus {
+					t.Errorf("items status = %d, want %d", OrdersItem.Status, Test.ItemsStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdersLegacyItems(t *testing.T) {
+	Tests := []struct {
+		Name   string
+		Run    func(o *ordersTest, OrderID int) (*pkg.JSONResponse, error)
+		Stock  int
+		Events []string
+	}{
+		{
+			Name: "cancel give the adopted stock back",
+			Run: func(o *ordersTest, OrderID int) (*pkg.JSONResponse, error) {
+				return o.Usecase.OrdersCancel(context.Background(), &entities.OrdersCancelRequest{UserID: 1, OrderID: OrderID})
+			},
+			Stock:  10,
+			Events: []string{string(entities.EventOrderCancelled)},
+		},
+		{
+			Name: "more qty only take the difference",
+			Run: func(o *ordersTest, OrderID int) (*pkg.JSONResponse, error) {
+				return o.Usecase.OrdersUpdate(context.Background(), &entities.OrdersUpdateRequest{
+					UserID:  1,
+					OrderID: OrderID,
+					Items:   []*entities.OrdersItemsUpdateRequest{{ProductID: 1, Qty: 3}},
+				})
+			},
+			Stock:  7,
+			Events: []string{string(entities.EventOrderUpdated)},
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			o := newOrdersTest()
+
+			// an order moved into orders_items by the 00003 migration, its
+			// stock was taken before the reservations existed
+			o.ProductsRepository.Products[1].Qty = 8
+			Price := money.FromMajor(15000, "IDR")
+			TotalPrice := money.FromMajor(30000, "IDR")
+			Tx, _ := o.OrdersRepository.Tx()
+			OrderID, _ := o.OrdersRepository.OrdersStore(context.Background(), Tx, &entities.Orders{
+				UserID:     1,
+				TotalPrice: TotalPrice,
+				Status:     entities.Pending,
+			})
+			o.OrdersRepository.OrdersItemsStore(context.Background(), Tx, &entities.OrdersItems{
+				OrderID:       OrderID,
+				ProductID:     1,
+				ReservationID: entities.LegacyReservationPrefix + "1",
+				ProductName:   "Kopi",
+				Price:         Price,
+				Qty:           2,
+				TotalPrice:    TotalPrice,
+				Status:        entities.ItemsPending,
+			})
+			Tx.Commit()
+
+			if Response, err := Test.Run(o, OrderID); err != nil || Response.Code != 200 {
+				t.Fatalf("run: %v %+v", err, Response)
+			}
+			o.check(t, map[int]int{1: Test.Stock}, Test.Events)
+		})
+	}
+}
+
+// staleOrdersRepository struct, OrdersFindStale return Stale as it was read
+// before the orders moved
+type staleOrdersRepository struct {
+	*memory.OrdersRepository
+	Stale []*entities.Orders
+}
+
+// OrdersFindStale func
+func (r *staleOrdersRepository) OrdersFindStale(ctx context.Context, Status entities.OrdersStatus, UpdatedBefore time.Time, Limit int) ([]*entities.Orders, error) {
+	return r.Stale, nil
+}
+
+func TestOrdersExpire(t *testing.T) {
+	o := newOrdersTest()
+	Cancelled := o.create(t, 1, 2)
+	Stale := o.create(t, 1, 3)
+	o.Usecase.OrdersRepository = &staleOrdersRepository{
+		OrdersRepository: o.OrdersRepository,
+		Stale:            []*entities.Orders{Cancelled, {ID: 99}, Stale},
+	}
+
+	// the owner cancel the first order once the stale orders are read
+	if Response, err := o.Usecase.OrdersCancel(context.Background(), &entities.OrdersCancelRequest{UserID: 1, OrderID: Cancelled.ID}); err != nil || Response.Code != 200 {
+		t.Fatalf("cancel order: %v %+v", err, Response)
+	}
+
+	Expired, err := o.Usecase.OrdersExpire(context.Background(), time.Minute, 10)
+	if err != nil || Expired != 1 {
+		t.Fatalf("expired = %d %v, want 1", Expired, err)
+	}
+
+	Created := string(entities.EventOrderCreated)
+	o.check(t, map[int]int{1: 10}, []string{Created, Created, string(entities.EventOrderCancelled), string(entities.EventOrderExpired)})
+	if Status := o.OrdersRepository.Orders[Stale.ID].Status; Status != entities.Expired {
+		t.Errorf("status = %d, want %d", Status, entities.Expired)
+	}
+}
@@ -0,0 +1,553 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+	"github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+// ISagasUsecases interface
+type ISagasUsecases interface {
+	SagasStart(ctx context.Context, Name entities.SagasName, OrderID int, Steps []*entities.SagasSteps) (Sagas *entities.Sagas, err error)
+	SagasRun(ctx context.Context, Sagas *entities.Sagas) (err error)
+	SagasComplete(ctx context.Context, Tx transaction.Tx, Sagas *entities.Sagas, OrderID int) (err error)
+	SagasCompensate(ctx context.Context, Sagas *entities.Sagas, Reason string) (err error)
+	SagasRecover(ctx context.Context, StaleAfter time.Duration, Limit int) (Recovered int, err error)
+	SagasRollForwardRegister(Name entities.SagasName, RollForward SagasRollForward)
+}
+
+// SagasRollForward func finish the local change of a saga whose remote steps
+// are all done. It must complete the saga with SagasComplete in the transaction
+// of the local change, or compensate it when the change can not be done
+type SagasRollForward func(ctx context.Context, Sagas *entities.Sagas) error
+
+// SagasStepHandler struct, Execute and Compensate must call Checkpoint right
+// before touching the remote service. Resolve tell whether the action (step
+// still executing) or the compensation (step still compensating) of an
+// interrupted step reached the remote service
+type SagasStepHandler struct {
+	Execute    func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error
+	Compensate func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error
+	Resolve    func(ctx context.Context, Step *entities.SagasSteps) (Applied bool, err error)
+}
+
+// SagasUsecases struct
+type SagasUsecases struct {
+	SagasRepository repositories.ISagasRepository
+	Handlers        map[entities.SagasAction]*SagasStepHandler
+	RollForwards    map[entities.SagasName]SagasRollForward
+}
+
+// InitSagasUsecases func
+func InitSagasUsecases(SagasRepository repositories.ISagasRepository, ProductsRepository repositories.IProductsrepository) *SagasUsecases {
+	return &SagasUsecases{
+		SagasRepository: SagasRepository,
+		Handlers:        StockStepHandlers(ProductsRepository),
+		RollForwards:    map[entities.SagasName]SagasRollForward{},
+	}
+}
+
+// SagasRollForwardRegister func, the sagas of Name left running with every step
+// done are finished by RollForward in SagasRecover instead of compensated.
+// Register them before the recovery start
+func (u *SagasUsecases) SagasRollForwardRegister(Name entities.SagasName, RollForward SagasRollForward) {
+	u.RollForwards[Name] = RollForward
+}
+
+// SagasStart func persist the saga and all of its steps before any step run
+func (u *SagasUsecases) SagasStart(ctx context.Context, Name entities.SagasName, OrderID int, Steps []*entities.SagasSteps) (Sagas *entities.Sagas, err error) {
+	ctx, Span := tracing.Start(ctx, "SagasUsecases.SagasStart")
+	defer Span.Finish(&err)
+
+	Tx, err := u.SagasRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	Sagas = &entities.Sagas{
+		Name:      Name,
+		OrderID:   OrderID,
+		Status:    entities.SagasRunning,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	Sagas.ID, err = u.SagasRepository.SagasStore(ctx, Tx, Sagas)
+	if err != nil {
+		return
+	}
+
+	for i, Step := range Steps {
+		Step.SagaID = Sagas.ID
+		Step.Sequence = i + 1
+		Step.Status = entities.StepsPending
+		Step.CreatedAt = time.Now()
+		Step.UpdatedAt = time.Now()
+
+		Step.ID, err = u.SagasRepository.SagasStepsStore(ctx, Tx, Step)
+		if err != nil {
+			return
+		}
+	}
+	Sagas.Steps = Steps
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit sagas start",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SagasRun func execute every step that is not done yet in order. When a step
+// fail, every step that reached the remote service is compensated
+func (u *SagasUsecases) SagasRun(ctx context.Context, Sagas *entities.Sagas) (err error) {
+	ctx, Span := tracing.Start(ctx, "SagasUsecases.SagasRun")
+	defer Span.Finish(&err)
+
+	for _, Step := range Sagas.Steps {
+		if Step.Status == entities.StepsDone {
+			continue
+		}
+
+		Handler, ok := u.Handlers[Step.Action]
+		if !ok {
+			err = fmt.Errorf("saga step action %s is not registered", Step.Action)
+		} else {
+			err = Handler.Execute(ctx, Step, func() error {
+				return u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsExecuting)
+			})
+		}
+		if err == nil {
+			err = u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsDone)
+		}
+
+		if err != nil {
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event":   "error when run saga step",
+				"saga_id": Sagas.ID,
+				"step_id": Step.ID,
+			}).Error(err)
+
+			if CompensateErr := u.SagasCompensate(ctx, Sagas, err.Error()); CompensateErr != nil {
+				logging.FromContext(ctx).WithFields(log.Fields{
+					"event":   "error when compensate saga",
+					"saga_id": Sagas.ID,
+				}).Error(CompensateErr)
+			}
+			return
+		}
+	}
+
+	return
+}
+
+// SagasComplete func mark the saga completed inside the transaction of the
+// local change, so the local change and the saga end can not be split by a crash
+func (u *SagasUsecases) SagasComplete(ctx context.Context, Tx transaction.Tx, Sagas *entities.Sagas, OrderID int) (err error) {
+	ctx, Span := tracing.Start(ctx, "SagasUsecases.SagasComplete")
+	defer Span.Finish(&err)
+
+	UpdatePayload := map[string]interface{}{
+		"order_id":   OrderID,
+		"status":     entities.SagasCompleted,
+		"updated_at": time.Now(),
+	}
+
+	err = u.SagasRepository.SagasUpdate(ctx, Tx, Sagas.ID, UpdatePayload)
+	if err != nil {
+		return
+	}
+
+	Sagas.OrderID = OrderID
+	Sagas.Status = entities.SagasCompleted
+	return
+}
+
+// SagasCompensate func undo every step that reached the remote service, the
+// newest one first. A saga that can not be compensated is marked failed
+func (u *SagasUsecases) SagasCompensate(ctx context.Context, Sagas *entities.Sagas, Reason string) (err error) {
+	ctx, Span := tracing.Start(ctx, "SagasUsecases.SagasCompensate")
+	defer Span.Finish(&err)
+
+	err = u.sagasUpdateStatus(ctx, Sagas, entities.SagasCompensating, Reason)
+	if err != nil {
+		return
+	}
+
+	for i := len(Sagas.Steps) - 1; i >= 0; i-- {
+		Step := Sagas.Steps[i]
+
+		if Step.Status == entities.StepsPending || Step.Status == entities.StepsCompensated {
+			continue
+		}
+
+		Handler, ok := u.Handlers[Step.Action]
+		if !ok {
+			err = fmt.Errorf("saga step action %s is not registered", Step.Action)
+			return u.sagasFail(ctx, Sagas, err)
+		}
+
+		// Step interrupted in the middle of the remote call
+		if Step.Status == entities.StepsExecuting || Step.Status == entities.StepsCompensating {
+			Applied, err := Handler.Resolve(ctx, Step)
+			if err != nil {
+				return u.sagasFail(ctx, Sagas, err)
+			}
+
+			if Step.Status == entities.StepsExecuting && !Applied {
+				if err = u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsPending); err != nil {
+					return u.sagasFail(ctx, Sagas, err)
+				}
+				continue
+			}
+
+			if Step.Status == entities.StepsCompensating && Applied {
+				if err = u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsCompensated); err != nil {
+					return u.sagasFail(ctx, Sagas, err)
+				}
+				continue
+			}
+		}
+
+		err = Handler.Compensate(ctx, Step, func() error {
+			return u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsCompensating)
+		})
+		if err != nil {
+			return u.sagasFail(ctx, Sagas, err)
+		}
+
+		err = u.sagasStepsCheckpoint(ctx, Sagas, Step, entities.StepsCompensated)
+		if err != nil {
+			return u.sagasFail(ctx, Sagas, err)
+		}
+	}
+
+	return u.sagasUpdateStatus(ctx, Sagas, entities.SagasCompensated, Reason)
+}
+
+// SagasRecover func finish the sagas left running or compensating by a crashed
+// process. A running saga never reached SagasComplete, so its local change was
+// never committed. When every remote step is done and a roll forward is
+// registered for its name the local change is finished, otherwise every remote
+// step is compensated (the request failed for its client)
+func (u *SagasUsecases) SagasRecover(ctx context.Context, StaleAfter time.Duration, Limit int) (Recovered int, err error) {
+	ctx, Span := tracing.Start(ctx, "SagasUsecases.SagasRecover")
+	defer Span.Finish(&err)
+
+	Status := []entities.SagasStatus{entities.SagasRunning, entities.SagasCompensating}
+	Sagas, err := u.SagasRepository.SagasFindStale(ctx, Status, time.Now().Add(-StaleAfter), Limit)
+	if err != nil {
+		return
+	}
+
+	for _, Saga := range Sagas {
+		Saga.Steps, err = u.SagasRepository.SagasStepsFindBySagaID(ctx, Saga.ID)
+		if err != nil {
+			return
+		}
+
+		if RollForward, ok := u.RollForwards[Saga.Name]; ok && Saga.Status == entities.SagasRunning && sagasStepsDone(Saga) {
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event":   "roll forward saga",
+				"saga_id": Saga.ID,
+				"name":    Saga.Name,
+			}).Info("every step is done, the local change is finished")
+
+			if RollForwardErr := RollForward(ctx, Saga); RollForwardErr != nil {
+				logging.FromContext(ctx).WithFields(log.Fields{
+					"event":   "error when roll forward saga",
+					"saga_id": Saga.ID,
+				}).Error(RollForwardErr)
+				continue
+			}
+			Recovered++
+			continue
+		}
+
+		Reason := Saga.Error
+		if Saga.Status == entities.SagasRunning {
+			Reason = "recovered after the process stopped in the middle of the saga"
+		}
+
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event":   "recover saga",
+			"saga_id": Saga.ID,
+			"name":    Saga.Name,
+			"status":  Saga.Status,
+		}).Info(Reason)
+
+		if CompensateErr := u.SagasCompensate(ctx, Saga, Reason); CompensateErr != nil {
+			logging.FromContext(ctx).WithFields(log.Fields{
+				"event":   "error when recover saga",
+				"saga_id": Saga.ID,
+			}).Error(CompensateErr)
+			continue
+		}
+		Recovered++
+	}
+
+	return
+}
+
+// sagasStepsDone func
+func sagasStepsDone(Sagas *entities.Sagas) bool {
+	for _, Step := range Sagas.Steps {
+		if Step.Status != entities.StepsDone {
+			return false
+		}
+	}
+	return true
+}
+
+// sagasStepsCheckpoint func persist the step status and payload, and touch the
+// saga so the recovery does not take a saga that is still moving
+func (u *SagasUsecases) sagasStepsCheckpoint(ctx context.Context, Sagas *entities.Sagas, Step *entities.SagasSteps, Status entities.SagasStepsStatus) (err error) {
+	Tx, err := u.SagasRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	UpdatePayload := map[string]interface{}{
+		"payload":    Step.Payload,
+		"status":     Status,
+		"updated_at": time.Now(),
+	}
+
+	err = u.SagasRepository.SagasStepsUpdate(ctx, Tx, Step.ID, UpdatePayload)
+	if err != nil {
+		return
+	}
+
+	err = u.SagasRepository.SagasUpdate(ctx, Tx, Sagas.ID, map[string]interface{}{
+		"updated_at": time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit saga step checkpoint",
+		}).Error(err)
+		return
+	}
+
+	Step.Status = Status
+	return
+}
+
+// sagasUpdateStatus func
+func (u *SagasUsecases) sagasUpdateStatus(ctx context.Context, Sagas *entities.Sagas, Status entities.SagasStatus, Reason string) (err error) {
+	Tx, err := u.SagasRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	UpdatePayload := map[string]interface{}{
+		"status":     Status,
+		"error":      Reason,
+		"updated_at": time.Now(),
+	}
+
+	err = u.SagasRepository.SagasUpdate(ctx, Tx, Sagas.ID, UpdatePayload)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit saga status",
+		}).Error(err)
+		return
+	}
+
+	Sagas.Status = Status
+	Sagas.Error = Reason
+	return
+}
+
+// sagasFail func mark the saga failed, it need to be checked manually
+func (u *SagasUsecases) sagasFail(ctx context.Context, Sagas *entities.Sagas, Cause error) error {
+	logging.FromContext(ctx).WithFields(log.Fields{
+		"event":   "saga failed and need manual check",
+		"saga_id": Sagas.ID,
+	}).Error(Cause)
+
+	if err := u.sagasUpdateStatus(ctx, Sagas, entities.SagasFailed, Cause.Error()); err != nil {
+		return err
+	}
+	return Cause
+}
+
+// StockReserveStep func build a step that move the reservation to Qty, PreviousQty 0 mean a new reservation
+func StockReserveStep(ReservationID string, ProductID int, Qty int, PreviousQty int) *entities.SagasSteps {
+	return sagasStockStep(entities.ActionStockReserve, ReservationID, ProductID, Qty, PreviousQty)
+}
+
+// StockCommitStep func build a step that keep the reserved stock for good
+func StockCommitStep(ReservationID string, ProductID int, Qty int) *entities.SagasSteps {
+	return sagasStockStep(entities.ActionStockCommit, ReservationID, ProductID, Qty, Qty)
+}
+
+// StockReleaseStep func build a step that give the stock held by the reservation back
+func StockReleaseStep(ReservationID string, ProductID int, PreviousQty int) *entities.SagasSteps {
+	return sagasStockStep(entities.ActionStockRelease, ReservationID, ProductID, 0, PreviousQty)
+}
+
+// sagasStockStep func
+func sagasStockStep(Action entities.SagasAction, ReservationID string, ProductID int, Qty int, PreviousQty int) *entities.SagasSteps {
+	Payload, _ := json.Marshal(&entities.SagasStockPayload{
+		ReservationID: ReservationID,
+		ProductID:     ProductID,
+		Qty:           Qty,
+		PreviousQty:   PreviousQty,
+	})
+
+	return &entities.SagasSteps{
+		Action:  Action,
+		Payload: string(Payload),
+	}
+}
+
+// StockStepHandlers func, every stock operation of products services is
+// idempotent so an interrupted step is simply compensated or compensated again
+func StockStepHandlers(ProductsRepository repositories.IProductsrepository) map[entities.SagasAction]*SagasStepHandler {
+	payload := func(Step *entities.SagasSteps) (Payload *entities.SagasStockPayload, err error) {
+		Payload = &entities.SagasStockPayload{}
+		err = json.Unmarshal([]byte(Step.Payload), Payload)
+		return
+	}
+
+	// adopt record the stock a legacy line took before its reservation is moved
+	adopt := func(ctx context.Context, Payload *entities.SagasStockPayload) error {
+		if !entities.IsLegacyReservation(Payload.ReservationID) || Payload.PreviousQty == 0 {
+			return nil
+		}
+
+		_, err := ProductsRepository.ReserveStock(ctx, &entities.ReserveStockPayload{
+			ReservationID: Payload.ReservationID,
+			ProductID:     Payload.ProductID,
+			Qty:           Payload.PreviousQty,
+			Adopt:         true,
+		})
+		return err
+	}
+
+	// restore move the reservation back to PreviousQty and keep it for good
+	restore := func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error {
+		Payload, err := payload(Step)
+		if err != nil {
+			return err
+		}
+		if err = Checkpoint(); err != nil {
+			return err
+		}
+
+		if err = adopt(ctx, Payload); err != nil {
+			return err
+		}
+
+		if Payload.PreviousQty == 0 {
+			_, err = ProductsRepository.ReleaseStock(ctx, Payload.ReservationID)
+			if err == repositories.ErrStockReservationNotFound {
+				return nil
+			}
+			return err
+		}
+
+		_, err = ProductsRepository.ReserveStock(ctx, &entities.ReserveStockPayload{
+			ReservationID: Payload.ReservationID,
+			ProductID:     Payload.ProductID,
+			Qty:           Payload.PreviousQty,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = ProductsRepository.CommitStock(ctx, Payload.ReservationID)
+		return err
+	}
+
+	resolve := func(ctx context.Context, Step *entities.SagasSteps) (Applied bool, err error) {
+		return Step.Status == entities.StepsExecuting, nil
+	}
+
+	return map[entities.SagasAction]*SagasStepHandler{
+		entities.ActionStockReserve: {
+			Execute: func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error {
+				Payload, err := payload(Step)
+				if err != nil {
+					return err
+				}
+				if err = Checkpoint(); err != nil {
+					return err
+				}
+				if err = adopt(ctx, Payload); err != nil {
+					return err
+				}
+
+				_, err = ProductsRepository.ReserveStock(ctx, &entities.ReserveStockPayload{
+					ReservationID: Payload.ReservationID,
+					ProductID:     Payload.ProductID,
+					Qty:           Payload.Qty,
+				})
+				return err
+			},
+			Compensate: restore,
+			Resolve:    resolve,
+		},
+		entities.ActionStockCommit: {
+			Execute: func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error {
+				Payload, err := payload(Step)
+				if err != nil {
+					return err
+				}
+				if err = Checkpoint(); err != nil {
+					return err
+				}
+
+				_, err = ProductsRepository.CommitStock(ctx, Payload.ReservationID)
+				return err
+			},
+			// the reserve step before it give the stock back
+			Compensate: func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error {
+				return nil
+			},
+			Resolve: resolve,
+		},
+		entities.ActionStockRelease: {
+			Execute: func(ctx context.Context, Step *entities.SagasSteps, Checkpoint func() error) error {
+				Payload, err := payload(Step)
+				if err != nil {
+					return err
+				}
+				if err = Checkpoint(); err != nil {
+					return err
+				}
+				if err = adopt(ctx, Payload); err != nil {
+					return err
+				}
+
+				_, err = ProductsRepository.ReleaseStock(ctx, Payload.ReservationID)
+				return err
+			},
+			Compensate: restore,
+			Resolve:    resolve,
+		},
+	}
+}
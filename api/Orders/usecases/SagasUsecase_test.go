@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Orders/entities"
+)
+
+func TestSagasRecover(t *testing.T) {
+	Created := string(entities.EventOrderCreated)
+
+	Tests := []struct {
+		Name string
+		// Saga is the saga the process crashed in after its last step, the
+		// order has 2 of product 1
+		Saga     func(Orders *entities.Orders) (entities.SagasName, int, []*entities.SagasSteps)
+		Approved bool
+		Want     entities.SagasStatus
+		Status   entities.OrdersStatus
+		Stock    map[int]int
+		Events   []string
+	}{
+		{
+			Name: "cancel is rolled forward",
+			Saga: func(Orders *entities.Orders) (entities.SagasName, int, []*entities.SagasSteps) {
+				Item := Orders.Items[0]
+				return entities.SagaOrdersCancel, Orders.ID, []*entities.SagasSteps{StockReleaseStep(Item.ReservationID, Item.ProductID, Item.Qty)}
+			},
+			Want:   entities.SagasCompleted,
+			Status: entities.Cancel,
+			Stock:  map[int]int{1: 10},
+			Events: []string{Created, string(entities.EventOrderCancelled)},
+		},
+		{
+			Name: "cancel of an order approved in the meantime is compensated",
+			Saga: func(Orders *entities.Orders) (entities.SagasName, int, []*entities.SagasSteps) {
+				Item := Orders.Items[0]
+				return entities.SagaOrdersCancel, Orders.ID, []*entities.SagasSteps{StockReleaseStep(Item.ReservationID, Item.ProductID, Item.Qty)}
+			},
+			Approved: true,
+			Want:     entities.SagasCompensated,
+			Status:   entities.Approve,
+			Stock:    map[int]int{1: 8},
+			Events:   []string{Created, string(entities.EventOrderApproved)},
+		},
+		{
+			Name: "create is compensated",
+			Saga: func(Orders *entities.Orders) (entities.SagasName, int, []*entities.SagasSteps) {
+				return entities.SagaOrdersCreate, 0, []*entities.SagasSteps{
+					StockReserveStep("crashed", 1, 3, 0),
+					StockCommitStep("crashed", 1, 3),
+				}
+			},
+			Want:   entities.SagasCompensated,
+			Status: entities.Pending,
+			Stock:  map[int]int{1: 8},
+			Events: []string{Created},
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			o := newOrdersTest()
+			Orders := o.create(t, 1, 2)
+
+			Name, OrderID, Steps := Test.Saga(Orders)
+			Sagas, err := o.Usecase.SagasUsecase.SagasStart(context.Background(), Name, OrderID, Steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = o.Usecase.SagasUsecase.SagasRun(context.Background(), Sagas); err != nil {
+				t.Fatal(err)
+			}
+
+			if Test.Approved {
+				if Response, err := o.Usecase.OrdersApprove(context.Background(), &entities.OrdersApproveRequest{UserID: 9, OrderID: Orders.ID, Actor: entities.ActorAdmin}); err != nil || Response.Code != 200 {
+					t.Fatalf("approve order: %v %+v", err, Response)
+				}
+			}
+
+			Recovered, err := o.Usecase.SagasUsecase.SagasRecover(context.Background(), -time.Minute, 10)
+			if err != nil || Recovered != 1 {
+				t.Fatalf("recovered = %d %v, want 1", Recovered, err)
+			}
+
+			if Status := o.SagasRepository.Sagas[Sagas.ID].Status; Status != Test.Want {
+				t.Errorf("saga status = %d, want %d", Status, Test.Want)
+			}
+			if Status := o.OrdersRepository.Orders[Orders.ID].Status; Status != Test.Status {
+				t.Errorf("order status = %d, want %d", Status, Test.Status)
+			}
+			o.check(t, Test.Stock, Test.Events)
+		})
+	}
+}
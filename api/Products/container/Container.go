@@ -0,0 +1,100 @@
+package container
+
+import (
+	"github.com/mrdhira/warpin-test/api/Products/infrastructures/database"
+	"github.com/mrdhira/warpin-test/api/Products/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/api/Products/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/money"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// Container struct, every dependency of products services built once by New.
+// The repositories and usecases are interfaces so a test can build them with
+// other implementations
+type Container struct {
+	PG     *database.PostgresConnection
+	Redis  *database.RedisConnection
+	Health *health.Checker
+
+	// Verifier and Revocations check the tokens of users services, the
+	// revocation list is in the redis shared by every service (auth.redis)
+	Verifier        *auth.Verifier
+	RevocationStore *auth.RedisRevocations
+	Revocations     *auth.Revocations
+
+	ProductsRepository          repositories.IProductsRepository
+	OrdersRepository            repositories.IOrdersRepository
+	StockReservationsRepository repositories.IStockReservationsRepository
+
+	ProductsUsecase usecases.IProductsUsecases
+}
+
+// New func open the database, redis and revocation list of products services and
+// build the repositories and usecases on them
+func New() (c *Container, err error) {
+	PG, err := database.NewPostgresConnection()
+	if err != nil {
+		return
+	}
+	if err = money.CheckCurrency(PG.Connection.DB); err != nil {
+		PG.Close()
+		return
+	}
+	Redis := database.NewRedisConnection()
+
+	RevocationStore := auth.NewRedisRevocations()
+
+	c = &Container{
+		PG:              PG,
+		Redis:           Redis,
+		Verifier:        auth.NewDefaultVerifier(),
+		RevocationStore: RevocationStore,
+		Revocations:     auth.NewRevocations(RevocationStore),
+	}
+
+	// Init Repositories
+	c.ProductsRepository = &repositories.ProductsRepository{PG: PG, Redis: Redis}
+	c.OrdersRepository = repositories.InitOrdersRepository()
+	c.StockReservationsRepository = &repositories.StockReservationsRepository{PG: PG}
+
+	// Init Usecases
+	c.ProductsUsecase = usecases.InitProductsUsecases(c.ProductsRepository, c.OrdersRepository, c.StockReservationsRepository)
+
+	// Init Health, the /readyz of the service
+	c.Health = health.New("products").
+		Add("postgres", health.Postgres(PG.Connection)).
+		Add("redis", health.Redis(Redis.Redis)).
+		Add("revocations", health.Redis(RevocationStore.Client)).
+		Add("orders", health.Service(serviceclient.New("orders")))
+
+	return
+}
+
+// Close func close the database, redis and revocation list, call it once the
+// server stopped
+func (c *Container) Close() (err error) {
+	if err = c.PG.Close(); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close sql connection",
+		}).Error(err)
+	}
+
+	if RedisErr := c.Redis.Close(); RedisErr != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close redis connection",
+		}).Error(RedisErr)
+		err = RedisErr
+	}
+
+	if RevocationsErr := c.RevocationStore.Close(); RevocationsErr != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close revocations redis connection",
+		}).Error(RevocationsErr)
+		err = RevocationsErr
+	}
+
+	return
+}
@@ -5,29 +5,70 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mrdhira/warpin-test/api/Products/deliveries/http/controllers"
+	"github.com/mrdhira/warpin-test/api/Products/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
 )
 
-// Route struct
-type Route struct{}
+// Route struct, the usecases are built by the caller (see container.New).
+// Health is the /readyz of the service, without it /readyz has no check.
+// Verifier and Revocations check the tokens of users services
+type Route struct {
+	Health          *health.Checker
+	Verifier        *auth.Verifier
+	Revocations     *auth.Revocations
+	ProductsUsecase usecases.IProductsUsecases
+}
 
 // Init func
 func (r *Route) Init() *mux.Router {
 	// Initialize Controllers
-	productsControllers := controllers.InitProductsControllers()
+	productsControllers := controllers.InitProductsControllers(r.ProductsUsecase)
+
+	// Initialize Middlewares
+	AuthAdmin := AuthAdmniMiddleware(r.Verifier, r.Revocations)
+	AuthAdminOrService := AuthAdminOrServiceMiddleware(r.Verifier, r.Revocations)
 
 	// Initialize Router
 	Router := mux.NewRouter().StrictSlash(true)
 
+	// Metrics, trace and log of every route
+	Router.Use(metrics.Middleware("products"), tracing.Middleware("products"), logging.Middleware("products"), i18n.Middleware())
+	Router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	// Health Routes
+	Health := r.Health
+	if Health == nil {
+		Health = health.New("products")
+	}
+	Router.HandleFunc("/healthz", Health.Liveness).Methods(http.MethodGet)
+	Router.HandleFunc("/readyz", Health.Readiness).Methods(http.MethodGet)
+
 	// Products Routes with no Auth
 	ProductsNoAuthRoutes := Router.PathPrefix("/products").Subrouter()
 	ProductsNoAuthRoutes.HandleFunc("/", productsControllers.GetProducts).Methods(http.MethodGet)
 	ProductsNoAuthRoutes.HandleFunc("/{id}", productsControllers.GetProductsByID).Methods(http.MethodGet)
 
-	// Products Routes with Auth Admin
+	// Products Routes with Auth Admin, the other services only get the stock
+	// reservations of AuthAdminOrServiceMiddleware (orders services reserve
+	// the stock of the orders)
+	Admin := func(Handler http.HandlerFunc) http.Handler {
+		return AuthAdmin(Handler)
+	}
+	AdminOrService := func(Handler http.HandlerFunc) http.Handler {
+		return AuthAdminOrService(Handler)
+	}
 	ProductsAuthAdminRoutes := Router.PathPrefix("/products/internal").Subrouter()
-	ProductsAuthAdminRoutes.Use(AuthAdmniMiddleware)
-	ProductsAuthAdminRoutes.HandleFunc("/add", productsControllers.AddProducts).Methods(http.MethodPost)
-	ProductsAuthAdminRoutes.HandleFunc("/{id}", productsControllers.UpdateProducts).Methods(http.MethodPut)
+	ProductsAuthAdminRoutes.Handle("/add", Admin(productsControllers.AddProducts)).Methods(http.MethodPost)
+	ProductsAuthAdminRoutes.Handle("/{id}", Admin(productsControllers.UpdateProducts)).Methods(http.MethodPut)
+	ProductsAuthAdminRoutes.Handle("/reservations", AdminOrService(productsControllers.ReserveStock)).Methods(http.MethodPost)
+	ProductsAuthAdminRoutes.Handle("/reservations/{reservation_id}", AdminOrService(productsControllers.GetStockReservation)).Methods(http.MethodGet)
+	ProductsAuthAdminRoutes.Handle("/reservations/{reservation_id}/release", AdminOrService(productsControllers.ReleaseStock)).Methods(http.MethodPut)
+	ProductsAuthAdminRoutes.Handle("/reservations/{reservation_id}/commit", AdminOrService(productsControllers.CommitStock)).Methods(http.MethodPut)
 
 	return Router
 }
@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -15,6 +14,9 @@ import (
 	"github.com/mrdhira/warpin-test/api/Products/entities"
 	"github.com/mrdhira/warpin-test/api/Products/usecases"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/money"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,7 +28,7 @@ type ProductsControllers struct {
 }
 
 // InitProductsControllers func
-func InitProductsControllers() *ProductsControllers {
+func InitProductsControllers(ProductsUsecase usecases.IProductsUsecases) *ProductsControllers {
 	validate = validator.New()
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
@@ -35,12 +37,11 @@ func InitProductsControllers() *ProductsControllers {
 		}
 		return name
 	})
-
-	// Init Usecase
-	productsUsecases := usecases.InitProductsUsecases()
+	// money is validated on its amount
+	validate.RegisterCustomTypeFunc(money.ValidateValuer, money.Money{})
 
 	return &ProductsControllers{
-		ProductsUsecase: productsUsecases,
+		ProductsUsecase: ProductsUsecase,
 	}
 }
 
@@ -49,10 +50,10 @@ func (c *ProductsControllers) GetProducts(res http.ResponseWriter, req *http.Req
 	var requestBody *entities.GetProductsRequest
 
 	if req.URL.Query().Get("limit") == "" && req.URL.Query().Get("offset") == "" {
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "Limit dan Offset tidak bisa kosong",
-		})
+		apperr.Write(res, req, apperr.Validation("pagination_required").WithDetails(
+			apperr.Field("limit", "required", reflect.Int, ""),
+			apperr.Field("offset", "required", reflect.Int, ""),
+		))
 		return
 	}
 
@@ -64,14 +65,10 @@ func (c *ProductsControllers) GetProducts(res http.ResponseWriter, req *http.Req
 	if req.URL.Query().Get("status") != "" {
 		Status, err := strconv.Atoi(req.URL.Query().Get("status"))
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(req.Context()).WithFields(log.Fields{
 				"event": "error when parse to int for status query params",
 			}).Error(err)
-			pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-				Code:    400,
-				Message: "Terjadi kesalahan sistem",
-				Error:   err.Error(),
-			})
+			apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 			return
 		}
 		requestBody.Status = Status
@@ -79,35 +76,13 @@ func (c *ProductsControllers) GetProducts(res http.ResponseWriter, req *http.Req
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.ProductsUsecase.GetProducts(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -121,14 +96,10 @@ func (c *ProductsControllers) GetProductsByID(res http.ResponseWriter, req *http
 
 	ProductID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get product id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -138,35 +109,13 @@ func (c *ProductsControllers) GetProductsByID(res http.ResponseWriter, req *http
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.ProductsUsecase.GetProductsByID(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -177,39 +126,22 @@ func (c *ProductsControllers) GetProductsByID(res http.ResponseWriter, req *http
 // AddProducts func
 func (c *ProductsControllers) AddProducts(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /products/internal/add payload body")
-
 	var requestBody *entities.AddProductsRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload add products",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -217,35 +149,13 @@ func (c *ProductsControllers) AddProducts(res http.ResponseWriter, req *http.Req
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.ProductsUsecase.AddProducts(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -256,39 +166,22 @@ func (c *ProductsControllers) AddProducts(res http.ResponseWriter, req *http.Req
 // UpdateProducts func
 func (c *ProductsControllers) UpdateProducts(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("PUT /products/internal/{id} payload body")
-
 	var requestBody *entities.UpdateProductsRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload update products",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -296,14 +189,10 @@ func (c *ProductsControllers) UpdateProducts(res http.ResponseWriter, req *http.
 
 	ProductID, err := strconv.Atoi(mux.Vars(req)["id"])
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when get product id from params",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.BadRequest("param_invalid").Wrap(err))
 		return
 	}
 
@@ -311,38 +200,133 @@ func (c *ProductsControllers) UpdateProducts(res http.ResponseWriter, req *http.
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.ProductsUsecase.UpdateProducts(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// ReserveStock func
+func (c *ProductsControllers) ReserveStock(res http.ResponseWriter, req *http.Request) {
+	RawPayload, _ := ioutil.ReadAll(req.Body)
+	var requestBody *entities.ReserveStockRequest
+	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal request payload reserve stock",
+		}).Error(err)
+		apperr.Write(res, req, apperr.Malformed(err))
+		return
+	}
+
+	TokenJSON := context.Get(req, "token").(string)
+	var TokenData *entities.TokenClaim
+	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal token",
+		}).Error(err)
+		apperr.Write(res, req, err)
+		return
+	}
+
+	requestBody.UserID = TokenData.UserID
+
+	// Payload Validation
+	if err := validate.Struct(requestBody); err != nil {
+		apperr.Write(res, req, apperr.Payload(err))
+		return
+	}
+
+	Response, err := c.ProductsUsecase.ReserveStock(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// ReleaseStock func
+func (c *ProductsControllers) ReleaseStock(res http.ResponseWriter, req *http.Request) {
+	requestBody, ok := stockReservationRequest(res, req)
+	if !ok {
+		return
+	}
+
+	Response, err := c.ProductsUsecase.ReleaseStock(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
 		return
 	}
 
 	pkg.Response(res, Response.Code, Response)
 	return
 }
+
+// CommitStock func
+func (c *ProductsControllers) CommitStock(res http.ResponseWriter, req *http.Request) {
+	requestBody, ok := stockReservationRequest(res, req)
+	if !ok {
+		return
+	}
+
+	Response, err := c.ProductsUsecase.CommitStock(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// GetStockReservation func
+func (c *ProductsControllers) GetStockReservation(res http.ResponseWriter, req *http.Request) {
+	requestBody, ok := stockReservationRequest(res, req)
+	if !ok {
+		return
+	}
+
+	Response, err := c.ProductsUsecase.GetStockReservation(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// stockReservationRequest func build the request from the token and the reservation id params
+func stockReservationRequest(res http.ResponseWriter, req *http.Request) (requestBody *entities.StockReservationRequest, ok bool) {
+	TokenJSON := context.Get(req, "token").(string)
+	var TokenData *entities.TokenClaim
+	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal token",
+		}).Error(err)
+		apperr.Write(res, req, err)
+		return
+	}
+
+	requestBody = &entities.StockReservationRequest{
+		UserID:        TokenData.UserID,
+		ReservationID: mux.Vars(req)["reservation_id"],
+	}
+
+	// Payload Validation
+	if err := validate.Struct(requestBody); err != nil {
+		apperr.Write(res, req, apperr.Payload(err))
+		return
+	}
+
+	return requestBody, true
+}
@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/money"
+)
 
 // OrdersStatus int
 type OrdersStatus int
@@ -13,16 +17,33 @@ const (
 	Cancel
 )
 
+// OrdersItemsStatus Master, a pending or approved line hold its stock
+const (
+	ItemsPending = iota + 1
+	ItemsApprove
+)
+
 // Orders struct
 type Orders struct {
-	ID          int          `db:"id" json:"id"`
-	UserID      int          `db:"user_id" json:"user_id"`
-	ProductID   int          `db:"product_id" json:"product_id"`
-	ProductName string       `db:"product_name" json:"product_name"`
-	Price       float32      `db:"price" json:"price"`
-	Qty         int          `db:"qty" json:"qty"`
-	TotalPrice  float32      `db:"total_price" json:"total_price"`
-	Status      OrdersStatus `db:"status" json:"status"`
-	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	ID         int            `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"user_id"`
+	TotalPrice money.Money    `db:"total_price" json:"total_price"`
+	Status     OrdersStatus   `db:"status" json:"status"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+	Items      []*OrdersItems `db:"-" json:"items"`
+}
+
+// OrdersItems struct
+type OrdersItems struct {
+	ID          int         `db:"id" json:"id"`
+	OrderID     int         `db:"order_id" json:"order_id"`
+	ProductID   int         `db:"product_id" json:"product_id"`
+	ProductName string      `db:"product_name" json:"product_name"`
+	Price       money.Money `db:"price" json:"price"`
+	Qty         int         `db:"qty" json:"qty"`
+	TotalPrice  money.Money `db:"total_price" json:"total_price"`
+	Status      int         `db:"status" json:"status"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at" json:"updated_at"`
 }
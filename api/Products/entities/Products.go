@@ -2,6 +2,8 @@ package entities
 
 import (
 	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/money"
 )
 
 // ProductsStatus int
@@ -18,15 +20,35 @@ type ProductsEvent string
 
 // ProductsEvent Master
 const (
-	EventInsert ProductsEvent = "INSERT"
-	EventUpdate ProductsEvent = "UPDATE"
+	EventInsert  ProductsEvent = "INSERT"
+	EventUpdate  ProductsEvent = "UPDATE"
+	EventReserve ProductsEvent = "RESERVE"
+	EventRelease ProductsEvent = "RELEASE"
+	EventExpire  ProductsEvent = "EXPIRE"
+)
+
+// ProductsDomainEvent string, domain event published through the outbox
+type ProductsDomainEvent string
+
+// ProductsDomainEvent Master
+const (
+	EventProductCreated     ProductsDomainEvent = "ProductCreated"
+	EventProductUpdated     ProductsDomainEvent = "ProductUpdated"
+	EventProductDeactivated ProductsDomainEvent = "ProductDeactivated"
+	EventStockReserved      ProductsDomainEvent = "StockReserved"
+	EventStockReleased      ProductsDomainEvent = "StockReleased"
+	EventStockCommitted     ProductsDomainEvent = "StockCommitted"
+	EventStockExpired       ProductsDomainEvent = "StockExpired"
 )
 
+// ProductsAggregate for outbox
+const ProductsAggregate = "products"
+
 // Products struct
 type Products struct {
 	ID        int            `db:"id" json:"id"`
 	Name      string         `db:"name" json:"name"`
-	Price     float32        `db:"price" json:"price"`
+	Price     money.Money    `db:"price" json:"price"`
 	Qty       int            `db:"qty" json:"qty"`
 	Status    ProductsStatus `db:"status" json:"status"`
 	CreatedAt time.Time      `db:"created_at" json:"created_at"`
@@ -39,7 +61,7 @@ type ProductsLog struct {
 	ProductID int            `db:"product_id" json:"product_id"`
 	UserID    int            `db:"user_id" json:"user_id"`
 	Name      string         `db:"name" json:"name"`
-	Price     float32        `db:"price" json:"price"`
+	Price     money.Money    `db:"price" json:"price"`
 	Qty       int            `db:"qty" json:"qty"`
 	Status    ProductsStatus `db:"status" json:"status"`
 	Event     ProductsEvent  `db:"event" json:"event"`
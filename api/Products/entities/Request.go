@@ -1,5 +1,7 @@
 package entities
 
+import "github.com/mrdhira/warpin-test/pkg/money"
+
 // GetProductsRequest struct
 type GetProductsRequest struct {
 	Limit  string `json:"limit" validate:"required"`
@@ -14,20 +16,42 @@ type GetProductsByIDRequest struct {
 
 // AddProductsRequest struct
 type AddProductsRequest struct {
-	UserID int     `json:"user_id" validate:"required"`
-	Name   string  `json:"name" validate:"required"`
-	Price  float32 `json:"price" validate:"required"`
-	Qty    int     `json:"qty" validate:"required"`
+	UserID int         `json:"user_id" validate:"required"`
+	Name   string      `json:"name" validate:"required"`
+	Price  money.Money `json:"price" validate:"required,gt=0"`
+	Qty    int         `json:"qty" validate:"required"`
 }
 
-// UpdateProductsRequest struct
+// UpdateProductsRequest struct, Qty is the new stock. It is stored as the change
+// from the stock of the locked product, the reservations in flight are kept
 type UpdateProductsRequest struct {
-	UserID    int      `json:"user_id" validate:"-"`
-	ProductID int      `json:"product_id" validate:"required"`
-	Name      string   `json:"name" validate:"-"`
-	Price     *float32 `json:"price,omitempty" validate:"-"`
-	Qty       *int     `json:"qty,omitempty" validate:"-"`
-	Status    *int     `json:"status,omitempty" validate:"-"`
+	UserID    int          `json:"user_id" validate:"-"`
+	ProductID int          `json:"product_id" validate:"required"`
+	Name      string       `json:"name" validate:"-"`
+	Price     *money.Money `json:"price,omitempty" validate:"omitempty,gt=0"`
+	Qty       *int         `json:"qty,omitempty" validate:"omitempty,min=0"`
+	Status    *int         `json:"status,omitempty" validate:"-"`
+}
+
+// ReserveStockRequest struct, reserving an existing reservation move it to the
+// new qty and qty 0 release it. Adopt record Qty as a committed reservation
+// without taking the stock, for the stock a legacy line took before the
+// reservations existed (see LegacyReservationPrefix). The qty is capped to the
+// qty the line hold in orders services. Adopting an existing reservation does
+// nothing
+type ReserveStockRequest struct {
+	UserID        int    `json:"user_id" validate:"-"`
+	ReservationID string `json:"reservation_id" validate:"max=64"`
+	ProductID     int    `json:"product_id" validate:"required"`
+	Qty           int    `json:"qty" validate:"min=0"`
+	TTL           int    `json:"ttl" validate:"min=0"`
+	Adopt         bool   `json:"adopt" validate:"-"`
+}
+
+// StockReservationRequest struct
+type StockReservationRequest struct {
+	UserID        int    `json:"user_id" validate:"-"`
+	ReservationID string `json:"reservation_id" validate:"required"`
 }
 
 // GetOrdersByPrductIDPayload struct
@@ -36,12 +60,5 @@ type GetOrdersByPrductIDPayload struct {
 	Offset    int `json:"offset"`
 	Status    int `json:"status"`
 	ProductID int `json:"product_id"`
-}
-
-// GetOrdersByPrductIDResponse struct
-type GetOrdersByPrductIDResponse struct {
-	Code    int       `json:"code"`
-	Message string    `json:"message"`
-	Error   string    `json:"error"`
-	Data    []*Orders `json:"data"`
+	OrderID   int `json:"order_id"`
 }
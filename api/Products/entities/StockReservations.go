@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// StockReservationsStatus int
+type StockReservationsStatus int
+
+// StockReservationsStatus Master
+const (
+	Reserved StockReservationsStatus = iota + 1
+	Committed
+	Released
+	Expired
+)
+
+// LegacyReservationPrefix of the reservation of the lines orders services moved
+// into orders_items in its 00003 migration, legacy-<order id>. Their stock was
+// taken before the reservations existed, only them can be adopted
+const LegacyReservationPrefix = "legacy-"
+
+// IsLegacyReservation func
+func IsLegacyReservation(ReservationID string) bool {
+	return strings.HasPrefix(ReservationID, LegacyReservationPrefix)
+}
+
+// StockReservations struct, Qty is the stock currently held by the reservation
+type StockReservations struct {
+	ID        string                  `db:"id" json:"id"`
+	ProductID int                     `db:"product_id" json:"product_id"`
+	Qty       int                     `db:"qty" json:"qty"`
+	Status    StockReservationsStatus `db:"status" json:"status"`
+	ExpiredAt time.Time               `db:"expired_at" json:"expired_at"`
+	CreatedAt time.Time               `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time               `db:"updated_at" json:"updated_at"`
+}
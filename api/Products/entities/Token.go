@@ -11,9 +11,11 @@ const (
 	Customer UserRole = "CUSTOMER"
 )
 
-// TokenClaim struct
+// TokenClaim struct, Service is only set for the requests of other services,
+// they have no users
 type TokenClaim struct {
 	UserID   int      `json:"user_id"`
 	UserRole UserRole `json:"user_role"`
+	Service  string   `json:"service,omitempty"`
 	jwt.StandardClaims
 }
@@ -13,11 +13,15 @@ type IRedisConnection interface {
 	Client() *redis.Client
 }
 
-// RedisConnection struct
-type RedisConnection struct{}
+// RedisConnection struct, a single pooled client of products services redis.
+// Build it once with NewRedisConnection and Close it on shutdown
+type RedisConnection struct {
+	Redis *redis.Client
+}
 
-// Client Func
-func (r *RedisConnection) Client() *redis.Client {
+// NewRedisConnection func create the client of productsServices.redis, a failed
+// ping is only logged, the client connect again on the next command
+func NewRedisConnection() *RedisConnection {
 	Address := viper.GetString("productsServices.redis.address")
 	Password := viper.GetString("productsServices.redis.password")
 	DB, _ := strconv.Atoi(viper.GetString("productsServices.redis.db"))
@@ -35,5 +39,15 @@ func (r *RedisConnection) Client() *redis.Client {
 		}).Error(err)
 	}
 
-	return Client
+	return &RedisConnection{Redis: Client}
+}
+
+// Client Func
+func (r *RedisConnection) Client() *redis.Client {
+	return r.Redis
+}
+
+// Close func
+func (r *RedisConnection) Close() error {
+	return r.Redis.Close()
 }
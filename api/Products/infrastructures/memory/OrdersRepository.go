@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+)
+
+// OrdersRepository struct, the orders services seen by products services kept
+// in memory for the tests. Err is returned by every call when it is set, e.g.
+// serviceclient.ErrCircuitOpen
+type OrdersRepository struct {
+	mu     sync.Mutex
+	Orders []*entities.Orders
+	Err    error
+}
+
+// NewOrdersRepository func
+func NewOrdersRepository(Orders ...*entities.Orders) *OrdersRepository {
+	return &OrdersRepository{Orders: Orders}
+}
+
+// GetOrdersByProductID func
+func (r *OrdersRepository) GetOrdersByProductID(ctx context.Context, Payload *entities.GetOrdersByPrductIDPayload) (Orders []*entities.Orders, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	Found := []*entities.Orders{}
+	for _, Stored := range r.Orders {
+		if Payload.Status != 0 && int(Stored.Status) != Payload.Status {
+			continue
+		}
+		if Payload.OrderID != 0 && Stored.ID != Payload.OrderID {
+			continue
+		}
+		if Payload.ProductID != 0 && !ordersHasProduct(Stored, Payload.ProductID) {
+			continue
+		}
+		Found = append(Found, Stored)
+	}
+
+	From, To := memstore.Page(len(Found), Payload.Limit, Payload.Offset)
+	return Found[From:To], nil
+}
+
+// ordersHasProduct func
+func ordersHasProduct(Orders *entities.Orders, ProductID int) bool {
+	for _, Items := range Orders.Items {
+		if Items.ProductID == ProductID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// ProductsRepository struct, repositories.IProductsRepository kept in memory
+// for the tests. Errors hold the error a method return by its name, to test the
+// paths where the database fail
+type ProductsRepository struct {
+	mu          sync.Mutex
+	Products    map[int]*entities.Products
+	ProductsLog []*entities.ProductsLog
+	Outbox      []*outbox.Message
+	Errors      map[string]error
+
+	// CacheDeleted hold the ids given to ProductsCacheDelete
+	CacheDeleted []int
+
+	// the ids are sequences, a rollback does not give them back
+	lastProductsID    int
+	lastProductsLogID int
+	lastOutboxID      int
+}
+
+// NewProductsRepository func
+func NewProductsRepository() *ProductsRepository {
+	return &ProductsRepository{
+		Products: map[int]*entities.Products{},
+		Errors:   map[string]error{},
+	}
+}
+
+// Tx func
+func (r *ProductsRepository) Tx() (Tx transaction.Tx, err error) {
+	if err = r.fail("Tx"); err != nil {
+		return
+	}
+	return transaction.NewMemory(), nil
+}
+
+// ProductsFind func, ordered by id
+func (r *ProductsRepository) ProductsFind(ctx context.Context, Limit int, Offset int, Condition map[string]interface{}) (Products []*entities.Products, err error) {
+	if err = r.fail("ProductsFind"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Found := []*entities.Products{}
+	for ID := 1; ID <= r.lastProductsID; ID++ {
+		if Stored, ok := r.Products[ID]; ok && memstore.Match(Stored, Condition) {
+			Copy := *Stored
+			Found = append(Found, &Copy)
+		}
+	}
+
+	From, To := memstore.Page(len(Found), Limit, Offset)
+	return Found[From:To], nil
+}
+
+// ProductsFindOneByID func
+func (r *ProductsRepository) ProductsFindOneByID(ctx context.Context, ID int) (Products *entities.Products, err error) {
+	if err = r.fail("ProductsFindOneByID"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if Stored, ok := r.Products[ID]; ok {
+		Copy := *Stored
+		return &Copy, nil
+	}
+	return
+}
+
+// ProductsLockByID func, the tests run one request at a time so there is
+// nothing to lock
+func (r *ProductsRepository) ProductsLockByID(ctx context.Context, Tx transaction.Tx, ID int) (Products *entities.Products, err error) {
+	if err = r.fail("ProductsLockByID"); err != nil {
+		return
+	}
+	return r.ProductsFindOneByID(ctx, ID)
+}
+
+// ProductsStore func
+func (r *ProductsRepository) ProductsStore(ctx context.Context, Tx transaction.Tx, Products *entities.Products) (ID int, err error) {
+	if err = r.fail("ProductsStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastProductsID++
+	ID = r.lastProductsID
+	Copy := *Products
+	Copy.ID = ID
+	r.Products[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.Products, ID)
+	})
+	return
+}
+
+// ProductsLogStore func
+func (r *ProductsRepository) ProductsLogStore(ctx context.Context, Tx transaction.Tx, ProductsLog *entities.ProductsLog) (ID int, err error) {
+	if err = r.fail("ProductsLogStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastProductsLogID++
+	ID = r.lastProductsLogID
+	Copy := *ProductsLog
+	Copy.ID = ID
+	r.ProductsLog = append(r.ProductsLog, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, Stored := range r.ProductsLog {
+			if Stored.ID == ID {
+				r.ProductsLog = append(r.ProductsLog[:i], r.ProductsLog[i+1:]...)
+				break
+			}
+		}
+	})
+	return
+}
+
+// ProductsUpdate func
+func (r *ProductsRepository) ProductsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("ProductsUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Products[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	r.onRollbackRestore(Tx, Stored, Previous)
+	return
+}
+
+// ProductsQtyAdd func, a negative Qty only pass when the product is active and
+// has enough stock like the conditional update of postgres
+func (r *ProductsRepository) ProductsQtyAdd(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error) {
+	if err = r.fail("ProductsQtyAdd"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Products[ID]
+	if !ok || (Qty < 0 && (Stored.Status != entities.Active || Stored.Qty < -Qty)) {
+		return
+	}
+
+	Previous := *Stored
+	Stored.Qty += Qty
+	Stored.UpdatedAt = time.Now()
+	r.onRollbackRestore(Tx, Stored, Previous)
+
+	Copy := *Stored
+	return &Copy, nil
+}
+
+// ProductsQtyAdjust func, Products is nil when the stock would go below zero
+func (r *ProductsRepository) ProductsQtyAdjust(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error) {
+	if err = r.fail("ProductsQtyAdjust"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Products[ID]
+	if !ok || Stored.Qty+Qty < 0 {
+		return
+	}
+
+	Previous := *Stored
+	Stored.Qty += Qty
+	Stored.UpdatedAt = time.Now()
+	r.onRollbackRestore(Tx, Stored, Previous)
+
+	Copy := *Stored
+	return &Copy, nil
+}
+
+// ProductsCacheDelete func, there is no cache in memory, the id is kept in
+// CacheDeleted
+func (r *ProductsRepository) ProductsCacheDelete(ctx context.Context, ID int) (err error) {
+	if err = r.fail("ProductsCacheDelete"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.CacheDeleted = append(r.CacheDeleted, ID)
+	return nil
+}
+
+// OutboxStore func
+func (r *ProductsRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	if err = r.fail("OutboxStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOutboxID++
+	ID = r.lastOutboxID
+	Copy := *Message
+	Copy.ID = ID
+	r.Outbox = append(r.Outbox, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Outbox = outboxRemove(r.Outbox, ID)
+	})
+	return
+}
+
+// onRollbackRestore func
+func (r *ProductsRepository) onRollbackRestore(Tx transaction.Tx, Stored *entities.Products, Previous entities.Products) {
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+}
+
+// fail func
+func (r *ProductsRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
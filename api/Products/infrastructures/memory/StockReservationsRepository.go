@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// StockReservationsRepository struct, repositories.IStockReservationsRepository
+// kept in memory for the tests
+type StockReservationsRepository struct {
+	mu                sync.Mutex
+	StockReservations map[string]*entities.StockReservations
+	Errors            map[string]error
+}
+
+// NewStockReservationsRepository func
+func NewStockReservationsRepository() *StockReservationsRepository {
+	return &StockReservationsRepository{
+		StockReservations: map[string]*entities.StockReservations{},
+		Errors:            map[string]error{},
+	}
+}
+
+// StockReservationsFindByID func
+func (r *StockReservationsRepository) StockReservationsFindByID(ctx context.Context, ID string) (StockReservations *entities.StockReservations, err error) {
+	if err = r.fail("StockReservationsFindByID"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if Stored, ok := r.StockReservations[ID]; ok {
+		Copy := *Stored
+		return &Copy, nil
+	}
+	return
+}
+
+// StockReservationsLockByID func, the tests run one request at a time so there
+// is nothing to lock
+func (r *StockReservationsRepository) StockReservationsLockByID(ctx context.Context, Tx transaction.Tx, ID string) (StockReservations *entities.StockReservations, err error) {
+	if err = r.fail("StockReservationsLockByID"); err != nil {
+		return
+	}
+	return r.StockReservationsFindByID(ctx, ID)
+}
+
+// StockReservationsFindExpired func
+func (r *StockReservationsRepository) StockReservationsFindExpired(ctx context.Context, Now time.Time, Limit int) (StockReservations []*entities.StockReservations, err error) {
+	if err = r.fail("StockReservationsFindExpired"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, Stored := range r.StockReservations {
+		if Stored.Status == entities.Reserved && Stored.ExpiredAt.Before(Now) {
+			Copy := *Stored
+			StockReservations = append(StockReservations, &Copy)
+		}
+	}
+	sort.Slice(StockReservations, func(i, j int) bool {
+		return StockReservations[i].ExpiredAt.Before(StockReservations[j].ExpiredAt)
+	})
+
+	From, To := memstore.Page(len(StockReservations), Limit, 0)
+	return StockReservations[From:To], nil
+}
+
+// StockReservationsStore func
+func (r *StockReservationsRepository) StockReservationsStore(ctx context.Context, Tx transaction.Tx, StockReservations *entities.StockReservations) (err error) {
+	if err = r.fail("StockReservationsStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Copy := *StockReservations
+	r.StockReservations[Copy.ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.StockReservations, Copy.ID)
+	})
+	return
+}
+
+// StockReservationsUpdate func
+func (r *StockReservationsRepository) StockReservationsUpdate(ctx context.Context, Tx transaction.Tx, ID string, Payload map[string]interface{}) (err error) {
+	if err = r.fail("StockReservationsUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.StockReservations[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return
+}
+
+// fail func
+func (r *StockReservationsRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
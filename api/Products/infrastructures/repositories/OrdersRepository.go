@@ -2,17 +2,13 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net"
-	"net/http"
+	"net/url"
 	"strconv"
-	"time"
 
 	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // IOrdersRepository interface
@@ -22,36 +18,28 @@ type IOrdersRepository interface {
 
 // OrdersRepository struct
 type OrdersRepository struct {
+	Client *serviceclient.Client
 }
 
-// GetOrdersByProductID func
-func (r *OrdersRepository) GetOrdersByProductID(ctx context.Context, Payload *entities.GetOrdersByPrductIDPayload) (Orders []*entities.Orders, err error) {
-	var netTransport = &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: time.Second * 60,
-		}).Dial,
-		TLSHandshakeTimeout: time.Second * 60,
-	}
-
-	var Client = &http.Client{
-		Timeout:   time.Second * 60,
-		Transport: netTransport,
-	}
-
-	BaseURL := viper.GetString("services.orders.url")
-	PathURL := "/orders/internal/"
-
-	RequestHTTP, err := http.NewRequest("GET", BaseURL+PathURL, nil)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err creating new request get order by product id to order service",
-		}).Error(err)
-		return
+// InitOrdersRepository func, the client of orders services signed as products services.
+// The GET calls are retried, every call go through the breaker of orders services
+// and is traced and recorded in the metrics
+func InitOrdersRepository() *OrdersRepository {
+	return &OrdersRepository{
+		Client: serviceclient.New("orders",
+			serviceclient.Metrics("products", "orders"),
+			serviceclient.Tracing("orders"),
+			serviceclient.Retry(serviceclient.RetryPolicyFor("orders")),
+			serviceclient.CircuitBreaker(serviceclient.BreakerFor("orders")),
+			serviceclient.ServiceAuth("products", "orders"),
+			serviceclient.Logging("orders"),
+		),
 	}
+}
 
-	RequestHTTP.Header.Set("Authorization", "INTERNAL-SERVICES")
-
-	QueryParams := RequestHTTP.URL.Query()
+// GetOrdersByProductID func
+func (r *OrdersRepository) GetOrdersByProductID(ctx context.Context, Payload *entities.GetOrdersByPrductIDPayload) (Orders []*entities.Orders, err error) {
+	QueryParams := url.Values{}
 	QueryParams.Add("limit", strconv.Itoa(Payload.Limit))
 	QueryParams.Add("offset", strconv.Itoa(Payload.Offset))
 
@@ -63,28 +51,16 @@ func (r *OrdersRepository) GetOrdersByProductID(ctx context.Context, Payload *en
 		QueryParams.Add("product_id", strconv.Itoa(Payload.ProductID))
 	}
 
-	RequestHTTP.URL.RawQuery = QueryParams.Encode()
+	if Payload.OrderID != 0 {
+		QueryParams.Add("order_id", strconv.Itoa(Payload.OrderID))
+	}
 
-	ResponseHTTP, err := Client.Do(RequestHTTP)
+	err = r.Client.Get(ctx, "/orders/internal/", QueryParams, &Orders)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "err performing request get order by product id to order service",
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "err request get order by product id to order service",
 		}).Error(err)
-		return
-	}
-	defer ResponseHTTP.Body.Close()
-
-	ResponseBody, _ := ioutil.ReadAll(ResponseHTTP.Body)
-	var GetOrdersByPrductIDResponse *entities.GetOrdersByPrductIDResponse
-	json.Unmarshal(ResponseBody, &GetOrdersByPrductIDResponse)
-
-	log.WithFields(log.Fields{
-		"event": "response from orders service for get orders list",
-		"data":  string(ResponseBody),
-	})
-
-	if GetOrdersByPrductIDResponse.Code != 200 {
-		return nil, errors.New(GetOrdersByPrductIDResponse.Message)
+		return nil, err
 	}
-	return GetOrdersByPrductIDResponse.Data, nil
+	return
 }
@@ -7,20 +7,28 @@ import (
 	"time"
 
 	redis "github.com/go-redis/redis/v7"
-	dbr "github.com/gocraft/dbr/v2"
 	"github.com/mrdhira/warpin-test/api/Products/entities"
 	"github.com/mrdhira/warpin-test/api/Products/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
 )
 
 // IProductsRepository interface
 type IProductsRepository interface {
-	Tx() (tx *dbr.Tx, err error)
+	Tx() (Tx transaction.Tx, err error)
 	ProductsFind(ctx context.Context, Limit int, Offset int, Condition map[string]interface{}) (Products []*entities.Products, err error)
 	ProductsFindOneByID(ctx context.Context, ID int) (Products *entities.Products, err error)
-	ProductsStore(ctx context.Context, db *dbr.Tx, Products *entities.Products) (ID int, err error)
-	ProductsLogStore(ctx context.Context, db *dbr.Tx, ProductsLog *entities.ProductsLog) (ID int, err error)
-	ProductsUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error)
+	ProductsLockByID(ctx context.Context, Tx transaction.Tx, ID int) (Products *entities.Products, err error)
+	ProductsStore(ctx context.Context, Tx transaction.Tx, Products *entities.Products) (ID int, err error)
+	ProductsLogStore(ctx context.Context, Tx transaction.Tx, ProductsLog *entities.ProductsLog) (ID int, err error)
+	ProductsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+	ProductsQtyAdd(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error)
+	ProductsQtyAdjust(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error)
+	ProductsCacheDelete(ctx context.Context, ID int) (err error)
+	OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error)
 }
 
 // ProductsRepository struct
@@ -30,17 +38,18 @@ type ProductsRepository struct {
 }
 
 // Tx func to create new transaction
-func (r *ProductsRepository) Tx() (tx *dbr.Tx, err error) {
+func (r *ProductsRepository) Tx() (Tx transaction.Tx, err error) {
 	db := r.PG.PostgresTrade()
 
-	tx, err = db.Begin()
+	tx, err := db.Begin()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"event": "error when begin transaction in postgres",
 		}).Error(err)
+		return
 	}
 
-	return
+	return tx, nil
 }
 
 // ProductsFind func
@@ -51,7 +60,7 @@ func (r *ProductsRepository) ProductsFind(ctx context.Context, Limit int, Offset
 	CacheKey := fmt.Sprintf("products:%d:%d", Limit, Offset)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache products",
 		}).Error(err)
 	}
@@ -68,7 +77,7 @@ func (r *ProductsRepository) ProductsFind(ctx context.Context, Limit int, Offset
 			Offset(uint64(Offset)).
 			LoadContext(ctx, &Products)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query products find",
 			}).Error(err)
 			return
@@ -78,7 +87,7 @@ func (r *ProductsRepository) ProductsFind(ctx context.Context, Limit int, Offset
 		ProductsJSON, _ := json.Marshal(Products)
 		err = r.Redis.Client().Set(CacheKey, ProductsJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for products",
 			}).Error(err)
 		}
@@ -94,10 +103,11 @@ func (r *ProductsRepository) ProductsFindOneByID(ctx context.Context, ID int) (P
 	db := r.PG.PostgresTrade()
 
 	// Check Cache
-	CacheKey := fmt.Sprintf("products:id:%d", ID)
+	CacheKey := productsCacheKey(ID)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
+	metrics.Cache("products", "products:id", err)
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache products by ID",
 		}).Error(err)
 	}
@@ -110,7 +120,7 @@ func (r *ProductsRepository) ProductsFindOneByID(ctx context.Context, ID int) (P
 
 		_, err = Query.LoadContext(ctx, &Products)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query products find by id",
 			}).Error(err)
 			return
@@ -120,7 +130,7 @@ func (r *ProductsRepository) ProductsFindOneByID(ctx context.Context, ID int) (P
 		ProductsJSON, _ := json.Marshal(Products)
 		err = r.Redis.Client().Set(CacheKey, ProductsJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for products",
 			}).Error(err)
 		}
@@ -131,8 +141,30 @@ func (r *ProductsRepository) ProductsFindOneByID(ctx context.Context, ID int) (P
 	return Products, nil
 }
 
+// ProductsLockByID func select the product with a row lock until the
+// transaction end, never from the cache
+func (r *ProductsRepository) ProductsLockByID(ctx context.Context, Tx transaction.Tx, ID int) (Products *entities.Products, err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.
+		Select("*").
+		From("products").
+		Where("id = ?", ID).
+		Suffix("FOR UPDATE").
+		LoadContext(ctx, &Products)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when lock products by id",
+		}).Error(err)
+	}
+
+	return
+}
+
 // ProductsStore func
-func (r *ProductsRepository) ProductsStore(ctx context.Context, db *dbr.Tx, Products *entities.Products) (ID int, err error) {
+func (r *ProductsRepository) ProductsStore(ctx context.Context, Tx transaction.Tx, Products *entities.Products) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("products").
 		Columns(
 			"name",
@@ -145,7 +177,7 @@ func (r *ProductsRepository) ProductsStore(ctx context.Context, db *dbr.Tx, Prod
 		Record(Products).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store products",
 		}).Error(err)
 	}
@@ -154,7 +186,9 @@ func (r *ProductsRepository) ProductsStore(ctx context.Context, db *dbr.Tx, Prod
 }
 
 // ProductsLogStore func
-func (r *ProductsRepository) ProductsLogStore(ctx context.Context, db *dbr.Tx, ProductsLog *entities.ProductsLog) (ID int, err error) {
+func (r *ProductsRepository) ProductsLogStore(ctx context.Context, Tx transaction.Tx, ProductsLog *entities.ProductsLog) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("products_log").
 		Columns(
 			"product_id",
@@ -170,7 +204,7 @@ func (r *ProductsRepository) ProductsLogStore(ctx context.Context, db *dbr.Tx, P
 		Record(ProductsLog).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store products log",
 		}).Error(err)
 	}
@@ -179,16 +213,91 @@ func (r *ProductsRepository) ProductsLogStore(ctx context.Context, db *dbr.Tx, P
 }
 
 // ProductsUpdate func
-func (r *ProductsRepository) ProductsUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error) {
+func (r *ProductsRepository) ProductsUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
 	_, err = db.Update("products").
 		Where("id = ?", ID).
 		SetMap(Payload).
 		ExecContext(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when update products",
 		}).Error(err)
 	}
 
 	return
 }
+
+// ProductsQtyAdd func add Qty to the stock in one conditional update, negative
+// Qty only pass when the product is active and has enough stock. Products is
+// nil when the condition does not pass
+func (r *ProductsRepository) ProductsQtyAdd(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error) {
+	db := transaction.Dbr(Tx)
+
+	Query := db.Update("products").
+		IncrBy("qty", Qty).
+		Set("updated_at", time.Now()).
+		Where("id = ?", ID)
+
+	if Qty < 0 {
+		Query.Where("status = ? AND qty >= ?", entities.Active, -Qty)
+	}
+
+	err = Query.
+		Returning("id", "name", "price", "qty", "status", "created_at", "updated_at").
+		LoadContext(ctx, &Products)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when add products qty",
+		}).Error(err)
+	}
+
+	return
+}
+
+// ProductsQtyAdjust func add Qty to the stock of the product whatever its
+// status, for the changes of the admin. Products is nil when the stock would go
+// below zero
+func (r *ProductsRepository) ProductsQtyAdjust(ctx context.Context, Tx transaction.Tx, ID int, Qty int) (Products *entities.Products, err error) {
+	db := transaction.Dbr(Tx)
+
+	err = db.Update("products").
+		IncrBy("qty", Qty).
+		Set("updated_at", time.Now()).
+		Where("id = ? AND qty + ? >= 0", ID, Qty).
+		Returning("id", "name", "price", "qty", "status", "created_at", "updated_at").
+		LoadContext(ctx, &Products)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when adjust products qty",
+		}).Error(err)
+	}
+
+	return
+}
+
+// ProductsCacheDelete func delete the cache of ProductsFindOneByID, to call
+// after the change of the product is committed
+func (r *ProductsRepository) ProductsCacheDelete(ctx context.Context, ID int) (err error) {
+	err = r.Redis.Client().WithContext(ctx).Del(productsCacheKey(ID)).Err()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when delete cache products by ID",
+		}).Error(err)
+	}
+
+	return
+}
+
+// OutboxStore func
+func (r *ProductsRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	return outbox.Store(ctx, db, Message)
+}
+
+// productsCacheKey func
+func productsCacheKey(ID int) string {
+	return fmt.Sprintf("products:id:%d", ID)
+}
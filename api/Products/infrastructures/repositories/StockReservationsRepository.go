@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/api/Products/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+// IStockReservationsRepository interface
+type IStockReservationsRepository interface {
+	StockReservationsFindByID(ctx context.Context, ID string) (StockReservations *entities.StockReservations, err error)
+	StockReservationsLockByID(ctx context.Context, Tx transaction.Tx, ID string) (StockReservations *entities.StockReservations, err error)
+	StockReservationsFindExpired(ctx context.Context, Now time.Time, Limit int) (StockReservations []*entities.StockReservations, err error)
+	StockReservationsStore(ctx context.Context, Tx transaction.Tx, StockReservations *entities.StockReservations) (err error)
+	StockReservationsUpdate(ctx context.Context, Tx transaction.Tx, ID string, Payload map[string]interface{}) (err error)
+}
+
+// StockReservationsRepository struct
+type StockReservationsRepository struct {
+	PG database.IPostgresConnection
+}
+
+// StockReservationsFindByID func
+func (r *StockReservationsRepository) StockReservationsFindByID(ctx context.Context, ID string) (StockReservations *entities.StockReservations, err error) {
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("stock_reservations").
+		Where("id = ?", ID).
+		LoadContext(ctx, &StockReservations)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query stock reservations find by id",
+		}).Error(err)
+	}
+
+	return
+}
+
+// StockReservationsLockByID func select the reservation with a row lock until the transaction end
+func (r *StockReservationsRepository) StockReservationsLockByID(ctx context.Context, Tx transaction.Tx, ID string) (StockReservations *entities.StockReservations, err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.
+		Select("*").
+		From("stock_reservations").
+		Where("id = ?", ID).
+		Suffix("FOR UPDATE").
+		LoadContext(ctx, &StockReservations)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when lock stock reservations by id",
+		}).Error(err)
+	}
+
+	return
+}
+
+// StockReservationsFindExpired func find reserved stock that pass the expired time
+func (r *StockReservationsRepository) StockReservationsFindExpired(ctx context.Context, Now time.Time, Limit int) (StockReservations []*entities.StockReservations, err error) {
+	db := r.PG.PostgresTrade()
+
+	_, err = db.
+		Select("*").
+		From("stock_reservations").
+		Where("status = ? AND expired_at < ?", entities.Reserved, Now).
+		OrderAsc("expired_at").
+		Limit(uint64(Limit)).
+		LoadContext(ctx, &StockReservations)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when query expired stock reservations",
+		}).Error(err)
+	}
+
+	return
+}
+
+// StockReservationsStore func
+func (r *StockReservationsRepository) StockReservationsStore(ctx context.Context, Tx transaction.Tx, StockReservations *entities.StockReservations) (err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.InsertInto("stock_reservations").
+		Columns(
+			"id",
+			"product_id",
+			"qty",
+			"status",
+			"expired_at",
+			"created_at",
+			"updated_at",
+		).
+		Record(StockReservations).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store stock reservations",
+		}).Error(err)
+	}
+
+	return
+}
+
+// StockReservationsUpdate func
+func (r *StockReservationsRepository) StockReservationsUpdate(ctx context.Context, Tx transaction.Tx, ID string, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
+	_, err = db.Update("stock_reservations").
+		Where("id = ?", ID).
+		SetMap(Payload).
+		ExecContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when update stock reservations",
+		}).Error(err)
+	}
+
+	return
+}
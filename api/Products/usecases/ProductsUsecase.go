@@ -2,14 +2,27 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mrdhira/warpin-test/api/Products/entities"
-	"github.com/mrdhira/warpin-test/api/Products/infrastructures/database"
 	"github.com/mrdhira/warpin-test/api/Products/infrastructures/repositories"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 // IProductsUsecases interface
@@ -18,31 +31,34 @@ type IProductsUsecases interface {
 	GetProductsByID(ctx context.Context, Data *entities.GetProductsByIDRequest) (Response *pkg.JSONResponse, err error)
 	AddProducts(ctx context.Context, Data *entities.AddProductsRequest) (Response *pkg.JSONResponse, err error)
 	UpdateProducts(ctx context.Context, Data *entities.UpdateProductsRequest) (Response *pkg.JSONResponse, err error)
+	ReserveStock(ctx context.Context, Data *entities.ReserveStockRequest) (Response *pkg.JSONResponse, err error)
+	ReleaseStock(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error)
+	CommitStock(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error)
+	GetStockReservation(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error)
+	ExpireStock(ctx context.Context, Limit int) (Expired int, err error)
 }
 
 // ProductsUsecases struct
 type ProductsUsecases struct {
-	ProductsRepository repositories.IProductsRepository
-	OrdersRepository   repositories.IOrdersRepository
+	ProductsRepository          repositories.IProductsRepository
+	OrdersRepository            repositories.IOrdersRepository
+	StockReservationsRepository repositories.IStockReservationsRepository
 }
 
 // InitProductsUsecases func
-func InitProductsUsecases() *ProductsUsecases {
-	// Init Repositories
-	productsRepository := new(repositories.ProductsRepository)
-	productsRepository.PG = &database.PostgresConnection{}
-	productsRepository.Redis = &database.RedisConnection{}
-
-	ordersRepository := new(repositories.OrdersRepository)
-
+func InitProductsUsecases(ProductsRepository repositories.IProductsRepository, OrdersRepository repositories.IOrdersRepository, StockReservationsRepository repositories.IStockReservationsRepository) *ProductsUsecases {
 	return &ProductsUsecases{
-		ProductsRepository: productsRepository,
-		OrdersRepository:   ordersRepository,
+		ProductsRepository:          ProductsRepository,
+		OrdersRepository:            OrdersRepository,
+		StockReservationsRepository: StockReservationsRepository,
 	}
 }
 
 // GetProducts usecases
 func (u *ProductsUsecases) GetProducts(ctx context.Context, Data *entities.GetProductsRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.GetProducts")
+	defer Span.Finish(&err)
+
 	Condition := map[string]interface{}{}
 	if Data.Status != 0 {
 		Condition["status"] = Data.Status
@@ -50,14 +66,14 @@ func (u *ProductsUsecases) GetProducts(ctx context.Context, Data *entities.GetPr
 
 	Limit, err := strconv.Atoi(Data.Limit)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for limit",
 		}).Error(err)
 		return
 	}
 	Offset, err := strconv.Atoi(Data.Offset)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when parse to int for offset",
 		}).Error(err)
 		return
@@ -70,27 +86,37 @@ func (u *ProductsUsecases) GetProducts(ctx context.Context, Data *entities.GetPr
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "OK",
+		Message: i18n.T(ctx, "ok"),
 		Data:    Products,
 	}, nil
 }
 
 // GetProductsByID usecases
 func (u *ProductsUsecases) GetProductsByID(ctx context.Context, Data *entities.GetProductsByIDRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.GetProductsByID")
+	defer Span.Finish(&err)
+
 	Products, err := u.ProductsRepository.ProductsFindOneByID(ctx, Data.ProductID)
 	if err != nil {
 		return
 	}
 
+	if Products == nil {
+		return nil, apperr.NotFound("product_not_found", Data.ProductID)
+	}
+
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "OK",
+		Message: i18n.T(ctx, "ok"),
 		Data:    Products,
 	}, nil
 }
 
 // AddProducts usecases
 func (u *ProductsUsecases) AddProducts(ctx context.Context, Data *entities.AddProductsRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.AddProducts")
+	defer Span.Finish(&err)
+
 	Tx, err := u.ProductsRepository.Tx()
 	if err != nil {
 		return
@@ -130,20 +156,31 @@ func (u *ProductsUsecases) AddProducts(ctx context.Context, Data *entities.AddPr
 		return
 	}
 
+	err = u.productsOutboxStore(ctx, Tx, Products.ID, entities.EventProductCreated, Products)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.StockChange("added", Products.Qty)
+
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Produk berhasil ditambahkan",
+		Message: i18n.T(ctx, string(entities.EventProductCreated)),
 		Data:    Products,
 	}, nil
 }
 
 // UpdateProducts usecases
 func (u *ProductsUsecases) UpdateProducts(ctx context.Context, Data *entities.UpdateProductsRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.UpdateProducts")
+	defer Span.Finish(&err)
+
 	if Data.Name == "" && Data.Price == nil && Data.Qty == nil && Data.Status == nil {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Nama, harga, dan kuantitas tidak bisa kosong semua",
-		}, nil
+		return nil, apperr.Validation("product_update_empty")
 	}
 
 	Products, err := u.ProductsRepository.ProductsFindOneByID(ctx, Data.ProductID)
@@ -152,10 +189,7 @@ func (u *ProductsUsecases) UpdateProducts(ctx context.Context, Data *entities.Up
 	}
 
 	if Products == nil {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Product dengan ID " + strconv.Itoa(Data.ProductID) + "tidak ditemukan",
-		}, nil
+		return nil, apperr.NotFound("product_not_found", Data.ProductID)
 	}
 
 	if Data.Status != nil {
@@ -167,15 +201,15 @@ func (u *ProductsUsecases) UpdateProducts(ctx context.Context, Data *entities.Up
 				ProductID: Data.ProductID,
 			}
 			Orders, err := u.OrdersRepository.GetOrdersByProductID(ctx, GetOrdersByPrductIDPayload)
+			if errors.Is(err, serviceclient.ErrCircuitOpen) {
+				return nil, apperr.Unavailable("orders_unavailable", err)
+			}
 			if err != nil {
 				return nil, err
 			}
 
 			if len(Orders) != 0 {
-				return &pkg.JSONResponse{
-					Code:    422,
-					Message: "Masih terdapat orders yang pending, tidak bisa menonaktifkan produk, silahkan mengurangi qty produk terlebih dahulu atau ubah status order",
-				}, nil
+				return nil, apperr.Validation("product_has_pending_orders")
 			}
 		}
 	}
@@ -186,6 +220,17 @@ func (u *ProductsUsecases) UpdateProducts(ctx context.Context, Data *entities.Up
 	}
 	defer Tx.RollbackUnlessCommitted()
 
+	// The reservations move the stock in the meantime, the product is read
+	// again under the row lock so the new qty is a change of the current one
+	Products, err = u.ProductsRepository.ProductsLockByID(ctx, Tx, Data.ProductID)
+	if err != nil {
+		return
+	}
+
+	if Products == nil {
+		return nil, apperr.NotFound("product_not_found", Data.ProductID)
+	}
+
 	ProductsLog := &entities.ProductsLog{
 		ProductID: Products.ID,
 		UserID:    Data.UserID,
@@ -205,33 +250,533 @@ func (u *ProductsUsecases) UpdateProducts(ctx context.Context, Data *entities.Up
 	}
 	if Data.Price != nil {
 		ProductsLog.Price = *Data.Price
-		UpdatePayload["price"] = Data.Price
+		UpdatePayload["price"] = *Data.Price
 	}
 	if Data.Qty != nil {
 		ProductsLog.Qty = *Data.Qty
-		UpdatePayload["qty"] = Data.Qty
 	}
 	if Data.Status != nil {
 		ProductsLog.Status = entities.ProductsStatus(*Data.Status)
 		UpdatePayload["status"] = entities.ProductsStatus(*Data.Status)
 	}
 
-	err = u.ProductsRepository.ProductsUpdate(ctx, Tx, Data.ProductID, UpdatePayload)
+	if len(UpdatePayload) != 0 {
+		err = u.ProductsRepository.ProductsUpdate(ctx, Tx, Data.ProductID, UpdatePayload)
+		if err != nil {
+			defer Tx.Rollback()
+			return
+		}
+	}
+
+	QtyDelta := ProductsLog.Qty - Products.Qty
+	if QtyDelta != 0 {
+		Adjusted, err := u.ProductsRepository.ProductsQtyAdjust(ctx, Tx, Data.ProductID, QtyDelta)
+		if err != nil {
+			defer Tx.Rollback()
+			return nil, err
+		}
+
+		// only a product that is gone is not adjusted under the lock
+		if Adjusted == nil {
+			defer Tx.Rollback()
+			return nil, apperr.NotFound("product_not_found", Data.ProductID)
+		}
+	}
+
+	ProductsLog.ID, err = u.ProductsRepository.ProductsLogStore(ctx, Tx, ProductsLog)
 	if err != nil {
 		defer Tx.Rollback()
 		return
 	}
 
-	ProductsLog.ID, err = u.ProductsRepository.ProductsLogStore(ctx, Tx, ProductsLog)
+	Event := entities.EventProductUpdated
+	if Products.Status == entities.Active && ProductsLog.Status == entities.InActive {
+		Event = entities.EventProductDeactivated
+	}
+
+	Products.Name = ProductsLog.Name
+	Products.Price = ProductsLog.Price
+	Products.Qty = ProductsLog.Qty
+	Products.Status = ProductsLog.Status
+	Products.UpdatedAt = ProductsLog.UpdatedAt
+
+	err = u.productsOutboxStore(ctx, Tx, Products.ID, Event, Products)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.StockChange("set", QtyDelta)
+
+	// The update is done, a cache that is not deleted only expire later
+	_ = u.ProductsRepository.ProductsCacheDelete(ctx, Products.ID)
+
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, string(entities.EventProductUpdated)),
+	}, nil
+}
+
+// ReserveStock usecases take the stock for a reservation with one conditional
+// update. Reserving an existing reservation again move it to the new qty, so
+// the same request can be retried safely
+func (u *ProductsUsecases) ReserveStock(ctx context.Context, Data *entities.ReserveStockRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.ReserveStock")
+	defer Span.Finish(&err)
+
+	if Data.ReservationID == "" {
+		Data.ReservationID, err = stockReservationID()
+		if err != nil {
+			return
+		}
+	}
+
+	if Data.Adopt {
+		Data.Qty, err = u.stockAdoptQty(ctx, Data)
+		if err != nil {
+			return
+		}
+	}
+
+	TTL := time.Duration(Data.TTL) * time.Second
+	if TTL == 0 {
+		TTL = viper.GetDuration("productsServices.reservation.ttl")
+	}
+	if TTL == 0 {
+		TTL = time.Minute * 15
+	}
+
+	Tx, err := u.ProductsRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	StockReservations, err := u.StockReservationsRepository.StockReservationsLockByID(ctx, Tx, Data.ReservationID)
+	if err != nil {
+		return
+	}
+
+	IsNew := StockReservations == nil
+	if IsNew {
+		if Data.Qty == 0 {
+			return nil, apperr.Validation("reservation_qty_empty").WithDetails(
+				apperr.Field("qty", "required", reflect.Int, ""),
+			)
+		}
+
+		StockReservations = &entities.StockReservations{
+			ID:        Data.ReservationID,
+			ProductID: Data.ProductID,
+			Status:    entities.Reserved,
+			ExpiredAt: time.Now().Add(TTL),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	if StockReservations.ProductID != Data.ProductID {
+		return nil, apperr.Validation("reservation_product_mismatch", Data.ReservationID)
+	}
+
+	// The stock was already taken, it is only recorded once
+	if Data.Adopt {
+		if IsNew {
+			StockReservations.Qty = Data.Qty
+			StockReservations.Status = entities.Committed
+			StockReservations.UpdatedAt = time.Now()
+
+			err = u.StockReservationsRepository.StockReservationsStore(ctx, Tx, StockReservations)
+			if err != nil {
+				return
+			}
+
+			err = Tx.Commit()
+			if err != nil {
+				return
+			}
+		}
+
+		return &pkg.JSONResponse{
+			Code:    200,
+			Message: i18n.T(ctx, string(entities.EventStockCommitted)),
+			Data:    StockReservations,
+		}, nil
+	}
+
+	// Released or expired reservation hold nothing, reserving it again start over
+	if StockReservations.Status == entities.Released || StockReservations.Status == entities.Expired {
+		StockReservations.Qty = 0
+		StockReservations.Status = entities.Reserved
+		StockReservations.ExpiredAt = time.Now().Add(TTL)
+	}
+
+	Delta := Data.Qty - StockReservations.Qty
+	if Delta != 0 {
+		Products, err := u.ProductsRepository.ProductsQtyAdd(ctx, Tx, Data.ProductID, -Delta)
+		if err != nil {
+			return nil, err
+		}
+
+		if Products == nil {
+			return nil, apperr.Validation("stock_insufficient").WithKey("stock_insufficient_or_inactive")
+		}
+
+		Event := entities.EventReserve
+		if Delta < 0 {
+			Event = entities.EventRelease
+		}
+
+		_, err = u.ProductsRepository.ProductsLogStore(ctx, Tx, stockProductsLog(Products, Data.UserID, Event))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	StockReservations.Qty = Data.Qty
+	StockReservations.UpdatedAt = time.Now()
+	if StockReservations.Qty == 0 {
+		StockReservations.Status = entities.Released
+	}
+
+	if IsNew {
+		err = u.StockReservationsRepository.StockReservationsStore(ctx, Tx, StockReservations)
+	} else {
+		err = u.StockReservationsRepository.StockReservationsUpdate(ctx, Tx, StockReservations.ID, map[string]interface{}{
+			"qty":        StockReservations.Qty,
+			"status":     StockReservations.Status,
+			"expired_at": StockReservations.ExpiredAt,
+			"updated_at": StockReservations.UpdatedAt,
+		})
+	}
+	if err != nil {
+		return
+	}
+
+	if Delta != 0 {
+		Event := entities.EventStockReserved
+		if StockReservations.Status == entities.Released {
+			Event = entities.EventStockReleased
+		}
+
+		err = u.productsOutboxStore(ctx, Tx, StockReservations.ProductID, Event, StockReservations)
+		if err != nil {
+			return
+		}
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	// a retry at the same qty moved nothing
+	switch {
+	case Delta > 0:
+		metrics.StockChange("reserved", Delta)
+	case Delta < 0:
+		metrics.StockChange("released", -Delta)
+	}
+
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, string(entities.EventStockReserved)),
+		Data:    StockReservations,
+	}, nil
+}
+
+// stockAdoptQty func, the qty to adopt capped to the qty the legacy line still
+// hold in orders services. Anything else than a legacy line is refused, an
+// adopted reservation give its stock back on release
+func (u *ProductsUsecases) stockAdoptQty(ctx context.Context, Data *entities.ReserveStockRequest) (int, error) {
+	OrderID, err := strconv.Atoi(strings.TrimPrefix(Data.ReservationID, entities.LegacyReservationPrefix))
+	if !entities.IsLegacyReservation(Data.ReservationID) || err != nil {
+		return 0, apperr.Validation("reservation_adopt_invalid", Data.ReservationID)
+	}
+
+	Orders, err := u.OrdersRepository.GetOrdersByProductID(ctx, &entities.GetOrdersByPrductIDPayload{
+		Limit:     1,
+		ProductID: Data.ProductID,
+		OrderID:   OrderID,
+	})
+	if errors.Is(err, serviceclient.ErrCircuitOpen) {
+		return 0, apperr.Unavailable("orders_unavailable", err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	Held := 0
+	for _, Orders := range Orders {
+		for _, Items := range Orders.Items {
+			if Items.ProductID == Data.ProductID && (Items.Status == entities.ItemsPending || Items.Status == entities.ItemsApprove) {
+				Held += Items.Qty
+			}
+		}
+	}
+	if Held == 0 {
+		return 0, apperr.Validation("reservation_adopt_invalid", Data.ReservationID)
+	}
+
+	if Data.Qty > Held {
+		return Held, nil
+	}
+	return Data.Qty, nil
+}
+
+// ReleaseStock usecases give the stock held by the reservation back, releasing
+// a released reservation again does nothing
+func (u *ProductsUsecases) ReleaseStock(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.ReleaseStock")
+	defer Span.Finish(&err)
+
+	Tx, err := u.ProductsRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	StockReservations, err := u.StockReservationsRepository.StockReservationsLockByID(ctx, Tx, Data.ReservationID)
+	if err != nil {
+		return
+	}
+
+	if StockReservations == nil {
+		return nil, apperr.NotFound("reservation_not_found", Data.ReservationID)
+	}
+
+	Released := 0
+	if StockReservations.Status == entities.Reserved || StockReservations.Status == entities.Committed {
+		Released = StockReservations.Qty
+		err = u.stockReservationsRelease(ctx, Tx, StockReservations, Data.UserID, entities.Released)
+		if err != nil {
+			return
+		}
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.StockChange("released", Released)
+
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, string(entities.EventStockReleased)),
+		Data:    StockReservations,
+	}, nil
+}
+
+// CommitStock usecases keep the reserved stock for good, a committed
+// reservation does not expire anymore
+func (u *ProductsUsecases) CommitStock(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.CommitStock")
+	defer Span.Finish(&err)
+
+	Tx, err := u.ProductsRepository.Tx()
+	if err != nil {
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	StockReservations, err := u.StockReservationsRepository.StockReservationsLockByID(ctx, Tx, Data.ReservationID)
+	if err != nil {
+		return
+	}
+
+	if StockReservations == nil {
+		return nil, apperr.NotFound("reservation_not_found", Data.ReservationID)
+	}
+
+	if StockReservations.Status == entities.Reserved && StockReservations.ExpiredAt.Before(time.Now()) {
+		Expired := StockReservations.Qty
+		err = u.stockReservationsRelease(ctx, Tx, StockReservations, Data.UserID, entities.Expired)
+		if err != nil {
+			return
+		}
+
+		err = Tx.Commit()
+		if err != nil {
+			return
+		}
+		metrics.StockChange("expired", Expired)
+	}
+
+	if StockReservations.Status == entities.Released || StockReservations.Status == entities.Expired {
+		return nil, apperr.Validation("reservation_expired", Data.ReservationID).WithData(StockReservations)
+	}
+
+	Committed := 0
+	if StockReservations.Status == entities.Reserved {
+		Committed = StockReservations.Qty
+		StockReservations.Status = entities.Committed
+		StockReservations.UpdatedAt = time.Now()
+
+		err = u.StockReservationsRepository.StockReservationsUpdate(ctx, Tx, StockReservations.ID, map[string]interface{}{
+			"status":     StockReservations.Status,
+			"updated_at": StockReservations.UpdatedAt,
+		})
+		if err != nil {
+			return
+		}
+
+		err = u.productsOutboxStore(ctx, Tx, StockReservations.ProductID, entities.EventStockCommitted, StockReservations)
+		if err != nil {
+			return
+		}
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		return
+	}
+	metrics.StockChange("committed", Committed)
+
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, string(entities.EventStockCommitted)),
+		Data:    StockReservations,
+	}, nil
+}
+
+// GetStockReservation usecases
+func (u *ProductsUsecases) GetStockReservation(ctx context.Context, Data *entities.StockReservationRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.GetStockReservation")
+	defer Span.Finish(&err)
+
+	StockReservations, err := u.StockReservationsRepository.StockReservationsFindByID(ctx, Data.ReservationID)
 	if err != nil {
-		defer Tx.Rollback()
 		return
 	}
 
-	defer Tx.Commit()
+	if StockReservations == nil {
+		return nil, apperr.NotFound("reservation_not_found", Data.ReservationID)
+	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Products berhasil diupdate",
+		Message: i18n.T(ctx, "ok"),
+		Data:    StockReservations,
 	}, nil
 }
+
+// ExpireStock usecases give back the stock of reservations that were not committed before the TTL
+func (u *ProductsUsecases) ExpireStock(ctx context.Context, Limit int) (Expired int, err error) {
+	ctx, Span := tracing.Start(ctx, "ProductsUsecases.ExpireStock")
+	defer Span.Finish(&err)
+
+	StockReservations, err := u.StockReservationsRepository.StockReservationsFindExpired(ctx, time.Now(), Limit)
+	if err != nil {
+		return
+	}
+
+	for _, StockReservation := range StockReservations {
+		Tx, err := u.ProductsRepository.Tx()
+		if err != nil {
+			return Expired, err
+		}
+
+		// Lock again, it could be committed since the query above
+		Locked, err := u.StockReservationsRepository.StockReservationsLockByID(ctx, Tx, StockReservation.ID)
+		if err == nil && Locked != nil && Locked.Status == entities.Reserved && Locked.ExpiredAt.Before(time.Now()) {
+			Qty := Locked.Qty
+			err = u.stockReservationsRelease(ctx, Tx, Locked, 0, entities.Expired)
+			if err == nil {
+				err = Tx.Commit()
+			}
+			if err == nil {
+				Expired++
+				metrics.StockChange("expired", Qty)
+			}
+		}
+		Tx.RollbackUnlessCommitted()
+
+		if err != nil {
+			return Expired, err
+		}
+	}
+
+	return
+}
+
+// stockReservationsRelease func give the held stock back and close the reservation with Status
+func (u *ProductsUsecases) stockReservationsRelease(ctx context.Context, Tx transaction.Tx, StockReservations *entities.StockReservations, UserID int, Status entities.StockReservationsStatus) (err error) {
+	if StockReservations.Qty > 0 {
+		Products, err := u.ProductsRepository.ProductsQtyAdd(ctx, Tx, StockReservations.ProductID, StockReservations.Qty)
+		if err != nil {
+			return err
+		}
+
+		Event := entities.EventRelease
+		if Status == entities.Expired {
+			Event = entities.EventExpire
+		}
+
+		if Products != nil {
+			_, err = u.ProductsRepository.ProductsLogStore(ctx, Tx, stockProductsLog(Products, UserID, Event))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	StockReservations.Qty = 0
+	StockReservations.Status = Status
+	StockReservations.UpdatedAt = time.Now()
+
+	err = u.StockReservationsRepository.StockReservationsUpdate(ctx, Tx, StockReservations.ID, map[string]interface{}{
+		"qty":        StockReservations.Qty,
+		"status":     StockReservations.Status,
+		"updated_at": StockReservations.UpdatedAt,
+	})
+	if err != nil {
+		return
+	}
+
+	Event := entities.EventStockReleased
+	if Status == entities.Expired {
+		Event = entities.EventStockExpired
+	}
+
+	return u.productsOutboxStore(ctx, Tx, StockReservations.ProductID, Event, StockReservations)
+}
+
+// productsOutboxStore store the products event in the outbox
+func (u *ProductsUsecases) productsOutboxStore(ctx context.Context, Tx transaction.Tx, ProductID int, Event entities.ProductsDomainEvent, Payload interface{}) (err error) {
+	Message, err := outbox.New(entities.ProductsAggregate, ProductID, string(Event), Payload)
+	if err != nil {
+		return
+	}
+
+	_, err = u.ProductsRepository.OutboxStore(ctx, Tx, Message)
+	return
+}
+
+// stockProductsLog func
+func stockProductsLog(Products *entities.Products, UserID int, Event entities.ProductsEvent) *entities.ProductsLog {
+	return &entities.ProductsLog{
+		ProductID: Products.ID,
+		UserID:    UserID,
+		Name:      Products.Name,
+		Price:     Products.Price,
+		Qty:       Products.Qty,
+		Status:    Products.Status,
+		Event:     Event,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// stockReservationID func
+func stockReservationID() (ID string, err error) {
+	Random := make([]byte, 16)
+	if _, err = rand.Read(Random); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when generate stock reservation id",
+		}).Error(err)
+		return
+	}
+
+	return hex.EncodeToString(Random), nil
+}
@@ -0,0 +1,276 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Products/entities"
+	"github.com/mrdhira/warpin-test/api/Products/infrastructures/memory"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/money"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// productsTestStore func store an active product with 10 stock
+func productsTestStore(t *testing.T, ProductsRepository *memory.ProductsRepository) int {
+	t.Helper()
+	Tx, _ := ProductsRepository.Tx()
+	ID, err := ProductsRepository.ProductsStore(context.Background(), Tx, &entities.Products{
+		Name:      "Kopi",
+		Price:     money.FromMajor(15000, "IDR"),
+		Qty:       10,
+		Status:    entities.Active,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return ID
+}
+
+// productsTestOrders func, an order of ProductID with Status
+func productsTestOrders(Status entities.OrdersStatus, ProductID int) *entities.Orders {
+	return &entities.Orders{
+		ID:     1,
+		UserID: 1,
+		Status: Status,
+		Items:  []*entities.OrdersItems{{ProductID: ProductID, Qty: 1}},
+	}
+}
+
+func TestUpdateProductsDeactivate(t *testing.T) {
+	Failure := errors.New("database is down")
+	InActive := int(entities.InActive)
+	Qty := 5
+
+	Tests := []struct {
+		Name      string
+		Orders    []*entities.Orders
+		OrdersErr error
+		Errors    map[string]error
+		Status    *int
+		Qty       *int
+		Code      int
+		ErrorCode string
+		Err       error
+		Want      entities.ProductsStatus
+		Events    []string
+	}{
+		{
+			Name:   "no orders",
+			Status: &InActive,
+			Code:   200,
+			Want:   entities.InActive,
+			Events: []string{string(entities.EventProductDeactivated)},
+		},
+		{
+			Name:   "only approved orders",
+			Orders: []*entities.Orders{productsTestOrders(entities.Approve, 1)},
+			Status: &InActive,
+			Code:   200,
+			Want:   entities.InActive,
+			Events: []string{string(entities.EventProductDeactivated)},
+		},
+		{
+			Name:   "pending orders of another product",
+			Orders: []*entities.Orders{productsTestOrders(entities.Pending, 2)},
+			Status: &InActive,
+			Code:   200,
+			Want:   entities.InActive,
+			Events: []string{string(entities.EventProductDeactivated)},
+		},
+		{
+			Name:      "pending orders",
+			Orders:    []*entities.Orders{productsTestOrders(entities.Pending, 1)},
+			Status:    &InActive,
+			Code:      422,
+			ErrorCode: "product_has_pending_orders",
+			Want:      entities.Active,
+		},
+		{
+			Name:   "pending orders does not block other updates",
+			Orders: []*entities.Orders{productsTestOrders(entities.Pending, 1)},
+			Qty:    &Qty,
+			Code:   200,
+			Want:   entities.Active,
+			Events: []string{string(entities.EventProductUpdated)},
+		},
+		{
+			Name:      "orders services breaker is open",
+			OrdersErr: serviceclient.ErrCircuitOpen,
+			Status:    &InActive,
+			Code:      503,
+			ErrorCode: "orders_unavailable",
+			Want:      entities.Active,
+		},
+		{
+			Name:      "orders services fail",
+			OrdersErr: Failure,
+			Status:    &InActive,
+			Err:       Failure,
+			Want:      entities.Active,
+		},
+		{
+			Name:   "products log fail roll the products back",
+			Errors: map[string]error{"ProductsLogStore": Failure},
+			Status: &InActive,
+			Err:    Failure,
+			Want:   entities.Active,
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			ProductsRepository := memory.NewProductsRepository()
+			OrdersRepository := memory.NewOrdersRepository(Test.Orders...)
+			OrdersRepository.Err = Test.OrdersErr
+			u := InitProductsUsecases(ProductsRepository, OrdersRepository, memory.NewStockReservationsRepository())
+
+			ProductID := productsTestStore(t, ProductsRepository)
+			for Method, err := range Test.Errors {
+				ProductsRepository.Errors[Method] = err
+			}
+
+			Response, err := u.UpdateProducts(context.Background(), &entities.UpdateProductsRequest{
+				UserID:    1,
+				ProductID: ProductID,
+				Qty:       Test.Qty,
+				Status:    Test.Status,
+			})
+			if Test.ErrorCode != "" {
+				if apperr.Status(err) != Test.Code || apperr.CodeOf(err) != Test.ErrorCode {
+					t.Fatalf("err = %v (%d), want %s (%d)", err, apperr.Status(err), Test.ErrorCode, Test.Code)
+				}
+			} else if !errors.Is(err, Test.Err) {
+				t.Fatalf("err = %v, want %v", err, Test.Err)
+			} else if Test.Err == nil && Response.Code != Test.Code {
+				t.Fatalf("code = %d, want %d (%s)", Response.Code, Test.Code, Response.Message)
+			}
+
+			if Status := ProductsRepository.Products[ProductID].Status; Status != Test.Want {
+				t.Errorf("status = %d, want %d", Status, Test.Want)
+			}
+			if Events := memory.OutboxEvents(ProductsRepository.Outbox); !reflect.DeepEqual(Events, Test.Events) {
+				t.Errorf("outbox = %v, want %v", Events, Test.Events)
+			}
+
+			// only a committed update delete the cache
+			Deleted := reflect.DeepEqual(ProductsRepository.CacheDeleted, []int{ProductID})
+			if Deleted != (Test.Events != nil) {
+				t.Errorf("cache deleted = %v, want it deleted %v", ProductsRepository.CacheDeleted, Test.Events != nil)
+			}
+		})
+	}
+}
+
+func TestUpdateProductsUnknown(t *testing.T) {
+	InActive := int(entities.InActive)
+	u := InitProductsUsecases(memory.NewProductsRepository(), memory.NewOrdersRepository(), memory.NewStockReservationsRepository())
+
+	_, err := u.UpdateProducts(context.Background(), &entities.UpdateProductsRequest{ProductID: 99, Status: &InActive})
+	if apperr.Status(err) != 404 || apperr.CodeOf(err) != "product_not_found" {
+		t.Fatalf("err = %v (%d), want product_not_found (404)", err, apperr.Status(err))
+	}
+}
+
+// reservingProductsRepository struct, a reservation take Reserved of the stock
+// right before the product is locked
+type reservingProductsRepository struct {
+	*memory.ProductsRepository
+	Reserved int
+}
+
+// ProductsLockByID func
+func (r *reservingProductsRepository) ProductsLockByID(ctx context.Context, Tx transaction.Tx, ID int) (*entities.Products, error) {
+	Reservation, _ := r.ProductsRepository.Tx()
+	if _, err := r.ProductsQtyAdd(ctx, Reservation, ID, -r.Reserved); err != nil {
+		return nil, err
+	}
+	if err := Reservation.Commit(); err != nil {
+		return nil, err
+	}
+	return r.ProductsRepository.ProductsLockByID(ctx, Tx, ID)
+}
+
+func TestUpdateProductsQty(t *testing.T) {
+	ProductsRepository := &reservingProductsRepository{ProductsRepository: memory.NewProductsRepository(), Reserved: 3}
+	u := InitProductsUsecases(ProductsRepository, memory.NewOrdersRepository(), memory.NewStockReservationsRepository())
+	ProductID := productsTestStore(t, ProductsRepository.ProductsRepository)
+
+	Qty := 20
+	Response, err := u.UpdateProducts(context.Background(), &entities.UpdateProductsRequest{UserID: 1, ProductID: ProductID, Qty: &Qty})
+	if err != nil || Response.Code != 200 {
+		t.Fatalf("update products: %v %+v", err, Response)
+	}
+
+	if Stock := ProductsRepository.Products[ProductID].Qty; Stock != Qty {
+		t.Errorf("stock = %d, want %d", Stock, Qty)
+	}
+	if Logged := ProductsRepository.ProductsLog[0].Qty; Logged != Qty {
+		t.Errorf("logged qty = %d, want %d", Logged, Qty)
+	}
+}
+
+func TestReserveStockAdopt(t *testing.T) {
+	Tests := []struct {
+		Name          string
+		ReservationID string
+		Items         []*entities.OrdersItems
+		Qty           int
+		ErrorCode     string
+		Want          int
+	}{
+		{Name: "legacy line", ReservationID: "legacy-1", Items: []*entities.OrdersItems{{ProductID: 1, Qty: 2, Status: entities.ItemsPending}}, Qty: 2, Want: 2},
+		{Name: "more than the line hold", ReservationID: "legacy-1", Items: []*entities.OrdersItems{{ProductID: 1, Qty: 2, Status: entities.ItemsApprove}}, Qty: 50, Want: 2},
+		{Name: "not a legacy reservation", ReservationID: "abc", Items: []*entities.OrdersItems{{ProductID: 1, Qty: 2, Status: entities.ItemsPending}}, Qty: 2, ErrorCode: "reservation_adopt_invalid"},
+		{Name: "unknown order", ReservationID: "legacy-2", Items: []*entities.OrdersItems{{ProductID: 1, Qty: 2, Status: entities.ItemsPending}}, Qty: 2, ErrorCode: "reservation_adopt_invalid"},
+		{Name: "cancelled line", ReservationID: "legacy-1", Items: []*entities.OrdersItems{{ProductID: 1, Qty: 2, Status: 4}}, Qty: 2, ErrorCode: "reservation_adopt_invalid"},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			ProductsRepository := memory.NewProductsRepository()
+			StockReservationsRepository := memory.NewStockReservationsRepository()
+			OrdersRepository := memory.NewOrdersRepository(&entities.Orders{ID: 1, UserID: 1, Status: entities.Pending, Items: Test.Items})
+			u := InitProductsUsecases(ProductsRepository, OrdersRepository, StockReservationsRepository)
+			ProductID := productsTestStore(t, ProductsRepository)
+
+			_, err := u.ReserveStock(context.Background(), &entities.ReserveStockRequest{ReservationID: Test.ReservationID, ProductID: ProductID, Qty: Test.Qty, Adopt: true})
+			if Test.ErrorCode != "" {
+				if apperr.CodeOf(err) != Test.ErrorCode {
+					t.Fatalf("err = %v, want %s", err, Test.ErrorCode)
+				}
+				if len(StockReservationsRepository.StockReservations) != 0 {
+					t.Fatalf("reservations = %+v, want none", StockReservationsRepository.StockReservations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if Qty := StockReservationsRepository.StockReservations[Test.ReservationID].Qty; Qty != Test.Want {
+				t.Errorf("adopted qty = %d, want %d", Qty, Test.Want)
+			}
+			if Stock := ProductsRepository.Products[ProductID].Qty; Stock != 10 {
+				t.Errorf("stock = %d, want 10 after the adopt", Stock)
+			}
+
+			// the release give back only what the line held
+			if _, err = u.ReleaseStock(context.Background(), &entities.StockReservationRequest{ReservationID: Test.ReservationID}); err != nil {
+				t.Fatal(err)
+			}
+			if Stock := ProductsRepository.Products[ProductID].Qty; Stock != 10+Test.Want {
+				t.Errorf("stock = %d, want %d after the release", Stock, 10+Test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package container
+
+import (
+	"github.com/mrdhira/warpin-test/api/Users/infrastructures/database"
+	"github.com/mrdhira/warpin-test/api/Users/infrastructures/repositories"
+	"github.com/mrdhira/warpin-test/api/Users/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	log "github.com/sirupsen/logrus"
+)
+
+// Container struct, every dependency of users services built once by New.
+// The repositories and usecases are interfaces so a test can build them with
+// other implementations
+type Container struct {
+	PG     *database.PostgresConnection
+	Redis  *database.RedisConnection
+	Health *health.Checker
+
+	// Verifier and Revocations check the tokens of users services, the
+	// revocation list is in the redis shared by every service (auth.redis)
+	Verifier        *auth.Verifier
+	RevocationStore *auth.RedisRevocations
+	Revocations     *auth.Revocations
+
+	UsersRepository  repositories.IUsersRepository
+	TokensRepository repositories.ITokensRepository
+
+	UsersUsecase usecases.IUsersUsecases
+}
+
+// New func open the database, redis and revocation list of users services and
+// build the repositories and usecases on them
+func New() (c *Container, err error) {
+	PG, err := database.NewPostgresConnection()
+	if err != nil {
+		return
+	}
+	Redis := database.NewRedisConnection()
+
+	RevocationStore := auth.NewRedisRevocations()
+
+	c = &Container{
+		PG:              PG,
+		Redis:           Redis,
+		Verifier:        auth.NewLocalVerifier(),
+		RevocationStore: RevocationStore,
+		Revocations:     auth.NewRevocations(RevocationStore),
+	}
+
+	// Init Repositories
+	c.UsersRepository = &repositories.UsersRepository{PG: PG, Redis: Redis}
+	c.TokensRepository = &repositories.TokensRepository{Redis: Redis}
+
+	// Init Usecases
+	c.UsersUsecase = usecases.InitUsersUsecases(c.UsersRepository, c.TokensRepository, c.Revocations)
+
+	// Init Health, the /readyz of the service
+	c.Health = health.New("users").
+		Add("postgres", health.Postgres(PG.Connection)).
+		Add("redis", health.Redis(Redis.Redis)).
+		Add("revocations", health.Redis(RevocationStore.Client))
+
+	return
+}
+
+// Close func close the database, redis and revocation list, call it once the
+// server stopped
+func (c *Container) Close() (err error) {
+	if err = c.PG.Close(); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close sql connection",
+		}).Error(err)
+	}
+
+	if RedisErr := c.Redis.Close(); RedisErr != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close redis connection",
+		}).Error(RedisErr)
+		err = RedisErr
+	}
+
+	if RevocationsErr := c.RevocationStore.Close(); RevocationsErr != nil {
+		log.WithFields(log.Fields{
+			"event": "error when close revocations redis connection",
+		}).Error(RevocationsErr)
+		err = RevocationsErr
+	}
+
+	return
+}
@@ -2,93 +2,109 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
 	"github.com/mrdhira/warpin-test/api/Users/entities"
-	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/logging"
 	log "github.com/sirupsen/logrus"
 )
 
-// AuthMiddleware func
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		// Validate Token
-		Authorization := req.Header.Get("Authorization")
-		TokenData := &entities.TokenClaim{}
-		Token, err := jwt.ParseWithClaims(Authorization, TokenData, func(token *jwt.Token) (interface{}, error) {
-			if jwt.GetSigningMethod("HS256") != token.Method {
-				return nil, fmt.Errorf("Unex[ected signing method: %v", token.Header["alg"])
+// AuthMiddleware func, the tokens of users services are checked with Verifier
+// and Revocations
+func AuthMiddleware(Verifier *auth.Verifier, Revocations *auth.Revocations) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			// Validate Token
+			Authorization := req.Header.Get("Authorization")
+			TokenData := &entities.TokenClaim{}
+			Token, err := Verifier.Parse(Authorization, TokenData)
+			if err == nil {
+				err = Revocations.CheckRevoked(TokenData.Id)
 			}
 
-			return []byte("secret"), nil
-		})
-
-		if Token != nil && err == nil {
-			TokenDataJSON, _ := json.Marshal(TokenData)
-			fmt.Println("Token Verified: ", string(TokenDataJSON))
-			context.Set(req, "token", string(TokenDataJSON))
-		} else {
-			log.WithFields(log.Fields{
-				"event": "unauthorized token",
-				"data":  Token,
-			}).Error(err)
-			pkg.Response(res, 401, &pkg.JSONResponse{
-				Code:    401,
-				Message: "Unauthorized",
-				Error:   err.Error(),
-			})
-			return
-		}
+			if Token != nil && err == nil {
+				TokenDataJSON, _ := json.Marshal(TokenData)
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"user_id":   TokenData.UserID,
+					"user_role": TokenData.UserRole,
+				}).Debug("token verified")
+				context.Set(req, "token", string(TokenDataJSON))
+			} else {
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"event": "unauthorized token",
+				}).Error(err)
+				apperr.Write(res, req, tokenError(err))
+				return
+			}
 
-		next.ServeHTTP(res, req)
-	})
+			next.ServeHTTP(res, req)
+		})
+	}
 }
 
 // AuthAdmniMiddleware func
-func AuthAdmniMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		// Validate Token
-		Authorization := req.Header.Get("Authorization")
-		if Authorization == "INTERNAL-SERVICES" {
-			fmt.Println("coming request from internal services")
-		} else {
-			TokenData := &entities.TokenClaim{}
-			Token, err := jwt.ParseWithClaims(Authorization, TokenData, func(token *jwt.Token) (interface{}, error) {
-				if jwt.GetSigningMethod("HS256") != token.Method {
-					return nil, fmt.Errorf("Unex[ected signing method: %v", token.Header["alg"])
+func AuthAdmniMiddleware(Verifier *auth.Verifier, Revocations *auth.Revocations) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			// Validate Token
+			Authorization := req.Header.Get("Authorization")
+			if auth.IsServiceToken(Authorization) {
+				ServiceClaim, err := auth.VerifyServiceToken(Authorization, "users")
+				if err != nil {
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"event": "unauthorized service token",
+					}).Error(err)
+					apperr.Write(res, req, apperr.Unauthorized("service_token_invalid").Wrap(err))
+					return
 				}
 
-				return []byte("secret"), nil
-			})
-
-			if Token != nil && err == nil {
-				TokenDataJSON, _ := json.Marshal(TokenData)
-				fmt.Println("Token Verified: ", string(TokenDataJSON))
-				if TokenData.UserRole != entities.Admin {
-					pkg.Response(res, 403, &pkg.JSONResponse{
-						Code:    403,
-						Message: "Forbidden Access",
-					})
-					return
+				TokenData := &entities.TokenClaim{
+					Service: ServiceClaim.Issuer,
 				}
+				TokenDataJSON, _ := json.Marshal(TokenData)
 				context.Set(req, "token", string(TokenDataJSON))
 			} else {
-				log.WithFields(log.Fields{
-					"event": "unauthorized token",
-					"data":  Token,
-				}).Error(err)
-				pkg.Response(res, 401, &pkg.JSONResponse{
-					Code:    401,
-					Message: "Unauthorized",
-					Error:   err.Error(),
-				})
-				return
+				TokenData := &entities.TokenClaim{}
+				Token, err := Verifier.Parse(Authorization, TokenData)
+				if err == nil {
+					err = Revocations.CheckRevoked(TokenData.Id)
+				}
+
+				if Token != nil && err == nil {
+					TokenDataJSON, _ := json.Marshal(TokenData)
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"user_id":   TokenData.UserID,
+						"user_role": TokenData.UserRole,
+					}).Debug("token verified")
+					if TokenData.UserRole != entities.Admin {
+						apperr.Write(res, req, apperr.Forbidden("admin_only"))
+						return
+					}
+					context.Set(req, "token", string(TokenDataJSON))
+				} else {
+					logging.FromContext(req.Context()).WithFields(log.Fields{
+						"event": "unauthorized token",
+					}).Error(err)
+					apperr.Write(res, req, tokenError(err))
+					return
+				}
 			}
-		}
 
-		next.ServeHTTP(res, req)
-	})
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// tokenError func, a token that is not valid is a 401 but a revocation list
+// that can not be read is a 503
+func tokenError(err error) *apperr.Error {
+	if errors.Is(err, auth.ErrRevocationsUnavailable) {
+		return apperr.Unavailable("service_unavailable", err)
+	}
+	return apperr.Unauthorized("token_invalid").Wrap(err)
 }
@@ -5,30 +5,61 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mrdhira/warpin-test/api/Users/deliveries/http/controllers"
+	"github.com/mrdhira/warpin-test/api/Users/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
 )
 
-// Route struct
-type Route struct{}
+// Route struct, the usecases are built by the caller (see container.New).
+// Health is the /readyz of the service, without it /readyz has no check.
+// Verifier and Revocations check the tokens signed by the service
+type Route struct {
+	Health       *health.Checker
+	Verifier     *auth.Verifier
+	Revocations  *auth.Revocations
+	UsersUsecase usecases.IUsersUsecases
+}
 
 // Init func
 func (r *Route) Init() *mux.Router {
 	// Initialize Controllers
-	usersControllers := controllers.InitUsersControllers()
+	usersControllers := controllers.InitUsersControllers(r.UsersUsecase)
 
 	// Initialize Router
 	Router := mux.NewRouter().StrictSlash(true)
 
+	// Metrics, trace and log of every route
+	Router.Use(metrics.Middleware("users"), tracing.Middleware("users"), logging.Middleware("users"), i18n.Middleware())
+	Router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	// Health Routes
+	Health := r.Health
+	if Health == nil {
+		Health = health.New("users")
+	}
+	Router.HandleFunc("/healthz", Health.Liveness).Methods(http.MethodGet)
+	Router.HandleFunc("/readyz", Health.Readiness).Methods(http.MethodGet)
+
+	// Public keys of the tokens
+	Router.HandleFunc("/.well-known/jwks.json", usersControllers.JWKS).Methods(http.MethodGet)
+
 	// Users Routes with no Auth
 	UsersNoAuthRoutes := Router.PathPrefix("/users").Subrouter()
 	UsersNoAuthRoutes.HandleFunc("/register", usersControllers.Register).Methods(http.MethodPost)
 	UsersNoAuthRoutes.HandleFunc("/login", usersControllers.Login).Methods(http.MethodPost)
+	UsersNoAuthRoutes.HandleFunc("/refresh", usersControllers.Refresh).Methods(http.MethodPost)
 
 	// Users Routes with Auth
 	UsersAuthRoutes := Router.PathPrefix("/users").Subrouter()
-	UsersAuthRoutes.Use(AuthMiddleware)
+	UsersAuthRoutes.Use(AuthMiddleware(r.Verifier, r.Revocations))
 	UsersAuthRoutes.HandleFunc("/profile", usersControllers.Profile).Methods(http.MethodGet)
 	UsersAuthRoutes.HandleFunc("/update-profile", usersControllers.UpdateProfile).Methods(http.MethodPut)
 	UsersAuthRoutes.HandleFunc("/update-password", usersControllers.UpdatePassword).Methods(http.MethodPut)
+	UsersAuthRoutes.HandleFunc("/logout", usersControllers.Logout).Methods(http.MethodPost)
 
 	return Router
 }
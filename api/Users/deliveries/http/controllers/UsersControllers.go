@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -13,6 +12,9 @@ import (
 	"github.com/mrdhira/warpin-test/api/Users/entities"
 	"github.com/mrdhira/warpin-test/api/Users/usecases"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/logging"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,7 +26,7 @@ type UsersControllers struct {
 }
 
 // InitUsersControllers func
-func InitUsersControllers() *UsersControllers {
+func InitUsersControllers(UsersUsecase usecases.IUsersUsecases) *UsersControllers {
 	validate = validator.New()
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
@@ -34,70 +36,32 @@ func InitUsersControllers() *UsersControllers {
 		return name
 	})
 
-	// Init Usecase
-	usersUsecases := usecases.InitUsersUsecases()
-
 	return &UsersControllers{
-		UsersUsecase: usersUsecases,
+		UsersUsecase: UsersUsecase,
 	}
 }
 
 // Register func
 func (c *UsersControllers) Register(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /users/register payload body")
-
 	var requestBody *entities.RegisterRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload register",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.UsersUsecase.Register(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -108,59 +72,76 @@ func (c *UsersControllers) Register(res http.ResponseWriter, req *http.Request)
 // Login func
 func (c *UsersControllers) Login(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /users/register payload body")
-
 	var requestBody *entities.LoginRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload login",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.UsersUsecase.Login(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// Refresh func
+func (c *UsersControllers) Refresh(res http.ResponseWriter, req *http.Request) {
+	RawPayload, _ := ioutil.ReadAll(req.Body)
+
+	var requestBody *entities.RefreshRequest
+	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal request payload refresh",
+		}).Error(err)
+		apperr.Write(res, req, apperr.Malformed(err))
+		return
+	}
+
+	// Payload Validation
+	if err := validate.Struct(requestBody); err != nil {
+		apperr.Write(res, req, apperr.Payload(err))
+		return
+	}
+
+	Response, err := c.UsersUsecase.Refresh(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
+
+	pkg.Response(res, Response.Code, Response)
+	return
+}
+
+// Logout func
+func (c *UsersControllers) Logout(res http.ResponseWriter, req *http.Request) {
+	TokenJSON := context.Get(req, "token").(string)
+
+	var requestBody *entities.LogoutRequest
+	if err := json.Unmarshal([]byte(TokenJSON), &requestBody); err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when unmarshal token data",
+		}).Error(err)
+		apperr.Write(res, req, err)
+		return
+	}
+
+	Response, err := c.UsersUsecase.Logout(req.Context(), requestBody)
+	if err != nil {
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -168,30 +149,44 @@ func (c *UsersControllers) Login(res http.ResponseWriter, req *http.Request) {
 	return
 }
 
+// JWKS func, public keys of the users tokens for the other services. The
+// body is the plain JWKS document, not a JSONResponse
+func (c *UsersControllers) JWKS(res http.ResponseWriter, req *http.Request) {
+	SigningKeys, err := auth.DefaultSigningKeys()
+	if err != nil {
+		logging.FromContext(req.Context()).WithFields(log.Fields{
+			"event": "error when load signing keys",
+		}).Error(err)
+		apperr.Write(res, req, err)
+		return
+	}
+
+	JWKS, err := SigningKeys.JWKS()
+	if err != nil {
+		apperr.Write(res, req, err)
+		return
+	}
+
+	res.Header().Set("Cache-Control", "public, max-age=300")
+	pkg.Response(res, http.StatusOK, JWKS)
+}
+
 // Profile func
 func (c *UsersControllers) Profile(res http.ResponseWriter, req *http.Request) {
 	TokenJSON := context.Get(req, "token").(string)
 
 	var requestBody *entities.ProfileRequest
 	if err := json.Unmarshal([]byte(TokenJSON), &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token data",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
 	Response, err := c.UsersUsecase.Profile(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -202,39 +197,22 @@ func (c *UsersControllers) Profile(res http.ResponseWriter, req *http.Request) {
 // UpdateProfile func
 func (c *UsersControllers) UpdateProfile(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /users/register payload body")
-
 	var requestBody *entities.UpdateProfileRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload update profile",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -242,35 +220,13 @@ func (c *UsersControllers) UpdateProfile(res http.ResponseWriter, req *http.Requ
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.UsersUsecase.UpdateProfile(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -281,39 +237,22 @@ func (c *UsersControllers) UpdateProfile(res http.ResponseWriter, req *http.Requ
 // UpdatePassword func
 func (c *UsersControllers) UpdatePassword(res http.ResponseWriter, req *http.Request) {
 	RawPayload, _ := ioutil.ReadAll(req.Body)
-	RawPayloadString := string(RawPayload)
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\n", "")
-	RawPayloadString = strings.ReplaceAll(RawPayloadString, "\\", "")
-	RawPayloadString = strings.TrimSpace(RawPayloadString)
-	// Log Raw Payload
-	log.WithFields(log.Fields{
-		"data": RawPayloadString,
-	}).Info("POST /users/register payload body")
-
 	var requestBody *entities.UpdatePasswordRequest
 	if err := json.Unmarshal(RawPayload, &requestBody); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal request payload update password",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, apperr.Malformed(err))
 		return
 	}
 
 	TokenJSON := context.Get(req, "token").(string)
 	var TokenData *entities.TokenClaim
 	if err := json.Unmarshal([]byte(TokenJSON), &TokenData); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(req.Context()).WithFields(log.Fields{
 			"event": "error when unmarshal token",
 		}).Error(err)
-		pkg.Response(res, http.StatusBadRequest, &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -321,35 +260,13 @@ func (c *UsersControllers) UpdatePassword(res http.ResponseWriter, req *http.Req
 
 	// Payload Validation
 	if err := validate.Struct(requestBody); err != nil {
-		errField := map[string]string{}
-		errFields := []map[string]string{}
-
-		for _, e := range err.(validator.ValidationErrors) {
-			errField[e.Field()] = fmt.Sprintf("%s failed on the %s tag", e.Field(), e.Tag())
-		}
-		errFields = append(errFields, errField)
-
-		log.WithFields(log.Fields{
-			"event":            "payload validation error",
-			"validation_error": errFields,
-		})
-
-		pkg.Response(res, http.StatusUnprocessableEntity, &pkg.JSONResponse{
-			Code:    422,
-			Message: "payload validation error",
-			Error:   err.Error(),
-			Data:    errFields,
-		})
+		apperr.Write(res, req, apperr.Payload(err))
 		return
 	}
 
 	Response, err := c.UsersUsecase.UpdatePassword(req.Context(), requestBody)
 	if err != nil {
-		pkg.Response(res, http.StatusBadRequest, pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		})
+		apperr.Write(res, req, err)
 		return
 	}
 
@@ -16,6 +16,19 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// RefreshRequest struct
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest struct, filled from the claims of the access token
+type LogoutRequest struct {
+	UserID    int    `json:"user_id" validate:"required"`
+	TokenID   string `json:"jti" validate:"-"`
+	SessionID string `json:"sid" validate:"-"`
+	ExpiresAt int64  `json:"exp" validate:"-"`
+}
+
 // ProfileRequest struct
 type ProfileRequest struct {
 	UserID int `json:"user_id" validate:"required"`
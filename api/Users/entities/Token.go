@@ -2,9 +2,29 @@ package entities
 
 import "github.com/dgrijalva/jwt-go"
 
-// TokenClaim struct
+// TokenClaim struct, Id (jti) identify the access token and SessionID the
+// refresh tokens it was issued with. Service is only set for the requests of
+// other services, they have no users
 type TokenClaim struct {
-	UserID   int      `json:"user_id"`
-	UserRole UserRole `json:"user_role"`
+	UserID    int      `json:"user_id"`
+	UserRole  UserRole `json:"user_role"`
+	SessionID string   `json:"sid,omitempty"`
+	Service   string   `json:"service,omitempty"`
 	jwt.StandardClaims
 }
+
+// RefreshTokens struct, a refresh token can be used once, using it again
+// revoke the whole session
+type RefreshTokens struct {
+	UserID    int      `json:"user_id"`
+	UserRole  UserRole `json:"user_role"`
+	SessionID string   `json:"session_id"`
+}
+
+// TokensResponse struct
+type TokensResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
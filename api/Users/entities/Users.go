@@ -22,7 +22,20 @@ const (
 	Customer UserRole = "CUSTOMER"
 )
 
-// Users struct
+// UsersEvent string, domain event published through the outbox
+type UsersEvent string
+
+// UsersEvent Master
+const (
+	EventUserRegistered      UsersEvent = "UserRegistered"
+	EventUserProfileUpdated  UsersEvent = "UserProfileUpdated"
+	EventUserPasswordChanged UsersEvent = "UserPasswordChanged"
+)
+
+// UsersAggregate for outbox
+const UsersAggregate = "users"
+
+// Users struct, Password is the bcrypt hash and is never marshaled
 type Users struct {
 	ID          int        `db:"id" json:"id"`
 	Email       string     `db:"email" json:"email"`
@@ -30,12 +43,12 @@ type Users struct {
 	FullName    string     `db:"full_name" json:"full_name"`
 	Gender      UserGender `db:"gender" json:"gender"`
 	Role        UserRole   `db:"role" json:"role"`
-	Password    string     `db:"password" json:"password"`
+	Password    string     `db:"password" json:"-"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
 
-// UsersLog struct
+// UsersLog struct, Password is the bcrypt hash and is never marshaled
 type UsersLog struct {
 	ID          int        `db:"id" json:"id"`
 	UserID      int        `db:"user_id" json:"user_id"`
@@ -44,7 +57,7 @@ type UsersLog struct {
 	FullName    string     `db:"full_name" json:"full_name"`
 	Gender      UserGender `db:"gender" json:"gender"`
 	Role        UserRole   `db:"role" json:"role"`
-	Password    string     `db:"password" json:"password"`
+	Password    string     `db:"password" json:"-"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
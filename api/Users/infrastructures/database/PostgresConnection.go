@@ -4,6 +4,8 @@ import (
 	"strconv"
 
 	dbr "github.com/gocraft/dbr/v2"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	_ "go.elastic.co/apm/module/apmsql/pq"
@@ -14,34 +16,40 @@ type IPostgresConnection interface {
 	PostgresTrade() *dbr.Session
 }
 
-// PostgresConnection struct
-type PostgresConnection struct{}
-
-// Initialize Variable
-var (
-	PGConnection *dbr.Connection
-)
+// PostgresConnection struct, the connection pool of users services database.
+// Build it once with NewPostgresConnection and Close it on shutdown
+type PostgresConnection struct {
+	Connection *dbr.Connection
+}
 
-// PostgresTrade func
-func (p *PostgresConnection) PostgresTrade() *dbr.Session {
-	if PGConnection == nil {
-		Driver := viper.GetString("usersServices.database.driver")
-		DSN := viper.GetString("usersServices.database.dsn")
-		MaxIdle, _ := strconv.Atoi(viper.GetString("usersServices.database.max_idle"))
-		MaxConn, _ := strconv.Atoi(viper.GetString("usersServices.database.max_conn"))
-
-		var err error
-
-		PGConnection, err = dbr.Open(Driver, DSN, nil)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"event": "error when create sql connection",
-			}).Error(err)
-		}
-		PGConnection.SetMaxIdleConns(MaxIdle)
-		PGConnection.SetMaxOpenConns(MaxConn)
+// NewPostgresConnection func open the pool of usersServices.database
+func NewPostgresConnection() (*PostgresConnection, error) {
+	Driver := viper.GetString("usersServices.database.driver")
+	DSN := viper.GetString("usersServices.database.dsn")
+	MaxIdle, _ := strconv.Atoi(viper.GetString("usersServices.database.max_idle"))
+	MaxConn, _ := strconv.Atoi(viper.GetString("usersServices.database.max_conn"))
+
+	Connection, err := dbr.Open(Driver, DSN, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when create sql connection",
+		}).Error(err)
+		return nil, err
 	}
+	Connection.SetMaxIdleConns(MaxIdle)
+	Connection.SetMaxOpenConns(MaxConn)
 
-	Session := PGConnection.NewSession(nil)
+	return &PostgresConnection{Connection: Connection}, nil
+}
+
+// PostgresTrade func, the queries of the session are traced and recorded in
+// the metrics
+func (p *PostgresConnection) PostgresTrade() *dbr.Session {
+	Session := p.Connection.NewSession(tracing.NewDBReceiver(metrics.NewDBReceiver("users")))
 	return Session
 }
+
+// Close func
+func (p *PostgresConnection) Close() error {
+	return p.Connection.Close()
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Users/entities"
+)
+
+// TokensRepository struct, repositories.ITokensRepository kept in memory for
+// the tests
+type TokensRepository struct {
+	mu            sync.Mutex
+	RefreshTokens map[string]*entities.RefreshTokens
+	Used          map[string]bool
+	Sessions      map[string]time.Time
+	Errors        map[string]error
+}
+
+// NewTokensRepository func
+func NewTokensRepository() *TokensRepository {
+	return &TokensRepository{
+		RefreshTokens: map[string]*entities.RefreshTokens{},
+		Used:          map[string]bool{},
+		Sessions:      map[string]time.Time{},
+		Errors:        map[string]error{},
+	}
+}
+
+// RefreshTokensStore func
+func (r *TokensRepository) RefreshTokensStore(ctx context.Context, Token string, RefreshTokens *entities.RefreshTokens, TTL time.Duration) (err error) {
+	if err = r.fail("RefreshTokensStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	Copy := *RefreshTokens
+	r.RefreshTokens[Token] = &Copy
+	r.Sessions[RefreshTokens.SessionID] = time.Now().Add(TTL)
+	return
+}
+
+// RefreshTokensUse func
+func (r *TokensRepository) RefreshTokensUse(ctx context.Context, Token string) (RefreshTokens *entities.RefreshTokens, FirstUse bool, err error) {
+	if err = r.fail("RefreshTokensUse"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	Stored, ok := r.RefreshTokens[Token]
+	if !ok {
+		return
+	}
+
+	Copy := *Stored
+	FirstUse = !r.Used[Token]
+	r.Used[Token] = true
+	return &Copy, FirstUse, nil
+}
+
+// SessionsActive func
+func (r *TokensRepository) SessionsActive(ctx context.Context, SessionID string) (Active bool, err error) {
+	if err = r.fail("SessionsActive"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ExpiredAt, ok := r.Sessions[SessionID]
+	return ok && time.Now().Before(ExpiredAt), nil
+}
+
+// SessionsRevoke func
+func (r *TokensRepository) SessionsRevoke(ctx context.Context, SessionID string) (err error) {
+	if err = r.fail("SessionsRevoke"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.Sessions, SessionID)
+	return
+}
+
+// fail func
+func (r *TokensRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
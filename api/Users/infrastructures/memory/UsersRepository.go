@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mrdhira/warpin-test/api/Users/entities"
+	"github.com/mrdhira/warpin-test/pkg/memstore"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
+)
+
+// UsersRepository struct, repositories.IUsersRepository kept in memory for the
+// tests. Errors hold the error a method return by its name, to test the paths
+// where the database fail
+type UsersRepository struct {
+	mu       sync.Mutex
+	Users    map[int]*entities.Users
+	UsersLog []*entities.UsersLog
+	Outbox   []*outbox.Message
+	Errors   map[string]error
+
+	// the ids are sequences, a rollback does not give them back
+	lastUsersID    int
+	lastUsersLogID int
+	lastOutboxID   int
+}
+
+// NewUsersRepository func
+func NewUsersRepository() *UsersRepository {
+	return &UsersRepository{
+		Users:  map[int]*entities.Users{},
+		Errors: map[string]error{},
+	}
+}
+
+// Tx func
+func (r *UsersRepository) Tx() (Tx transaction.Tx, err error) {
+	if err = r.fail("Tx"); err != nil {
+		return
+	}
+	return transaction.NewMemory(), nil
+}
+
+// UsersFindOne func
+func (r *UsersRepository) UsersFindOne(ctx context.Context, Condition map[string]interface{}) (Users *entities.Users, err error) {
+	if err = r.fail("UsersFindOne"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ID := 1; ID <= r.lastUsersID; ID++ {
+		if Stored, ok := r.Users[ID]; ok && memstore.Match(Stored, Condition) {
+			Copy := *Stored
+			return &Copy, nil
+		}
+	}
+	return
+}
+
+// UsersFindByID func
+func (r *UsersRepository) UsersFindByID(ctx context.Context, ID int) (Users *entities.Users, err error) {
+	return r.UsersFindOne(ctx, map[string]interface{}{"id": ID})
+}
+
+// UsersFindByEmail func
+func (r *UsersRepository) UsersFindByEmail(ctx context.Context, Email string) (Users *entities.Users, err error) {
+	return r.UsersFindOne(ctx, map[string]interface{}{"email": Email})
+}
+
+// UsersStore func
+func (r *UsersRepository) UsersStore(ctx context.Context, Tx transaction.Tx, Users *entities.Users) (ID int, err error) {
+	if err = r.fail("UsersStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastUsersID++
+	ID = r.lastUsersID
+	Copy := *Users
+	Copy.ID = ID
+	r.Users[ID] = &Copy
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.Users, ID)
+	})
+	return
+}
+
+// UsersLogStore func
+func (r *UsersRepository) UsersLogStore(ctx context.Context, Tx transaction.Tx, UsersLog *entities.UsersLog) (ID int, err error) {
+	if err = r.fail("UsersLogStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastUsersLogID++
+	ID = r.lastUsersLogID
+	Copy := *UsersLog
+	Copy.ID = ID
+	r.UsersLog = append(r.UsersLog, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, Stored := range r.UsersLog {
+			if Stored.ID == ID {
+				r.UsersLog = append(r.UsersLog[:i], r.UsersLog[i+1:]...)
+				break
+			}
+		}
+	})
+	return
+}
+
+// ProfileByID func
+func (r *UsersRepository) ProfileByID(ctx context.Context, ID int) (Profile *entities.Profile, err error) {
+	Users, err := r.UsersFindByID(ctx, ID)
+	if err != nil || Users == nil {
+		return
+	}
+
+	return &entities.Profile{
+		ID:          Users.ID,
+		Email:       Users.Email,
+		PhoneNumber: Users.PhoneNumber,
+		FullName:    Users.FullName,
+		Gender:      Users.Gender,
+		Role:        Users.Role,
+	}, nil
+}
+
+// UsersUpdate func
+func (r *UsersRepository) UsersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	if err = r.fail("UsersUpdate"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	Stored, ok := r.Users[ID]
+	if !ok {
+		return
+	}
+
+	Previous := *Stored
+	if err = memstore.Set(Stored, Payload); err != nil {
+		return
+	}
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*Stored = Previous
+	})
+	return
+}
+
+// OutboxStore func
+func (r *UsersRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	if err = r.fail("OutboxStore"); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastOutboxID++
+	ID = r.lastOutboxID
+	Copy := *Message
+	Copy.ID = ID
+	r.Outbox = append(r.Outbox, &Copy)
+
+	transaction.AsMemory(Tx).OnRollback(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.Outbox = outboxRemove(r.Outbox, ID)
+	})
+	return
+}
+
+// fail func
+func (r *UsersRepository) fail(Method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Errors[Method]
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mrdhira/warpin-test/api/Users/entities"
+	"github.com/mrdhira/warpin-test/api/Users/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// ITokensRepository interface
+type ITokensRepository interface {
+	RefreshTokensStore(ctx context.Context, Token string, RefreshTokens *entities.RefreshTokens, TTL time.Duration) (err error)
+	RefreshTokensUse(ctx context.Context, Token string) (RefreshTokens *entities.RefreshTokens, FirstUse bool, err error)
+	SessionsActive(ctx context.Context, SessionID string) (Active bool, err error)
+	SessionsRevoke(ctx context.Context, SessionID string) (err error)
+}
+
+// TokensRepository struct, refresh tokens and sessions are only kept in redis
+type TokensRepository struct {
+	Redis database.IRedisConnection
+}
+
+// RefreshTokensStore func store the refresh token and keep its session alive for TTL
+func (r *TokensRepository) RefreshTokensStore(ctx context.Context, Token string, RefreshTokens *entities.RefreshTokens, TTL time.Duration) (err error) {
+	Key := "users:refresh:" + Token
+
+	Pipe := r.Redis.Client().WithContext(ctx).TxPipeline()
+	Pipe.HSet(Key,
+		"user_id", RefreshTokens.UserID,
+		"user_role", string(RefreshTokens.UserRole),
+		"session_id", RefreshTokens.SessionID,
+	)
+	Pipe.Expire(Key, TTL)
+	Pipe.Set("users:session:"+RefreshTokens.SessionID, RefreshTokens.UserID, TTL)
+
+	_, err = Pipe.Exec()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when store refresh token",
+		}).Error(err)
+	}
+
+	return
+}
+
+// RefreshTokensUse func load the refresh token and mark it used, FirstUse is
+// only true for the first caller so a token can not be rotated twice
+func (r *TokensRepository) RefreshTokensUse(ctx context.Context, Token string) (RefreshTokens *entities.RefreshTokens, FirstUse bool, err error) {
+	Key := "users:refresh:" + Token
+	Client := r.Redis.Client().WithContext(ctx)
+
+	Values, err := Client.HGetAll(Key).Result()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when get refresh token",
+		}).Error(err)
+		return
+	}
+
+	if len(Values) == 0 {
+		return
+	}
+
+	UserID, _ := strconv.Atoi(Values["user_id"])
+	RefreshTokens = &entities.RefreshTokens{
+		UserID:    UserID,
+		UserRole:  entities.UserRole(Values["user_role"]),
+		SessionID: Values["session_id"],
+	}
+
+	FirstUse, err = Client.HSetNX(Key, "used_at", time.Now().Unix()).Result()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when mark refresh token used",
+		}).Error(err)
+	}
+
+	return
+}
+
+// SessionsActive func
+func (r *TokensRepository) SessionsActive(ctx context.Context, SessionID string) (Active bool, err error) {
+	Exists, err := r.Redis.Client().WithContext(ctx).Exists("users:session:" + SessionID).Result()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when check session",
+		}).Error(err)
+		return
+	}
+
+	return Exists > 0, nil
+}
+
+// SessionsRevoke func, every refresh token of the session stop working
+func (r *TokensRepository) SessionsRevoke(ctx context.Context, SessionID string) (err error) {
+	err = r.Redis.Client().WithContext(ctx).Del("users:session:" + SessionID).Err()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when revoke session",
+		}).Error(err)
+	}
+
+	return
+}
@@ -7,23 +7,27 @@ import (
 	"time"
 
 	redis "github.com/go-redis/redis/v7"
-	dbr "github.com/gocraft/dbr/v2"
 	"github.com/mrdhira/warpin-test/api/Users/entities"
 	"github.com/mrdhira/warpin-test/api/Users/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
 )
 
 // IUsersRepository interface
 type IUsersRepository interface {
-	Tx() (tx *dbr.Tx, err error)
+	Tx() (Tx transaction.Tx, err error)
 	UsersFindOne(ctx context.Context, Condition map[string]interface{}) (Users *entities.Users, err error)
 	UsersFindByID(ctx context.Context, ID int) (Users *entities.Users, err error)
 	UsersFindByEmail(ctx context.Context, Email string) (Users *entities.Users, err error)
-	UsersStore(ctx context.Context, db *dbr.Tx, Users *entities.Users) (ID int, err error)
-	UsersLogStore(ctx context.Context, db *dbr.Tx, UsersLog *entities.UsersLog) (ID int, err error)
-	// UsersEventLogStore(ctx context.Context, db *dbr.Tx, UsersEventLog *entities.UsersEventLog) (ID int, err error)
+	UsersStore(ctx context.Context, Tx transaction.Tx, Users *entities.Users) (ID int, err error)
+	UsersLogStore(ctx context.Context, Tx transaction.Tx, UsersLog *entities.UsersLog) (ID int, err error)
+	// UsersEventLogStore(ctx context.Context, Tx transaction.Tx, UsersEventLog *entities.UsersEventLog) (ID int, err error)
 	ProfileByID(ctx context.Context, ID int) (Profile *entities.Profile, err error)
-	UsersUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error)
+	UsersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error)
+	OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error)
 }
 
 // UsersRepository struct
@@ -32,18 +36,37 @@ type UsersRepository struct {
 	Redis database.IRedisConnection
 }
 
+// usersCache struct, the Users kept in redis with the hash of its password
+// that entities.Users never marshal, the login read it from the cache
+type usersCache struct {
+	*entities.Users
+	Password string `json:"password"`
+}
+
+// usersFromCache func
+func usersFromCache(Value string) (Users *entities.Users) {
+	Cache := &usersCache{}
+	_ = json.Unmarshal([]byte(Value), Cache)
+	if Cache.Users == nil {
+		return nil
+	}
+	Cache.Users.Password = Cache.Password
+	return Cache.Users
+}
+
 // Tx func to create new transaction
-func (r *UsersRepository) Tx() (tx *dbr.Tx, err error) {
+func (r *UsersRepository) Tx() (Tx transaction.Tx, err error) {
 	db := r.PG.PostgresTrade()
 
-	tx, err = db.Begin()
+	tx, err := db.Begin()
 	if err != nil {
 		log.WithFields(log.Fields{
 			"event": "error when begin transaction in postgres",
 		}).Error(err)
+		return
 	}
 
-	return
+	return tx, nil
 }
 
 // UsersFindOne func
@@ -58,7 +81,7 @@ func (r *UsersRepository) UsersFindOne(ctx context.Context, Condition map[string
 
 	_, err = Query.Limit(1).LoadContext(ctx, &Users)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when query users find one",
 		}).Error(err)
 		return
@@ -74,8 +97,9 @@ func (r *UsersRepository) UsersFindByID(ctx context.Context, ID int) (Users *ent
 	// Check Cache
 	CacheKey := fmt.Sprintf("users:id:%d", ID)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
+	metrics.Cache("users", "users:id", err)
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache users by ID",
 		}).Error(err)
 	}
@@ -88,25 +112,24 @@ func (r *UsersRepository) UsersFindByID(ctx context.Context, ID int) (Users *ent
 
 		_, err = Query.LoadContext(ctx, &Users)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query users find by id",
 			}).Error(err)
 			return
 		}
 
 		// Set Cache
-		UsersJSON, _ := json.Marshal(Users)
+		UsersJSON, _ := json.Marshal(&usersCache{Users: Users, Password: Users.Password})
 		err = r.Redis.Client().Set(CacheKey, UsersJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for users",
 			}).Error(err)
 		}
 		return Users, nil
 	}
 
-	_ = json.Unmarshal([]byte(Value), &Users)
-	return Users, nil
+	return usersFromCache(Value), nil
 }
 
 // UsersFindByEmail func
@@ -117,7 +140,7 @@ func (r *UsersRepository) UsersFindByEmail(ctx context.Context, Email string) (U
 	CacheKey := fmt.Sprintf("users:email:%s", Email)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache users by email",
 		}).Error(err)
 	}
@@ -130,29 +153,30 @@ func (r *UsersRepository) UsersFindByEmail(ctx context.Context, Email string) (U
 
 		_, err = Query.LoadContext(ctx, &Users)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query users find by email",
 			}).Error(err)
 			return
 		}
 
 		// Set Cache
-		UsersJSON, _ := json.Marshal(Users)
+		UsersJSON, _ := json.Marshal(&usersCache{Users: Users, Password: Users.Password})
 		err = r.Redis.Client().Set(CacheKey, UsersJSON, time.Second*10).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for users",
 			}).Error(err)
 		}
 		return
 	}
 
-	_ = json.Unmarshal([]byte(Value), &Users)
-	return Users, nil
+	return usersFromCache(Value), nil
 }
 
 // UsersStore func
-func (r *UsersRepository) UsersStore(ctx context.Context, db *dbr.Tx, Users *entities.Users) (ID int, err error) {
+func (r *UsersRepository) UsersStore(ctx context.Context, Tx transaction.Tx, Users *entities.Users) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("users").
 		Columns(
 			"email",
@@ -167,7 +191,7 @@ func (r *UsersRepository) UsersStore(ctx context.Context, db *dbr.Tx, Users *ent
 		Record(Users).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store users",
 		}).Error(err)
 	}
@@ -176,7 +200,9 @@ func (r *UsersRepository) UsersStore(ctx context.Context, db *dbr.Tx, Users *ent
 }
 
 // UsersLogStore func
-func (r *UsersRepository) UsersLogStore(ctx context.Context, db *dbr.Tx, UsersLog *entities.UsersLog) (ID int, err error) {
+func (r *UsersRepository) UsersLogStore(ctx context.Context, Tx transaction.Tx, UsersLog *entities.UsersLog) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
 	if err = db.InsertInto("users_log").
 		Columns(
 			"user_id",
@@ -192,7 +218,7 @@ func (r *UsersRepository) UsersLogStore(ctx context.Context, db *dbr.Tx, UsersLo
 		Record(UsersLog).
 		Returning("id").
 		LoadContext(ctx, &ID); err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when store users log",
 		}).Error(err)
 	}
@@ -208,7 +234,7 @@ func (r *UsersRepository) ProfileByID(ctx context.Context, ID int) (Profile *ent
 	CacheKey := fmt.Sprintf("users:profile:id:%d", ID)
 	Value, err := r.Redis.Client().Get(CacheKey).Result()
 	if err != redis.Nil && err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when get cache profile by ID",
 		}).Error(err)
 	}
@@ -221,7 +247,7 @@ func (r *UsersRepository) ProfileByID(ctx context.Context, ID int) (Profile *ent
 
 		_, err = Query.LoadContext(ctx, &Profile)
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when query profile find by id",
 			}).Error(err)
 			return
@@ -231,7 +257,7 @@ func (r *UsersRepository) ProfileByID(ctx context.Context, ID int) (Profile *ent
 		ProfileJSON, _ := json.Marshal(Profile)
 		err = r.Redis.Client().Set(CacheKey, ProfileJSON, time.Minute*15).Err()
 		if err != nil {
-			log.WithFields(log.Fields{
+			logging.FromContext(ctx).WithFields(log.Fields{
 				"event": "error when set cache for Profile",
 			}).Error(err)
 		}
@@ -242,16 +268,25 @@ func (r *UsersRepository) ProfileByID(ctx context.Context, ID int) (Profile *ent
 }
 
 // UsersUpdate func
-func (r *UsersRepository) UsersUpdate(ctx context.Context, db *dbr.Tx, ID int, Payload map[string]interface{}) (err error) {
+func (r *UsersRepository) UsersUpdate(ctx context.Context, Tx transaction.Tx, ID int, Payload map[string]interface{}) (err error) {
+	db := transaction.Dbr(Tx)
+
 	_, err = db.Update("users").
 		Where("id = ?", ID).
 		SetMap(Payload).
 		ExecContext(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when update users",
 		}).Error(err)
 	}
 
 	return
 }
+
+// OutboxStore func
+func (r *UsersRepository) OutboxStore(ctx context.Context, Tx transaction.Tx, Message *outbox.Message) (ID int, err error) {
+	db := transaction.Dbr(Tx)
+
+	return outbox.Store(ctx, db, Message)
+}
@@ -2,14 +2,24 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/mrdhira/warpin-test/api/Users/entities"
-	"github.com/mrdhira/warpin-test/api/Users/infrastructures/database"
 	"github.com/mrdhira/warpin-test/api/Users/infrastructures/repositories"
 	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	"github.com/mrdhira/warpin-test/pkg/transaction"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,6 +27,8 @@ import (
 type IUsersUsecases interface {
 	Register(ctx context.Context, Data *entities.RegisterRequest) (Response *pkg.JSONResponse, err error)
 	Login(ctx context.Context, Data *entities.LoginRequest) (Response *pkg.JSONResponse, err error)
+	Refresh(ctx context.Context, Data *entities.RefreshRequest) (Response *pkg.JSONResponse, err error)
+	Logout(ctx context.Context, Data *entities.LogoutRequest) (Response *pkg.JSONResponse, err error)
 	Profile(ctx context.Context, Data *entities.ProfileRequest) (Response *pkg.JSONResponse, err error)
 	UpdateProfile(ctx context.Context, Data *entities.UpdateProfileRequest) (Response *pkg.JSONResponse, err error)
 	UpdatePassword(ctx context.Context, Data *entities.UpdatePasswordRequest) (Response *pkg.JSONResponse, err error)
@@ -24,33 +36,32 @@ type IUsersUsecases interface {
 
 // UsersUsecases struct
 type UsersUsecases struct {
-	UsersRepository repositories.IUsersRepository
+	UsersRepository  repositories.IUsersRepository
+	TokensRepository repositories.ITokensRepository
+	Revocations      *auth.Revocations
 }
 
-// InitUsersUsecases func
-func InitUsersUsecases() *UsersUsecases {
-	// Init Repositories
-	usersRepository := new(repositories.UsersRepository)
-	usersRepository.PG = &database.PostgresConnection{}
-	usersRepository.Redis = &database.RedisConnection{}
-
+// InitUsersUsecases func, Revocations is where Logout revoke the access tokens
+func InitUsersUsecases(UsersRepository repositories.IUsersRepository, TokensRepository repositories.ITokensRepository, Revocations *auth.Revocations) *UsersUsecases {
 	return &UsersUsecases{
-		UsersRepository: usersRepository,
+		UsersRepository:  UsersRepository,
+		TokensRepository: TokensRepository,
+		Revocations:      Revocations,
 	}
 }
 
 // Register usecases
 func (u *UsersUsecases) Register(ctx context.Context, Data *entities.RegisterRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.Register")
+	defer Span.Finish(&err)
+
 	CheckUsers, err := u.UsersRepository.UsersFindByEmail(ctx, Data.Email)
 	if err != nil {
 		return
 	}
 
 	if CheckUsers != nil {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Anda sudah terdaftar dengan email " + Data.Email,
-		}, nil
+		return nil, apperr.Conflict("email_registered", Data.Email)
 	}
 
 	Tx, err := u.UsersRepository.Tx()
@@ -101,97 +112,174 @@ func (u *UsersUsecases) Register(ctx context.Context, Data *entities.RegisterReq
 		return
 	}
 
-	defer Tx.Commit()
+	err = u.usersOutboxStore(ctx, Tx, UsersLog, entities.EventUserRegistered)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit register",
+		}).Error(err)
+		Tx.Rollback()
+		return nil, apperr.Internal(err)
+	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Berhasil terdaftar",
+		Message: i18n.T(ctx, string(entities.EventUserRegistered)),
 		Data:    Users,
 	}, nil
 }
 
 // Login usecases
 func (u *UsersUsecases) Login(ctx context.Context, Data *entities.LoginRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.Login")
+	defer Span.Finish(&err)
+
 	Users, err := u.UsersRepository.UsersFindByEmail(ctx, Data.Email)
 	if err != nil {
 		return
 	}
 
 	if Users == nil {
-		return &pkg.JSONResponse{
-			Code:    404,
-			Message: "users tidak ditemukan",
-		}, nil
+		return nil, apperr.NotFound("user_not_found")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(Users.Password), []byte(Data.Password))
 	if err != nil {
-		log.WithFields(log.Fields{
+		logging.FromContext(ctx).WithFields(log.Fields{
 			"event": "error when compare hash and password",
 		}).Error(err)
 
-		return &pkg.JSONResponse{
-			Code:    403,
-			Message: "Password yang anda masukkan salah",
-			Error:   err.Error(),
-		}, nil
+		return nil, apperr.Forbidden("wrong_password")
 	}
 
-	TokenData := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), &entities.TokenClaim{
-		UserID:   Users.ID,
-		UserRole: Users.Role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-		},
-	})
+	Tokens, err := u.tokensIssue(ctx, Users.ID, Users.Role, "")
+	if err != nil {
+		return
+	}
 
-	TokenString, err := TokenData.SignedString([]byte("secret"))
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, "UserLoggedIn"),
+		Data:    Tokens,
+	}, nil
+}
+
+// Refresh usecases rotate the refresh token, a refresh token that is used
+// twice was stolen or replayed so its whole session is revoked
+func (u *UsersUsecases) Refresh(ctx context.Context, Data *entities.RefreshRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.Refresh")
+	defer Span.Finish(&err)
+
+	RefreshTokens, FirstUse, err := u.TokensRepository.RefreshTokensUse(ctx, Data.RefreshToken)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"event": "error when signed string for jwt token",
-		}).Error(err)
+		return
+	}
+
+	if RefreshTokens == nil {
+		return nil, apperr.Unauthorized("refresh_token_invalid")
+	}
 
-		return &pkg.JSONResponse{
-			Code:    400,
-			Message: "Terjadi kesalahan sistem",
-			Error:   err.Error(),
-		}, nil
+	Active, err := u.TokensRepository.SessionsActive(ctx, RefreshTokens.SessionID)
+	if err != nil {
+		return
+	}
+
+	if !Active {
+		return nil, apperr.Unauthorized("session_ended")
+	}
+
+	if !FirstUse {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event":   "refresh token reused, revoking session",
+			"user_id": RefreshTokens.UserID,
+		}).Warn("refresh token reused")
+
+		err = u.TokensRepository.SessionsRevoke(ctx, RefreshTokens.SessionID)
+		if err != nil {
+			return
+		}
+
+		return nil, apperr.Unauthorized("refresh_token_reused")
+	}
+
+	Tokens, err := u.tokensIssue(ctx, RefreshTokens.UserID, RefreshTokens.UserRole, RefreshTokens.SessionID)
+	if err != nil {
+		return
+	}
+
+	return &pkg.JSONResponse{
+		Code:    200,
+		Message: i18n.T(ctx, "ok"),
+		Data:    Tokens,
+	}, nil
+}
+
+// Logout usecases revoke the access token and every refresh token of its session
+func (u *UsersUsecases) Logout(ctx context.Context, Data *entities.LogoutRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.Logout")
+	defer Span.Finish(&err)
+
+	err = u.Revocations.Revoke(Data.TokenID, time.Unix(Data.ExpiresAt, 0))
+	if err != nil {
+		return
+	}
+
+	if Data.SessionID != "" {
+		err = u.TokensRepository.SessionsRevoke(ctx, Data.SessionID)
+		if err != nil {
+			return
+		}
 	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Berhasil login",
-		Data:    TokenString,
+		Message: i18n.T(ctx, "UserLoggedOut"),
 	}, nil
 }
 
 // Profile usecases
 func (u *UsersUsecases) Profile(ctx context.Context, Data *entities.ProfileRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.Profile")
+	defer Span.Finish(&err)
+
 	Profile, err := u.UsersRepository.ProfileByID(ctx, Data.UserID)
 	if err != nil {
 		return
 	}
+	if Profile == nil {
+		return nil, apperr.NotFound("user_not_found")
+	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "OK",
+		Message: i18n.T(ctx, "ok"),
 		Data:    Profile,
 	}, nil
 }
 
 // UpdateProfile usecases
 func (u *UsersUsecases) UpdateProfile(ctx context.Context, Data *entities.UpdateProfileRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.UpdateProfile")
+	defer Span.Finish(&err)
+
 	if Data.FullName == "" && Data.PhoneNumber == "" {
-		return &pkg.JSONResponse{
-			Code:    422,
-			Message: "Nama dan Nomor Handphone tidak bisa kosong semua",
-		}, nil
+		return nil, apperr.Validation("profile_empty").WithDetails(
+			apperr.Field("full_name", "required_without", reflect.String, "phone_number"),
+			apperr.Field("phone_number", "required_without", reflect.String, "full_name"),
+		)
 	}
 
 	Users, err := u.UsersRepository.UsersFindByID(ctx, Data.UserID)
 	if err != nil {
 		return
 	}
+	if Users == nil {
+		return nil, apperr.NotFound("user_not_found")
+	}
 
 	Tx, err := u.UsersRepository.Tx()
 	if err != nil {
@@ -233,20 +321,38 @@ func (u *UsersUsecases) UpdateProfile(ctx context.Context, Data *entities.Update
 		return
 	}
 
-	defer Tx.Commit()
+	err = u.usersOutboxStore(ctx, Tx, UsersLog, entities.EventUserProfileUpdated)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit update profile",
+		}).Error(err)
+		Tx.Rollback()
+		return nil, apperr.Internal(err)
+	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Profile berhasil diupdate",
+		Message: i18n.T(ctx, string(entities.EventUserProfileUpdated)),
 	}, nil
 }
 
 // UpdatePassword usecases
 func (u *UsersUsecases) UpdatePassword(ctx context.Context, Data *entities.UpdatePasswordRequest) (Response *pkg.JSONResponse, err error) {
+	ctx, Span := tracing.Start(ctx, "UsersUsecases.UpdatePassword")
+	defer Span.Finish(&err)
+
 	Users, err := u.UsersRepository.UsersFindByID(ctx, Data.UserID)
 	if err != nil {
 		return
 	}
+	if Users == nil {
+		return nil, apperr.NotFound("user_not_found")
+	}
 
 	Tx, err := u.UsersRepository.Tx()
 	if err != nil {
@@ -287,10 +393,124 @@ func (u *UsersUsecases) UpdatePassword(ctx context.Context, Data *entities.Updat
 		return
 	}
 
-	defer Tx.Commit()
+	err = u.usersOutboxStore(ctx, Tx, UsersLog, entities.EventUserPasswordChanged)
+	if err != nil {
+		return
+	}
+
+	err = Tx.Commit()
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when commit update password",
+		}).Error(err)
+		Tx.Rollback()
+		return nil, apperr.Internal(err)
+	}
 
 	return &pkg.JSONResponse{
 		Code:    200,
-		Message: "Password berhasil diupdate",
+		Message: i18n.T(ctx, string(entities.EventUserPasswordChanged)),
+	}, nil
+}
+
+// usersOutboxStore store the users event in the outbox, the payload is the
+// profile so the password hash is never published
+func (u *UsersUsecases) usersOutboxStore(ctx context.Context, Tx transaction.Tx, Users *entities.UsersLog, Event entities.UsersEvent) (err error) {
+	Profile := &entities.Profile{
+		ID:          Users.UserID,
+		Email:       Users.Email,
+		PhoneNumber: Users.PhoneNumber,
+		FullName:    Users.FullName,
+		Gender:      Users.Gender,
+		Role:        Users.Role,
+	}
+
+	Message, err := outbox.New(entities.UsersAggregate, Users.UserID, string(Event), Profile)
+	if err != nil {
+		return
+	}
+
+	_, err = u.UsersRepository.OutboxStore(ctx, Tx, Message)
+	return
+}
+
+// tokensIssue func sign a short lived access token and store a new refresh
+// token for the session, a new session is started when SessionID is empty
+func (u *UsersUsecases) tokensIssue(ctx context.Context, UserID int, UserRole entities.UserRole, SessionID string) (Tokens *entities.TokensResponse, err error) {
+	AccessTTL := viper.GetDuration("auth.access_ttl")
+	if AccessTTL == 0 {
+		AccessTTL = time.Minute * 15
+	}
+	RefreshTTL := viper.GetDuration("auth.refresh_ttl")
+	if RefreshTTL == 0 {
+		RefreshTTL = time.Hour * 24 * 30
+	}
+
+	if SessionID == "" {
+		SessionID, err = tokensRandom()
+		if err != nil {
+			return
+		}
+	}
+
+	TokenID, err := tokensRandom()
+	if err != nil {
+		return
+	}
+
+	SigningKeys, err := auth.DefaultSigningKeys()
+	if err != nil {
+		return
+	}
+
+	AccessToken, err := SigningKeys.Sign(&entities.TokenClaim{
+		UserID:    UserID,
+		UserRole:  UserRole,
+		SessionID: SessionID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        TokenID,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(AccessTTL).Unix(),
+		},
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithFields(log.Fields{
+			"event": "error when signed string for jwt token",
+		}).Error(err)
+		return
+	}
+
+	RefreshToken, err := tokensRandom()
+	if err != nil {
+		return
+	}
+
+	err = u.TokensRepository.RefreshTokensStore(ctx, RefreshToken, &entities.RefreshTokens{
+		UserID:    UserID,
+		UserRole:  UserRole,
+		SessionID: SessionID,
+	}, RefreshTTL)
+	if err != nil {
+		return
+	}
+
+	return &entities.TokensResponse{
+		AccessToken:  AccessToken,
+		RefreshToken: RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(AccessTTL.Seconds()),
 	}, nil
 }
+
+// tokensRandom func
+func tokensRandom() (Token string, err error) {
+	Random := make([]byte, 32)
+	if _, err = rand.Read(Random); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when generate random token",
+		}).Error(err)
+		return
+	}
+
+	return hex.EncodeToString(Random), nil
+}
@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mrdhira/warpin-test/api/Users/entities"
+	"github.com/mrdhira/warpin-test/api/Users/infrastructures/memory"
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usersTestUsecases func
+func usersTestUsecases() (*UsersUsecases, *memory.UsersRepository, *memory.TokensRepository) {
+	UsersRepository := memory.NewUsersRepository()
+	TokensRepository := memory.NewTokensRepository()
+	return InitUsersUsecases(UsersRepository, TokensRepository, auth.NewRevocations(auth.NewMemoryRevocations())), UsersRepository, TokensRepository
+}
+
+// usersTestRegister func
+func usersTestRegister(t *testing.T, u *UsersUsecases, Email string, Password string) {
+	t.Helper()
+	Response, err := u.Register(context.Background(), &entities.RegisterRequest{
+		Email:    Email,
+		FullName: "Budi",
+		Gender:   entities.Male,
+		Role:     entities.Customer,
+		Password: Password,
+	})
+	if err != nil || Response.Code != 200 {
+		t.Fatalf("register %s: %v %+v", Email, err, Response)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Failure := errors.New("database is down")
+
+	Tests := []struct {
+		Name       string
+		Existing   string
+		Errors     map[string]error
+		Code       int
+		ErrorCode  string
+		Err        error
+		Users      int
+		UsersLog   int
+		OutboxEvts []string
+	}{
+		{
+			Name:       "new email",
+			Code:       200,
+			Users:      1,
+			UsersLog:   1,
+			OutboxEvts: []string{string(entities.EventUserRegistered)},
+		},
+		{
+			Name:      "email already registered",
+			Existing:  "budi@mail.com",
+			Code:      409,
+			ErrorCode: "email_registered",
+			Users:     1,
+			UsersLog:  1,
+			// only the event of the first register
+			OutboxEvts: []string{string(entities.EventUserRegistered)},
+		},
+		{
+			Name:   "users log fail roll the users back",
+			Errors: map[string]error{"UsersLogStore": Failure},
+			Err:    Failure,
+		},
+		{
+			Name:   "outbox fail roll the users back",
+			Errors: map[string]error{"OutboxStore": Failure},
+			Err:    Failure,
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			u, UsersRepository, _ := usersTestUsecases()
+			if Test.Existing != "" {
+				usersTestRegister(t, u, Test.Existing, "secret")
+			}
+			for Method, err := range Test.Errors {
+				UsersRepository.Errors[Method] = err
+			}
+
+			Response, err := u.Register(context.Background(), &entities.RegisterRequest{
+				Email:    "budi@mail.com",
+				FullName: "Budi",
+				Gender:   entities.Male,
+				Role:     entities.Customer,
+				Password: "secret",
+			})
+			if Test.ErrorCode != "" {
+				if apperr.Status(err) != Test.Code || apperr.CodeOf(err) != Test.ErrorCode {
+					t.Fatalf("err = %v (%d), want %s (%d)", err, apperr.Status(err), Test.ErrorCode, Test.Code)
+				}
+			} else if err != Test.Err {
+				t.Fatalf("err = %v, want %v", err, Test.Err)
+			} else if Test.Err == nil && Response.Code != Test.Code {
+				t.Fatalf("code = %d, want %d (%s)", Response.Code, Test.Code, Response.Message)
+			}
+
+			if len(UsersRepository.Users) != Test.Users {
+				t.Errorf("users = %d, want %d", len(UsersRepository.Users), Test.Users)
+			}
+			if len(UsersRepository.UsersLog) != Test.UsersLog {
+				t.Errorf("users log = %d, want %d", len(UsersRepository.UsersLog), Test.UsersLog)
+			}
+			if Events := memory.OutboxEvents(UsersRepository.Outbox); !reflect.DeepEqual(Events, Test.OutboxEvts) {
+				t.Errorf("outbox = %v, want %v", Events, Test.OutboxEvts)
+			}
+
+			for _, Users := range UsersRepository.Users {
+				if bcrypt.CompareHashAndPassword([]byte(Users.Password), []byte("secret")) != nil {
+					t.Errorf("password of %s is not the bcrypt hash of the password", Users.Email)
+				}
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	Tests := []struct {
+		Name      string
+		Email     string
+		Password  string
+		Code      int
+		ErrorCode string
+	}{
+		{Name: "right password", Email: "budi@mail.com", Password: "secret", Code: 200},
+		{Name: "wrong password", Email: "budi@mail.com", Password: "wrong", Code: 403, ErrorCode: "wrong_password"},
+		{Name: "unknown email", Email: "ani@mail.com", Password: "secret", Code: 404, ErrorCode: "user_not_found"},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			u, _, TokensRepository := usersTestUsecases()
+			usersTestRegister(t, u, "budi@mail.com", "secret")
+
+			Response, err := u.Login(context.Background(), &entities.LoginRequest{
+				Email:    Test.Email,
+				Password: Test.Password,
+			})
+			if apperr.Status(err) != Test.Code || apperr.CodeOf(err) != Test.ErrorCode {
+				t.Fatalf("err = %v (%d), want %s (%d)", err, apperr.Status(err), Test.ErrorCode, Test.Code)
+			}
+			if Test.Code != 200 {
+				if len(TokensRepository.RefreshTokens) != 0 {
+					t.Errorf("refresh token stored for a failed login")
+				}
+				return
+			}
+
+			Tokens := Response.Data.(*entities.TokensResponse)
+			if Tokens.AccessToken == "" || Tokens.TokenType != "Bearer" {
+				t.Errorf("tokens = %+v", Tokens)
+			}
+			if TokensRepository.RefreshTokens[Tokens.RefreshToken] == nil {
+				t.Errorf("refresh token is not stored")
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	Tests := []struct {
+		Name      string
+		Token     func(Login *entities.TokensResponse) string
+		Reuse     bool
+		ErrorCode string
+	}{
+		{Name: "first use rotate the token"},
+		{Name: "unknown token", Token: func(*entities.TokensResponse) string { return "unknown" }, ErrorCode: "refresh_token_invalid"},
+		{Name: "second use revoke the session", Reuse: true, ErrorCode: "refresh_token_reused"},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			u, _, TokensRepository := usersTestUsecases()
+			usersTestRegister(t, u, "budi@mail.com", "secret")
+			Login, err := u.Login(context.Background(), &entities.LoginRequest{Email: "budi@mail.com", Password: "secret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			Tokens := Login.Data.(*entities.TokensResponse)
+
+			Token := Tokens.RefreshToken
+			if Test.Token != nil {
+				Token = Test.Token(Tokens)
+			}
+			if Test.Reuse {
+				if _, err = u.Refresh(context.Background(), &entities.RefreshRequest{RefreshToken: Token}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = u.Refresh(context.Background(), &entities.RefreshRequest{RefreshToken: Token})
+			if apperr.CodeOf(err) != Test.ErrorCode {
+				t.Fatalf("err = %v, want %s", err, Test.ErrorCode)
+			}
+			if Test.ErrorCode != "" && apperr.Status(err) != 401 {
+				t.Fatalf("status = %d, want 401", apperr.Status(err))
+			}
+			if Test.Reuse && len(TokensRepository.Sessions) != 0 {
+				t.Errorf("session is still active after the refresh token was reused")
+			}
+		})
+	}
+}
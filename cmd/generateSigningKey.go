@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// SigningKeyAlgorithm string
+var SigningKeyAlgorithm string
+
+// SigningKeyOut string
+var SigningKeyOut string
+
+// generateSigningKeyCmd add command
+var generateSigningKeyCmd = &cobra.Command{
+	Use:   "generateSigningKey",
+	Short: "Generate a private key for the users tokens",
+	Long: `Write a new PKCS8 PEM private key to --out. Add it to auth.signing.keys
+	with a new kid, then switch auth.signing.current_kid to it once every
+	service had time to fetch the JWKS.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var PrivateKey crypto.Signer
+		var err error
+
+		switch SigningKeyAlgorithm {
+		case "EdDSA":
+			_, PrivateKey, err = ed25519.GenerateKey(rand.Reader)
+		case "RS256":
+			PrivateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		default:
+			log.Fatalf("unsupported algorithm %s, use RS256 or EdDSA", SigningKeyAlgorithm)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		DER, err := x509.MarshalPKCS8PrivateKey(PrivateKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		PEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: DER})
+		if err = ioutil.WriteFile(SigningKeyOut, PEM, 0600); err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println("Signing key written to", SigningKeyOut)
+	},
+}
+
+func init() {
+	generateSigningKeyCmd.Flags().StringVar(&SigningKeyAlgorithm, "algorithm", "EdDSA", "RS256 or EdDSA")
+	generateSigningKeyCmd.Flags().StringVar(&SigningKeyOut, "out", "signing-key.pem", "file of the private key")
+	rootCmd.AddCommand(generateSigningKeyCmd)
+}
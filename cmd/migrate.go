@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// MigrateService string
+var MigrateService string
+
+// MigrateDir string
+var MigrateDir string
+
+// MigrateDSN string
+var MigrateDSN string
+
+// MigrateTo int64
+var MigrateTo int64
+
+// MigrateSeedFile string
+var MigrateSeedFile string
+
+// migrateCmd add command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Versioned database migrations of one service",
+	Long: `Apply, roll back and create the goose migrations of one service. The
+	migrations of a service are in database/migrations/<service> and run on the
+	database of <service>Services.database.dsn, e.g.
+
+	go run main.go migrate up --service orders
+	go run main.go migrate create add_orders_notes --service orders
+
+	The sample data of the local development is not a migration, it is in
+	database/seeds/<service>.sql and applied by migrate seed`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		switch MigrateService {
+		case "users", "products", "orders":
+		default:
+			return fmt.Errorf("--service must be users, products or orders")
+		}
+
+		if MigrateDir == "" {
+			MigrateDir = filepath.Join("database", "migrations", MigrateService)
+		}
+		goose.SetSequential(true)
+		return goose.SetDialect("postgres")
+	},
+}
+
+// migrateUpCmd add command
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply every pending migration (or up to --to)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateRun(func(DB *sql.DB) error {
+			if MigrateTo > 0 {
+				return goose.UpTo(DB, MigrateDir, MigrateTo)
+			}
+			return goose.Up(DB, MigrateDir)
+		})
+	},
+}
+
+// migrateDownCmd add command
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the last migration (or down to --to)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateRun(func(DB *sql.DB) error {
+			if cmd.Flags().Changed("to") {
+				return goose.DownTo(DB, MigrateDir, MigrateTo)
+			}
+			return goose.Down(DB, MigrateDir)
+		})
+	},
+}
+
+// migrateStatusCmd add command
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the applied and pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateRun(func(DB *sql.DB) error {
+			return goose.Status(DB, MigrateDir)
+		})
+	},
+}
+
+// migrateCreateCmd add command
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name> [sql|go]",
+	Short: "Create the next migration file of the service",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Type := "sql"
+		if len(args) == 2 {
+			Type = args[1]
+		}
+		// the database is not needed to create a file
+		return goose.Create(nil, MigrateDir, args[0], Type)
+	},
+}
+
+// migrateSeedCmd add command
+var migrateSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Apply the sample data of the local development",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		SeedFile := MigrateSeedFile
+		if SeedFile == "" {
+			SeedFile = filepath.Join("database", "seeds", MigrateService+".sql")
+		}
+
+		Seed, err := ioutil.ReadFile(SeedFile)
+		if os.IsNotExist(err) && MigrateSeedFile == "" {
+			log.WithFields(log.Fields{
+				"event":   "no seed for service",
+				"service": MigrateService,
+			}).Info(SeedFile)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return migrateRun(func(DB *sql.DB) error {
+			Tx, err := DB.Begin()
+			if err != nil {
+				return err
+			}
+			defer Tx.Rollback()
+
+			if _, err = Tx.Exec(string(Seed)); err != nil {
+				return err
+			}
+			return Tx.Commit()
+		})
+	},
+}
+
+// migrateRun func open the database of the service and run Migrate on it
+func migrateRun(Migrate func(DB *sql.DB) error) (err error) {
+	DSN := MigrateDSN
+	if DSN == "" {
+		DSN = viper.GetString(MigrateService + "Services.database.dsn")
+	}
+	if DSN == "" {
+		return fmt.Errorf("%sServices.database.dsn is not configured", MigrateService)
+	}
+
+	DB, err := sql.Open("postgres", DSN)
+	if err != nil {
+		return
+	}
+	defer DB.Close()
+
+	if err = Migrate(DB); err != nil {
+		log.WithFields(log.Fields{
+			"event":   "error when migrate database",
+			"service": MigrateService,
+		}).Error(err)
+	}
+	return
+}
+
+func init() {
+	migrateCmd.PersistentFlags().StringVar(&MigrateService, "service", "", "service to migrate: users, products or orders")
+	migrateCmd.PersistentFlags().StringVar(&MigrateDir, "dir", "", "migrations directory (default database/migrations/<service>)")
+	migrateCmd.PersistentFlags().StringVar(&MigrateDSN, "dsn", "", "database to migrate (default <service>Services.database.dsn)")
+	migrateUpCmd.Flags().Int64Var(&MigrateTo, "to", 0, "apply the migrations up to this version")
+	migrateDownCmd.Flags().Int64Var(&MigrateTo, "to", 0, "roll back the migrations down to this version")
+	migrateSeedCmd.Flags().StringVar(&MigrateSeedFile, "file", "", "seed to apply (default database/seeds/<service>.sql)")
+
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateCreateCmd, migrateSeedCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	OrdersContainer "github.com/mrdhira/warpin-test/api/Orders/container"
+	OrdersRoutes "github.com/mrdhira/warpin-test/api/Orders/deliveries/http"
+	ProductsContainer "github.com/mrdhira/warpin-test/api/Products/container"
+	ProductsRoutes "github.com/mrdhira/warpin-test/api/Products/deliveries/http"
+	UsersContainer "github.com/mrdhira/warpin-test/api/Users/container"
+	UsersRoutes "github.com/mrdhira/warpin-test/api/Users/deliveries/http"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+	"github.com/mrdhira/warpin-test/pkg/server"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ServeAllAddr string
+var ServeAllAddr string
+
+// ServeAllUsersAddr string
+var ServeAllUsersAddr string
+
+// ServeAllProductsAddr string
+var ServeAllProductsAddr string
+
+// ServeAllOrdersAddr string
+var ServeAllOrdersAddr string
+
+// ServeAllInProcess bool
+var ServeAllInProcess bool
+
+// serveAllCmd add command
+var serveAllCmd = &cobra.Command{
+	Use:   "serveAll",
+	Short: "Serve users, products and orders services in one process",
+	Long: `Serve the three services in one process for local development, every
+	service on its own port (--users-addr, --products-addr, --orders-addr) or all
+	of them on --addr under their own paths (/users, /products, /orders). The
+	services call each other in process unless --in-process=false, e.g.
+
+	go run main.go serveAll
+	go run main.go serveAll --addr 0.0.0.0:8000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Configs := map[string]server.Config{
+			"users":    server.LoadConfig("users", "0.0.0.0:8001"),
+			"products": server.LoadConfig("products", "0.0.0.0:8002"),
+			"orders":   server.LoadConfig("orders", "0.0.0.0:8003"),
+		}
+		for Service, Addr := range map[string]string{
+			"users":    ServeAllUsersAddr,
+			"products": ServeAllProductsAddr,
+			"orders":   ServeAllOrdersAddr,
+		} {
+			Config := Configs[Service]
+			if ServeAllAddr != "" {
+				Config.Addr = ServeAllAddr
+			} else if Addr != "" {
+				Config.Addr = Addr
+			}
+			Configs[Service] = Config
+		}
+
+		// every service is here, the clients between them and the JWKS of
+		// users services never go to the urls of docker-compose
+		for Service, Config := range Configs {
+			viper.Set("services."+Service+".url", serveAllURL(Config))
+		}
+
+		Users, err := UsersContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build users services",
+			}).Fatal(err)
+		}
+		Products, err := ProductsContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build products services",
+			}).Fatal(err)
+		}
+		Orders, err := OrdersContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build orders services",
+			}).Fatal(err)
+		}
+
+		UsersRoute := &UsersRoutes.Route{
+			Health:       Users.Health,
+			Verifier:     Users.Verifier,
+			Revocations:  Users.Revocations,
+			UsersUsecase: Users.UsersUsecase,
+		}
+		ProductsRoute := &ProductsRoutes.Route{
+			Health:          Products.Health,
+			Verifier:        Products.Verifier,
+			Revocations:     Products.Revocations,
+			ProductsUsecase: Products.ProductsUsecase,
+		}
+		OrdersRoute := &OrdersRoutes.Route{
+			Health:             Orders.Health,
+			Verifier:           Orders.Verifier,
+			Revocations:        Orders.Revocations,
+			OrdersUsecase:      Orders.OrdersUsecase,
+			IdempotencyUsecase: Orders.IdempotencyUsecase,
+		}
+		Routers := map[string]http.Handler{
+			"users":    UsersRoute.Init(),
+			"products": ProductsRoute.Init(),
+			"orders":   OrdersRoute.Init(),
+		}
+
+		Servers := []*server.Server{}
+		if ServeAllAddr != "" {
+			Router := http.NewServeMux()
+			Router.Handle("/.well-known/", Routers["users"])
+			Router.Handle("/users/", Routers["users"])
+			Router.Handle("/products/", Routers["products"])
+			Router.Handle("/orders/", Routers["orders"])
+
+			// the process is ready when the three services are
+			Health := health.New("all").
+				Add("users", Users.Health.Check).
+				Add("products", Products.Health.Check).
+				Add("orders", Orders.Health.Check)
+			Router.HandleFunc("/healthz", Health.Liveness)
+			Router.HandleFunc("/readyz", Health.Readiness)
+			Router.Handle("/metrics", metrics.Handler())
+
+			// the server of users services with the client certificate paths
+			// of the three services
+			Config := Configs["users"]
+			Config.TLS.ClientAuthPaths = append(append(append([]string{},
+				Configs["users"].TLS.ClientAuthPaths...),
+				Configs["products"].TLS.ClientAuthPaths...),
+				Configs["orders"].TLS.ClientAuthPaths...)
+
+			Servers = append(Servers, serveAllServer(Config, Router))
+		} else {
+			for _, Service := range []string{"users", "products", "orders"} {
+				Servers = append(Servers, serveAllServer(Configs[Service], Routers[Service]))
+			}
+		}
+
+		if ServeAllInProcess {
+			for Service, Router := range Routers {
+				serviceclient.ServeInProcess(Service, Router)
+			}
+		}
+
+		// until SIGTERM or SIGINT, SIGHUP reload the certificates
+		server.Run(Servers...)
+
+		// the pools are closed once no request use them anymore
+		Orders.Close()
+		Products.Close()
+		Users.Close()
+		fmt.Println("All Services Closed")
+	},
+}
+
+// serveAllServer func
+func serveAllServer(Config server.Config, Handler http.Handler) *server.Server {
+	Server, err := server.New(Config, Handler)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when build http server",
+			"addr":  Config.Addr,
+		}).Fatal(err)
+	}
+	return Server
+}
+
+// serveAllURL func, the url to reach the server of Config from this host
+func serveAllURL(Config server.Config) string {
+	Scheme := "http://"
+	if Config.TLS.Enabled() {
+		Scheme = "https://"
+	}
+
+	Host, Port, err := net.SplitHostPort(Config.Addr)
+	if err != nil {
+		return Scheme + Config.Addr
+	}
+	if Host == "" || Host == "0.0.0.0" || Host == "::" {
+		Host = "127.0.0.1"
+	}
+	return Scheme + net.JoinHostPort(Host, Port)
+}
+
+func init() {
+	serveAllCmd.Flags().StringVar(&ServeAllAddr, "addr", "", "serve every service on this address under its own paths")
+	serveAllCmd.Flags().StringVar(&ServeAllUsersAddr, "users-addr", "", "address of users services (default usersServices.server.address)")
+	serveAllCmd.Flags().StringVar(&ServeAllProductsAddr, "products-addr", "", "address of products services (default productsServices.server.address)")
+	serveAllCmd.Flags().StringVar(&ServeAllOrdersAddr, "orders-addr", "", "address of orders services (default ordersServices.server.address)")
+	serveAllCmd.Flags().BoolVar(&ServeAllInProcess, "in-process", true, "call the other services in process instead of over http")
+	rootCmd.AddCommand(serveAllCmd)
+}
@@ -1,16 +1,12 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
+	UsersContainer "github.com/mrdhira/warpin-test/api/Users/container"
 	Routes "github.com/mrdhira/warpin-test/api/Users/deliveries/http"
+	"github.com/mrdhira/warpin-test/pkg/server"
 	"github.com/spf13/cobra"
 )
 
@@ -25,31 +21,28 @@ var serveHTTPUsersCmd = &cobra.Command{
 	This application is a tool to generate the needed files
 	to quickly create a Cobra application.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		Route := new(Routes.Route)
-
-		Router := Route.Init()
+		Container, err := UsersContainer.New()
+		if err != nil {
+			log.Fatalf("Error on build users services: %v", err)
+		}
 
-		var GracefulStop = make(chan os.Signal)
-		signal.Notify(GracefulStop, syscall.SIGTERM)
-		signal.Notify(GracefulStop, syscall.SIGINT)
+		Route := &Routes.Route{
+			Health:       Container.Health,
+			Verifier:     Container.Verifier,
+			Revocations:  Container.Revocations,
+			UsersUsecase: Container.UsersUsecase,
+		}
 
-		HTTPServer := &http.Server{
-			Handler:      Router,
-			Addr:         "0.0.0.0:8001",
-			WriteTimeout: time.Second * 15,
-			ReadTimeout:  time.Second * 15,
+		Server, err := server.New(server.LoadConfig("users", "0.0.0.0:8001"), Route.Init())
+		if err != nil {
+			log.Fatalf("Error on build users http server: %v", err)
 		}
 
-		go func() {
-			if err := HTTPServer.ListenAndServe(); err != http.ErrServerClosed {
-				log.Fatalf("Error on listen and serve: %v", err)
-			}
-		}()
+		// until SIGTERM or SIGINT, the running requests get the shutdown grace
+		server.Run(Server)
 
-		<-GracefulStop
-		if err := HTTPServer.Shutdown(context.TODO()); err != nil {
-			panic(err)
-		}
+		// the pools are closed once no request use them anymore
+		Container.Close()
 		fmt.Println("User Services Closed")
 	},
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	OrdersContainer "github.com/mrdhira/warpin-test/api/Orders/container"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// OrdersExpiryOnce bool
+var OrdersExpiryOnce bool
+
+// serveOrdersExpiryCmd add command
+var serveOrdersExpiryCmd = &cobra.Command{
+	Use:   "serveOrdersExpiry",
+	Short: "Expire the orders that stay pending too long",
+	Long: `Periodically move the orders that are still pending after
+	ordersServices.expiry.pending_for to EXPIRED as the system actor, the
+	stock of their items is given back to products services. Use --once to
+	run a single pass.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Container, err := OrdersContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build orders services",
+			}).Fatal(err)
+		}
+		defer Container.Close()
+		Orders := Container.OrdersUsecase
+
+		PendingFor := viper.GetDuration("ordersServices.expiry.pending_for")
+		if PendingFor == 0 {
+			PendingFor = time.Hour * 24
+		}
+		Interval := viper.GetDuration("ordersServices.expiry.interval")
+		if Interval == 0 {
+			Interval = time.Minute * 5
+		}
+		Batch := viper.GetInt("ordersServices.expiry.batch")
+		if Batch == 0 {
+			Batch = 100
+		}
+
+		Expire := func() {
+			Expired, err := Orders.OrdersExpire(context.Background(), PendingFor, Batch)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"event": "error when expire orders",
+				}).Error(err)
+				return
+			}
+			log.WithFields(log.Fields{
+				"event":   "orders expiry done",
+				"expired": Expired,
+			}).Info("orders expiry")
+		}
+
+		Expire()
+		if OrdersExpiryOnce {
+			return
+		}
+
+		var GracefulStop = make(chan os.Signal, 1)
+		signal.Notify(GracefulStop, syscall.SIGTERM, syscall.SIGINT)
+
+		Ticker := time.NewTicker(Interval)
+		defer Ticker.Stop()
+
+		for {
+			select {
+			case <-Ticker.C:
+				Expire()
+			case <-GracefulStop:
+				fmt.Println("Orders Expiry Closed")
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	serveOrdersExpiryCmd.Flags().BoolVar(&OrdersExpiryOnce, "once", false, "run a single expiry pass and exit")
+	rootCmd.AddCommand(serveOrdersExpiryCmd)
+}
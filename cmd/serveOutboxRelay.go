@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	OrdersDatabase "github.com/mrdhira/warpin-test/api/Orders/infrastructures/database"
+	ProductsDatabase "github.com/mrdhira/warpin-test/api/Products/infrastructures/database"
+	UsersDatabase "github.com/mrdhira/warpin-test/api/Users/infrastructures/database"
+	"github.com/mrdhira/warpin-test/pkg/outbox"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// OutboxRelayService string
+var OutboxRelayService string
+
+// OutboxRelayOnce bool
+var OutboxRelayOnce bool
+
+// serveOutboxRelayCmd add command
+var serveOutboxRelayCmd = &cobra.Command{
+	Use:   "serveOutboxRelay",
+	Short: "Publish the domain events of the outbox tables to Redis Streams",
+	Long: `Periodically publish the unpublished rows of the outbox table of every
+	service to its Redis Stream (<service>Services.outbox.stream). Use --service
+	to relay a single service and --once to run a single pass.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Every service publish to the same redis so the streams can be read by all of them
+		Redis := redis.NewClient(&redis.Options{
+			Addr:     viper.GetString("outbox.redis.address"),
+			Password: viper.GetString("outbox.redis.password"),
+			DB:       viper.GetInt("outbox.redis.db"),
+		})
+		defer Redis.Close()
+
+		Relays := []*outbox.Relay{}
+		if OutboxRelayService == "" || OutboxRelayService == "users" {
+			PG, err := UsersDatabase.NewPostgresConnection()
+			if err != nil {
+				log.WithFields(log.Fields{"event": "error when open users database"}).Fatal(err)
+			}
+			defer PG.Close()
+			Relays = append(Relays, outboxRelay("users", PG, Redis))
+		}
+		if OutboxRelayService == "" || OutboxRelayService == "products" {
+			PG, err := ProductsDatabase.NewPostgresConnection()
+			if err != nil {
+				log.WithFields(log.Fields{"event": "error when open products database"}).Fatal(err)
+			}
+			defer PG.Close()
+			Relays = append(Relays, outboxRelay("products", PG, Redis))
+		}
+		if OutboxRelayService == "" || OutboxRelayService == "orders" {
+			PG, err := OrdersDatabase.NewPostgresConnection()
+			if err != nil {
+				log.WithFields(log.Fields{"event": "error when open orders database"}).Fatal(err)
+			}
+			defer PG.Close()
+			Relays = append(Relays, outboxRelay("orders", PG, Redis))
+		}
+
+		if len(Relays) == 0 {
+			log.WithFields(log.Fields{
+				"event":   "unknown outbox relay service",
+				"service": OutboxRelayService,
+			}).Fatal("service must be users, products or orders")
+		}
+
+		Interval := viper.GetDuration("outbox.relay_interval")
+		if Interval == 0 {
+			Interval = time.Second
+		}
+		Batch := viper.GetInt("outbox.relay_batch")
+		if Batch == 0 {
+			Batch = 100
+		}
+
+		Publish := func() {
+			for _, Relay := range Relays {
+				// Drain the backlog before waiting for the next tick
+				for {
+					Published, err := Relay.Publish(context.Background(), Batch)
+					if err != nil {
+						log.WithFields(log.Fields{
+							"event":   "error when relay outbox",
+							"service": Relay.Service,
+						}).Error(err)
+						break
+					}
+					if Published > 0 {
+						log.WithFields(log.Fields{
+							"event":     "outbox relay done",
+							"service":   Relay.Service,
+							"published": Published,
+						}).Info("outbox relay")
+					}
+					if Published < Batch {
+						break
+					}
+				}
+			}
+		}
+
+		Publish()
+		if OutboxRelayOnce {
+			return
+		}
+
+		var GracefulStop = make(chan os.Signal, 1)
+		signal.Notify(GracefulStop, syscall.SIGTERM, syscall.SIGINT)
+
+		Ticker := time.NewTicker(Interval)
+		defer Ticker.Stop()
+
+		for {
+			select {
+			case <-Ticker.C:
+				Publish()
+			case <-GracefulStop:
+				fmt.Println("Outbox Relay Closed")
+				return
+			}
+		}
+	},
+}
+
+// outboxRelay func build the relay of one service from <service>Services.outbox
+func outboxRelay(Service string, PG outbox.IPostgresConnection, Redis *redis.Client) *outbox.Relay {
+	Stream := viper.GetString(Service + "Services.outbox.stream")
+	if Stream == "" {
+		Stream = "events:" + Service
+	}
+
+	return &outbox.Relay{
+		Service: Service,
+		PG:      PG,
+		Redis:   Redis,
+		Stream:  Stream,
+		MaxLen:  viper.GetInt64(Service + "Services.outbox.max_len"),
+	}
+}
+
+func init() {
+	serveOutboxRelayCmd.Flags().StringVar(&OutboxRelayService, "service", "", "relay a single service: users, products or orders (default all)")
+	serveOutboxRelayCmd.Flags().BoolVar(&OutboxRelayOnce, "once", false, "run a single relay pass and exit")
+	rootCmd.AddCommand(serveOutboxRelayCmd)
+}
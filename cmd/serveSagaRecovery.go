@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	OrdersContainer "github.com/mrdhira/warpin-test/api/Orders/container"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SagaRecoveryOnce bool
+var SagaRecoveryOnce bool
+
+// serveSagaRecoveryCmd add command
+var serveSagaRecoveryCmd = &cobra.Command{
+	Use:   "serveSagaRecovery",
+	Short: "Finish or roll back orders sagas left half done after a crash",
+	Long: `Periodically look for orders sagas that are still running or compensating
+	but did not move for ordersServices.saga.stale_after, and compensate every
+	products stock change they made. Use --once to run a single pass.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Container, err := OrdersContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build orders services",
+			}).Fatal(err)
+		}
+		defer Container.Close()
+		Sagas := Container.SagasUsecase
+
+		Interval := viper.GetDuration("ordersServices.saga.recovery_interval")
+		if Interval == 0 {
+			Interval = time.Minute
+		}
+		StaleAfter := viper.GetDuration("ordersServices.saga.stale_after")
+		if StaleAfter == 0 {
+			StaleAfter = time.Minute * 5
+		}
+		Batch := viper.GetInt("ordersServices.saga.recovery_batch")
+		if Batch == 0 {
+			Batch = 100
+		}
+
+		Recover := func() {
+			Recovered, err := Sagas.SagasRecover(context.Background(), StaleAfter, Batch)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"event": "error when recover sagas",
+				}).Error(err)
+				return
+			}
+			log.WithFields(log.Fields{
+				"event":     "sagas recovery done",
+				"recovered": Recovered,
+			}).Info("sagas recovery")
+		}
+
+		Recover()
+		if SagaRecoveryOnce {
+			return
+		}
+
+		var GracefulStop = make(chan os.Signal, 1)
+		signal.Notify(GracefulStop, syscall.SIGTERM, syscall.SIGINT)
+
+		Ticker := time.NewTicker(Interval)
+		defer Ticker.Stop()
+
+		for {
+			select {
+			case <-Ticker.C:
+				Recover()
+			case <-GracefulStop:
+				fmt.Println("Saga Recovery Closed")
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	serveSagaRecoveryCmd.Flags().BoolVar(&SagaRecoveryOnce, "once", false, "run a single recovery pass and exit")
+	rootCmd.AddCommand(serveSagaRecoveryCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	ProductsContainer "github.com/mrdhira/warpin-test/api/Products/container"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// StockExpiryOnce bool
+var StockExpiryOnce bool
+
+// serveStockExpiryCmd add command
+var serveStockExpiryCmd = &cobra.Command{
+	Use:   "serveStockExpiry",
+	Short: "Give back the stock of reservations that were never committed",
+	Long: `Periodically release products stock reservations that are still reserved
+	after their TTL (productsServices.reservation.ttl by default). Use --once to
+	run a single pass.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Container, err := ProductsContainer.New()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "error when build products services",
+			}).Fatal(err)
+		}
+		defer Container.Close()
+		Products := Container.ProductsUsecase
+
+		Interval := viper.GetDuration("productsServices.reservation.expiry_interval")
+		if Interval == 0 {
+			Interval = time.Minute
+		}
+		Batch := viper.GetInt("productsServices.reservation.expiry_batch")
+		if Batch == 0 {
+			Batch = 100
+		}
+
+		Expire := func() {
+			Expired, err := Products.ExpireStock(context.Background(), Batch)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"event": "error when expire stock reservations",
+				}).Error(err)
+				return
+			}
+			log.WithFields(log.Fields{
+				"event":   "stock reservations expiry done",
+				"expired": Expired,
+			}).Info("stock reservations expiry")
+		}
+
+		Expire()
+		if StockExpiryOnce {
+			return
+		}
+
+		var GracefulStop = make(chan os.Signal, 1)
+		signal.Notify(GracefulStop, syscall.SIGTERM, syscall.SIGINT)
+
+		Ticker := time.NewTicker(Interval)
+		defer Ticker.Stop()
+
+		for {
+			select {
+			case <-Ticker.C:
+				Expire()
+			case <-GracefulStop:
+				fmt.Println("Stock Expiry Closed")
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	serveStockExpiryCmd.Flags().BoolVar(&StockExpiryOnce, "once", false, "run a single expiry pass and exit")
+	rootCmd.AddCommand(serveStockExpiryCmd)
+}
@@ -0,0 +1,101 @@
+-- Schema of orders_db as it was created by database/init.sql, IF NOT EXISTS keep
+-- it a no-op on the databases that were created by it
+-- +goose Up
+CREATE TABLE IF NOT EXISTS orders (
+  id SERIAL PRIMARY KEY,
+  user_id int,
+  total_price NUMERIC(19, 2),
+  status int,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS orders_log (
+  id SERIAL PRIMARY KEY,
+  user_id int,
+  order_id int,
+  total_price NUMERIC(19, 2),
+  status int,
+  event VARCHAR(255),
+  admin_id int,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS orders_items (
+  id SERIAL PRIMARY KEY,
+  order_id int REFERENCES orders (id),
+  product_id int,
+  reservation_id VARCHAR(64),
+  product_name VARCHAR(255),
+  price NUMERIC(19, 2),
+  qty int,
+  total_price NUMERIC(19, 2),
+  status int,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS orders_items_order_id_idx ON orders_items (order_id);
+CREATE INDEX IF NOT EXISTS orders_items_product_id_idx ON orders_items (product_id);
+
+CREATE TABLE IF NOT EXISTS orders_items_log (
+  id SERIAL PRIMARY KEY,
+  order_item_id int,
+  order_id int,
+  product_id int,
+  product_name VARCHAR(255),
+  price NUMERIC(19, 2),
+  qty int,
+  total_price NUMERIC(19, 2),
+  status int,
+  event VARCHAR(255),
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS sagas (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255),
+  order_id int,
+  status int,
+  error TEXT,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS sagas_status_updated_at_idx ON sagas (status, updated_at);
+
+CREATE TABLE IF NOT EXISTS sagas_steps (
+  id SERIAL PRIMARY KEY,
+  saga_id int REFERENCES sagas (id),
+  sequence int,
+  action VARCHAR(255),
+  payload TEXT,
+  status int,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS sagas_steps_saga_id_idx ON sagas_steps (saga_id);
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id SERIAL PRIMARY KEY,
+  aggregate VARCHAR(32),
+  aggregate_id int,
+  event VARCHAR(64),
+  payload TEXT,
+  published_at timestamp,
+  created_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS sagas_steps;
+DROP TABLE IF EXISTS sagas;
+DROP TABLE IF EXISTS orders_items_log;
+DROP TABLE IF EXISTS orders_items;
+DROP TABLE IF EXISTS orders_log;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,23 @@
+-- Prices are exact decimals, see pkg/money. The totals are computed again from
+-- the lines so the float drift is not kept
+-- +goose Up
+ALTER TABLE orders_items ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+ALTER TABLE orders_items ALTER COLUMN total_price TYPE NUMERIC(19, 2) USING round(total_price::numeric, 2);
+ALTER TABLE orders_items_log ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+ALTER TABLE orders_items_log ALTER COLUMN total_price TYPE NUMERIC(19, 2) USING round(total_price::numeric, 2);
+ALTER TABLE orders ALTER COLUMN total_price TYPE NUMERIC(19, 2) USING round(total_price::numeric, 2);
+ALTER TABLE orders_log ALTER COLUMN total_price TYPE NUMERIC(19, 2) USING round(total_price::numeric, 2);
+
+UPDATE orders_items SET total_price = price * qty;
+UPDATE orders SET total_price = COALESCE((
+  SELECT SUM(orders_items.total_price) FROM orders_items
+  WHERE orders_items.order_id = orders.id AND orders_items.status <> 4
+), 0);
+
+-- +goose Down
+ALTER TABLE orders_items ALTER COLUMN price TYPE float USING price::float;
+ALTER TABLE orders_items ALTER COLUMN total_price TYPE float USING total_price::float;
+ALTER TABLE orders_items_log ALTER COLUMN price TYPE float USING price::float;
+ALTER TABLE orders_items_log ALTER COLUMN total_price TYPE float USING total_price::float;
+ALTER TABLE orders ALTER COLUMN total_price TYPE float USING total_price::float;
+ALTER TABLE orders_log ALTER COLUMN total_price TYPE float USING total_price::float;
@@ -0,0 +1,68 @@
+-- Orders created before orders_items kept their only line in the product_id,
+-- product_name, price and qty columns of orders. The line is copied into
+-- orders_items and the columns are dropped. Their stock was taken before the
+-- reservations existed, the reservation id of the line is legacy-<order id> and
+-- the first stock step on it adopt the taken stock in products services (see
+-- entities.LegacyReservationPrefix). A database created by 00001 has none of
+-- these columns and is left as it is
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'product_id'
+  ) THEN
+    EXECUTE $backfill$
+      INSERT INTO orders_items (order_id, product_id, reservation_id, product_name, price, qty, total_price, status, created_at, updated_at)
+      SELECT orders.id, orders.product_id, 'legacy-' || orders.id, orders.product_name,
+        round(orders.price::numeric, 2), orders.qty, round(orders.price::numeric, 2) * orders.qty,
+        CASE orders.status WHEN 1 THEN 1 WHEN 3 THEN 3 WHEN 4 THEN 4 WHEN 9 THEN 4 ELSE 2 END,
+        orders.created_at, orders.updated_at
+      FROM orders
+      WHERE orders.product_id IS NOT NULL
+        AND NOT EXISTS (SELECT 1 FROM orders_items WHERE orders_items.order_id = orders.id)
+    $backfill$;
+
+    ALTER TABLE orders DROP COLUMN product_id;
+    ALTER TABLE orders DROP COLUMN product_name;
+    ALTER TABLE orders DROP COLUMN price;
+    ALTER TABLE orders DROP COLUMN qty;
+  END IF;
+
+  -- the old lines stay in the log, they are not written anymore
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'orders_log' AND column_name = 'product_id'
+  ) THEN
+    ALTER TABLE orders_log ALTER COLUMN product_id DROP NOT NULL;
+    ALTER TABLE orders_log ALTER COLUMN product_name DROP NOT NULL;
+    ALTER TABLE orders_log ALTER COLUMN price DROP NOT NULL;
+    ALTER TABLE orders_log ALTER COLUMN qty DROP NOT NULL;
+  END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- 00002 summed the lines before they were copied
+UPDATE orders SET total_price = COALESCE((
+  SELECT SUM(orders_items.total_price) FROM orders_items
+  WHERE orders_items.order_id = orders.id AND orders_items.status <> 4
+), 0);
+
+-- +goose Down
+-- The columns come back with the first line of every order, an order with more
+-- lines can not be written in them
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS product_id int;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS product_name VARCHAR(255);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS price NUMERIC(19, 2);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS qty int;
+
+UPDATE orders SET product_id = line.product_id, product_name = line.product_name, price = line.price, qty = line.qty
+FROM (
+  SELECT DISTINCT ON (order_id) order_id, product_id, product_name, price, qty
+  FROM orders_items ORDER BY order_id, id
+) AS line
+WHERE line.order_id = orders.id;
+
+DELETE FROM orders_items WHERE reservation_id LIKE 'legacy-%';
@@ -0,0 +1,15 @@
+-- The amounts of this database are minor units of one currency, it is pinned
+-- here so changing money.currency can not change what they mean. The service
+-- refuse to start when money.currency is another one (see money.CheckCurrency).
+-- IDR is the currency the amounts were written in so far
+-- +goose Up
+CREATE TABLE IF NOT EXISTS money_currency (
+  id boolean PRIMARY KEY DEFAULT TRUE CHECK (id),
+  currency VARCHAR(3) NOT NULL,
+  created_at timestamp
+);
+
+INSERT INTO money_currency (currency, created_at) VALUES ('IDR', NOW()) ON CONFLICT (id) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS money_currency;
@@ -0,0 +1,55 @@
+-- Schema of products_db as it was created by database/init.sql, IF NOT EXISTS keep
+-- it a no-op on the databases that were created by it
+-- +goose Up
+CREATE TABLE IF NOT EXISTS products (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255),
+  price NUMERIC(19, 2),
+  qty int,
+  status int,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS products_log (
+  id SERIAL PRIMARY KEY,
+  product_id int,
+  user_id int,
+  name VARCHAR(255),
+  price NUMERIC(19, 2),
+  qty int,
+  status int,
+  event VARCHAR(255),
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+  id VARCHAR(64) PRIMARY KEY,
+  product_id int REFERENCES products (id),
+  qty int,
+  status int,
+  expired_at timestamp,
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservations_status_expired_at_idx ON stock_reservations (status, expired_at);
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id SERIAL PRIMARY KEY,
+  aggregate VARCHAR(32),
+  aggregate_id int,
+  event VARCHAR(64),
+  payload TEXT,
+  published_at timestamp,
+  created_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS products_log;
+DROP TABLE IF EXISTS products;
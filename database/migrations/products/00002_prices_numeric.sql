@@ -0,0 +1,8 @@
+-- Prices are exact decimals, see pkg/money
+-- +goose Up
+ALTER TABLE products ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+ALTER TABLE products_log ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+
+-- +goose Down
+ALTER TABLE products ALTER COLUMN price TYPE float USING price::float;
+ALTER TABLE products_log ALTER COLUMN price TYPE float USING price::float;
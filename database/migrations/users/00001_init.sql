@@ -0,0 +1,44 @@
+-- Schema of users_db as it was created by database/init.sql, IF NOT EXISTS keep
+-- it a no-op on the databases that were created by it
+-- +goose Up
+CREATE TABLE IF NOT EXISTS users (
+  id SERIAL PRIMARY KEY,
+  email VARCHAR(255),
+  phone_number VARCHAR(255),
+  full_name VARCHAR(255),
+  gender int,
+  role  VARCHAR(255),
+  password VARCHAR(255),
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS users_log (
+  id SERIAL PRIMARY KEY,
+  user_id int,
+  email VARCHAR(255),
+  phone_number VARCHAR(255),
+  full_name VARCHAR(255),
+  gender int,
+  role  VARCHAR(255),
+  password VARCHAR(255),
+  created_at timestamp,
+  updated_at timestamp
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+  id SERIAL PRIMARY KEY,
+  aggregate VARCHAR(32),
+  aggregate_id int,
+  event VARCHAR(64),
+  payload TEXT,
+  published_at timestamp,
+  created_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS users_log;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,13 @@
+-- Sample products for the local development, applied by
+-- `go run main.go migrate seed --service products` (docker-compose run it).
+-- Never part of the migrations, only an empty table is seeded
+INSERT INTO products ("name", "price", "qty", "status", "created_at", "updated_at")
+SELECT "name", "price", "qty", "status", NOW(), NOW() FROM (VALUES
+('PRODUCT001', 15000, 15, 1),
+('PRODUCT002', 18000, 18, 1),
+('PRODUCT003', 7000, 2, 2),
+('PRODUCT004', 5000, 0, 2),
+('PRODUCT005', 35000, 10, 1),
+('PRODUCT006', 30000, 18, 1)
+) AS sample ("name", "price", "qty", "status")
+WHERE NOT EXISTS (SELECT 1 FROM products);
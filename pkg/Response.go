@@ -12,10 +12,13 @@ func Response(res http.ResponseWriter, HttpStatus int, Data interface{}) {
 	json.NewEncoder(res).Encode(Data)
 }
 
-// JSONResponse struct
+// JSONResponse struct, ErrorCode and Details are set on the errors (see
+// apperr.Response)
 type JSONResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Error   string      `json:"error"`
-	Data    interface{} `json:"data"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Error     string      `json:"error"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	Data      interface{} `json:"data"`
 }
@@ -0,0 +1,234 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+)
+
+// Kind of an error, it choose the HTTP status of the response
+type Kind string
+
+// Kinds
+const (
+	KindBadRequest   Kind = "bad_request"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindUpstream     Kind = "upstream"
+	KindUnavailable  Kind = "unavailable"
+	KindInternal     Kind = "internal"
+)
+
+// Status func, the HTTP status of the errors of Kind
+func (k Kind) Status() int {
+	switch k {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUpstream:
+		return http.StatusBadGateway
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError struct, what is wrong with one field of the request. Code is
+// the validator tag of the rule (e.g. required), Message is written in the
+// locale of the response
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	kind  reflect.Kind
+	param string
+	err   validator.FieldError
+}
+
+// Field func, a FieldError of the Code rule with its Param (e.g. Field("qty",
+// "min", reflect.Int, "1"))
+func Field(Name string, Code string, Kind reflect.Kind, Param string) FieldError {
+	return FieldError{Field: Name, Code: Code, kind: Kind, param: Param}
+}
+
+// Localize func, the FieldError with its message in Locale
+func (f FieldError) Localize(Locale i18n.Locale) FieldError {
+	switch {
+	case f.err != nil:
+		f.Message = i18n.ValidationMessage(Locale, f.err)
+	case f.Message == "":
+		f.Message = i18n.FieldMessage(Locale, f.Field, f.Code, f.kind, f.param)
+	}
+	return f
+}
+
+// Error struct, an error of the domain. Code is stable and machine readable
+// (e.g. order_not_found), the message for the users is the one of Key (Code
+// when it is empty) in the i18n catalog formatted with Args, or Message as it
+// is (e.g. the message of another service). Data is sent with them (e.g. the
+// new price of a product) and Err is the cause
+type Error struct {
+	Kind    Kind
+	Code    string
+	Key     string
+	Args    []interface{}
+	Message string
+	Details []FieldError
+	Data    interface{}
+	Err     error
+}
+
+// Error func
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Text(i18n.Default)
+}
+
+// Text func, the message of e in Locale
+func (e *Error) Text(Locale i18n.Locale) string {
+	if e.Message != "" {
+		return e.Message
+	}
+	Key := e.Key
+	if Key == "" {
+		Key = e.Code
+	}
+	return i18n.Translate(Locale, Key, e.Args...)
+}
+
+// Unwrap func
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is func, errors.Is(err, apperr.NotFound("order_not_found")) match on the
+// kind and the code
+func (e *Error) Is(Target error) bool {
+	Other, ok := Target.(*Error)
+	return ok && Other.Kind == e.Kind && Other.Code == e.Code
+}
+
+// WithDetails func
+func (e *Error) WithDetails(Details ...FieldError) *Error {
+	e.Details = append(e.Details, Details...)
+	return e
+}
+
+// WithKey func, the message of e is the one of Key with Args instead of the
+// one of its code
+func (e *Error) WithKey(Key string, Args ...interface{}) *Error {
+	e.Key = Key
+	e.Args = Args
+	return e
+}
+
+// WithMessage func, Message is sent as it is in every locale
+func (e *Error) WithMessage(Message string) *Error {
+	e.Message = Message
+	return e
+}
+
+// WithData func
+func (e *Error) WithData(Data interface{}) *Error {
+	e.Data = Data
+	return e
+}
+
+// Wrap func set the cause of e
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// New func, Args format the message of Code in the catalog
+func New(Kind Kind, Code string, Args ...interface{}) *Error {
+	return &Error{Kind: Kind, Code: Code, Args: Args}
+}
+
+// BadRequest func, the request can not be read
+func BadRequest(Code string, Args ...interface{}) *Error {
+	return New(KindBadRequest, Code, Args...)
+}
+
+// Validation func, the request is read but its values are refused
+func Validation(Code string, Args ...interface{}) *Error {
+	return New(KindValidation, Code, Args...)
+}
+
+// Unauthorized func
+func Unauthorized(Code string, Args ...interface{}) *Error {
+	return New(KindUnauthorized, Code, Args...)
+}
+
+// Forbidden func
+func Forbidden(Code string, Args ...interface{}) *Error {
+	return New(KindForbidden, Code, Args...)
+}
+
+// NotFound func
+func NotFound(Code string, Args ...interface{}) *Error {
+	return New(KindNotFound, Code, Args...)
+}
+
+// Conflict func, the state changed under the request, it can be retried
+func Conflict(Code string, Args ...interface{}) *Error {
+	return New(KindConflict, Code, Args...)
+}
+
+// Upstream func, another service answered with an error
+func Upstream(Code string, err error, Args ...interface{}) *Error {
+	return New(KindUpstream, Code, Args...).Wrap(err)
+}
+
+// Unavailable func, another service or a dependency can not be reached now
+func Unavailable(Code string, err error, Args ...interface{}) *Error {
+	return New(KindUnavailable, Code, Args...).Wrap(err)
+}
+
+// Internal func
+func Internal(err error) *Error {
+	return New(KindInternal, "internal_error").Wrap(err)
+}
+
+// As func, the *Error of err when there is one in its chain
+func As(err error) (*Error, bool) {
+	var AppErr *Error
+	if errors.As(err, &AppErr) {
+		return AppErr, true
+	}
+	return nil, false
+}
+
+// KindOf func, the kind of err once classified by From
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+	return From(err).Kind
+}
+
+// CodeOf func, the code of err once classified by From
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	return From(err).Code
+}
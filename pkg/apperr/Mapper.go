@@ -0,0 +1,120 @@
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/gocraft/dbr/v2"
+	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// Coder interface, an error of another package that know its *Error (e.g.
+// the errors of serviceclient) without this package knowing it
+type Coder interface {
+	AppError() *Error
+}
+
+// From func classify err: an *Error or a Coder in its chain, a record that is
+// not found, a timeout. Any other error is Internal
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if AppErr, ok := As(err); ok {
+		return AppErr
+	}
+
+	var Coder Coder
+	if errors.As(err, &Coder) {
+		if AppErr := Coder.AppError(); AppErr != nil {
+			return AppErr
+		}
+	}
+
+	switch {
+	case errors.Is(err, dbr.ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return NotFound("not_found").Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Unavailable("timeout", err)
+	}
+	return Internal(err)
+}
+
+// Status func, the HTTP status of err
+func Status(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return From(err).Kind.Status()
+}
+
+// Response func, the JSONResponse of err with its messages in Locale. Only
+// the code and the message are sent, the cause is never sent (it can hold the
+// details of a token, of a dependency or of another service), Write log it
+func Response(Locale i18n.Locale, err error) *pkg.JSONResponse {
+	AppErr := From(err)
+
+	Response := &pkg.JSONResponse{
+		Code:      AppErr.Kind.Status(),
+		Message:   AppErr.Text(Locale),
+		ErrorCode: AppErr.Code,
+		Data:      AppErr.Data,
+	}
+	if len(AppErr.Details) > 0 {
+		Details := make([]FieldError, len(AppErr.Details))
+		for i, Detail := range AppErr.Details {
+			Details[i] = Detail.Localize(Locale)
+		}
+		Response.Details = Details
+	}
+	return Response
+}
+
+// Write func send the JSONResponse of err in the locale of req, the server
+// errors and the causes of the client errors are logged with the request id
+// of req
+func Write(res http.ResponseWriter, req *http.Request, err error) {
+	Response := Response(i18n.FromContext(req.Context()), err)
+	Logger := logging.FromContext(req.Context()).WithFields(log.Fields{
+		"event":      "error response",
+		"error_code": Response.ErrorCode,
+		"status":     Response.Code,
+	})
+	if Response.Code >= 500 {
+		Logger.Error(err)
+	} else if AppErr := From(err); AppErr.Err != nil {
+		Logger.Warn(err)
+	}
+	pkg.Response(res, Response.Code, Response)
+}
+
+// Payload func, the validator errors of a request payload with one detail per
+// field, their messages are translated by Write. The field names are the json
+// tags when the validator use them
+func Payload(err error) *Error {
+	AppErr := Validation("payload_invalid")
+
+	var ValidationErrors validator.ValidationErrors
+	if !errors.As(err, &ValidationErrors) {
+		return AppErr.Wrap(err)
+	}
+	for _, e := range ValidationErrors {
+		AppErr.Details = append(AppErr.Details, FieldError{
+			Field: e.Field(),
+			Code:  e.Tag(),
+			err:   e,
+		})
+	}
+	return AppErr
+}
+
+// Malformed func, a request payload that is not valid JSON
+func Malformed(err error) *Error {
+	return BadRequest("payload_malformed").Wrap(err)
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA Ed25519 signature, jwt-go v3 does not ship it
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg func
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Sign func, key must be an ed25519.PrivateKey
+func (m *signingMethodEdDSA) Sign(SigningString string, Key interface{}) (string, error) {
+	PrivateKey, ok := Key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	Signature := ed25519.Sign(PrivateKey, []byte(SigningString))
+	return base64.RawURLEncoding.EncodeToString(Signature), nil
+}
+
+// Verify func, key must be an ed25519.PublicKey
+func (m *signingMethodEdDSA) Verify(SigningString string, Signature string, Key interface{}) error {
+	PublicKey, ok := Key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	SignatureBytes, err := base64.RawURLEncoding.DecodeString(Signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(PublicKey, []byte(SigningString), SignatureBytes) {
+		return errors.New("EdDSA signature is invalid")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+)
+
+// JWKS struct, the public keys published on /.well-known/jwks.json
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// JWK struct, RSA keys use N and E, Ed25519 keys use Crv and X
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicKey struct
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// ErrUnsupportedKey returned for a key that is not RSA or Ed25519
+var ErrUnsupportedKey = errors.New("unsupported key, only RS256 and EdDSA are allowed")
+
+// NewJWK func
+func NewJWK(Key *PublicKey) (*JWK, error) {
+	switch PublicKey := Key.Key.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: Key.Algorithm,
+			Kid: Key.ID,
+			N:   base64.RawURLEncoding.EncodeToString(PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(PublicKey.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: Key.Algorithm,
+			Kid: Key.ID,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(PublicKey),
+		}, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// PublicKey func decode the public key of the JWK
+func (k *JWK) PublicKey() (Key *PublicKey, err error) {
+	switch {
+	case k.Kty == "RSA" && k.Alg == "RS256":
+		N, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		E, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PublicKey{
+			ID:        k.Kid,
+			Algorithm: k.Alg,
+			Key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(N),
+				E: int(new(big.Int).SetBytes(E).Int64()),
+			},
+		}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && k.Alg == "EdDSA":
+		X, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(X) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key size")
+		}
+
+		return &PublicKey{
+			ID:        k.Kid,
+			Algorithm: k.Alg,
+			Key:       ed25519.PublicKey(X),
+		}, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RevocationStore interface, where the revoked token IDs are kept until the
+// token expire
+type RevocationStore interface {
+	Revoke(ID string, TTL time.Duration) (err error)
+	IsRevoked(ID string) (Revoked bool, err error)
+}
+
+// Revocations struct, the revocation list every service check the tokens of
+// users services with. Each service build its own (see container.New) on the
+// redis shared by all of them (NewRedisRevocations) so a logout on users
+// services is seen by all of them
+type Revocations struct {
+	Store RevocationStore
+}
+
+// NewRevocations func
+func NewRevocations(Store RevocationStore) *Revocations {
+	return &Revocations{Store: Store}
+}
+
+// Revoke func put the token ID in the revocation list until the token expire
+func (r *Revocations) Revoke(ID string, ExpiresAt time.Time) (err error) {
+	TTL := time.Until(ExpiresAt)
+	if ID == "" || TTL <= 0 {
+		return
+	}
+
+	err = r.Store.Revoke(ID, TTL)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when revoke token",
+			"jti":   ID,
+		}).Error(err)
+	}
+
+	return
+}
+
+// IsRevoked func, a token without ID can not be revoked so it is reported
+// revoked, it must not be accepted
+func (r *Revocations) IsRevoked(ID string) (Revoked bool, err error) {
+	if ID == "" {
+		return true, nil
+	}
+
+	Revoked, err = r.Store.IsRevoked(ID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when check revoked token",
+			"jti":   ID,
+		}).Error(err)
+	}
+
+	return
+}
+
+// ErrTokenRevoked returned for a token that was revoked by logout
+var ErrTokenRevoked = errors.New("token revoked")
+
+// ErrTokenWithoutID returned for a token without jti, a logout could not
+// revoke it
+var ErrTokenWithoutID = errors.New("token has no id")
+
+// ErrRevocationsUnavailable returned when the revocation list can not be read,
+// the token is not known to be bad so it is not an unauthorized error
+var ErrRevocationsUnavailable = errors.New("revocation list unavailable")
+
+// CheckRevoked func return ErrTokenRevoked when the token ID was revoked and
+// ErrTokenWithoutID when the token has no ID
+func (r *Revocations) CheckRevoked(ID string) (err error) {
+	if ID == "" {
+		return ErrTokenWithoutID
+	}
+
+	Revoked, err := r.IsRevoked(ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRevocationsUnavailable, err)
+	}
+
+	if Revoked {
+		return ErrTokenRevoked
+	}
+
+	return
+}
+
+// RedisRevocations struct
+type RedisRevocations struct {
+	Client *redis.Client
+}
+
+// NewRedisRevocations func, the revocation list in the redis of auth.redis.
+// Close it once the server stopped
+func NewRedisRevocations() *RedisRevocations {
+	return &RedisRevocations{
+		Client: redis.NewClient(&redis.Options{
+			Addr:     viper.GetString("auth.redis.address"),
+			Password: viper.GetString("auth.redis.password"),
+			DB:       viper.GetInt("auth.redis.db"),
+		}),
+	}
+}
+
+// Close func
+func (r *RedisRevocations) Close() error {
+	return r.Client.Close()
+}
+
+// Revoke func
+func (r *RedisRevocations) Revoke(ID string, TTL time.Duration) (err error) {
+	return r.Client.Set("auth:revoked:"+ID, 1, TTL).Err()
+}
+
+// IsRevoked func
+func (r *RedisRevocations) IsRevoked(ID string) (Revoked bool, err error) {
+	Exists, err := r.Client.Exists("auth:revoked:" + ID).Result()
+	if err != nil {
+		return
+	}
+
+	return Exists > 0, nil
+}
+
+// MemoryRevocations struct, the revocation list of a single process
+type MemoryRevocations struct {
+	mu        sync.Mutex
+	ExpiredAt map[string]time.Time
+}
+
+// NewMemoryRevocations func
+func NewMemoryRevocations() *MemoryRevocations {
+	return &MemoryRevocations{
+		ExpiredAt: map[string]time.Time{},
+	}
+}
+
+// Revoke func
+func (r *MemoryRevocations) Revoke(ID string, TTL time.Duration) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ExpiredAt[ID] = time.Now().Add(TTL)
+	return
+}
+
+// IsRevoked func
+func (r *MemoryRevocations) IsRevoked(ID string) (Revoked bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ExpiredAt, ok := r.ExpiredAt[ID]
+	if ok && !time.Now().Before(ExpiredAt) {
+		delete(r.ExpiredAt, ID)
+		return false, nil
+	}
+
+	return ok, nil
+}
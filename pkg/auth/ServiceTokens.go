@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/spf13/viper"
+)
+
+// ServicePrefix of the Authorization header sent between services
+const ServicePrefix = "Service "
+
+// ServiceClaim struct, Issuer is the calling service and Audience the called one
+type ServiceClaim struct {
+	jwt.StandardClaims
+}
+
+// ErrServiceNotAllowed returned when the calling service may not call the audience
+var ErrServiceNotAllowed = errors.New("service not allowed")
+
+// IsServiceToken func
+func IsServiceToken(Authorization string) bool {
+	return strings.HasPrefix(Authorization, ServicePrefix)
+}
+
+// IssueServiceToken func sign a short lived token (auth.services.token_ttl) of
+// Service for Audience with the secret of Service, the result is the whole
+// Authorization header
+func IssueServiceToken(Service string, Audience string) (Authorization string, err error) {
+	Secret := serviceSecret(Service)
+	if Secret == nil {
+		return "", fmt.Errorf("auth.services.%s.secret is not configured", Service)
+	}
+
+	TTL := viper.GetDuration("auth.services.token_ttl")
+	if TTL == 0 {
+		TTL = time.Minute
+	}
+
+	ID := make([]byte, 16)
+	if _, err = rand.Read(ID); err != nil {
+		return
+	}
+
+	TokenData := jwt.NewWithClaims(jwt.SigningMethodHS256, &ServiceClaim{
+		StandardClaims: jwt.StandardClaims{
+			Id:        hex.EncodeToString(ID),
+			Issuer:    Service,
+			Subject:   "service:" + Service,
+			Audience:  Audience,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(TTL).Unix(),
+		},
+	})
+	TokenData.Header["kid"] = Service
+
+	Token, err := TokenData.SignedString(Secret)
+	if err != nil {
+		return
+	}
+
+	return ServicePrefix + Token, nil
+}
+
+// VerifyServiceToken func check the Authorization header was signed by a
+// service listed in auth.services.<Audience>.allowed_callers for Audience
+func VerifyServiceToken(Authorization string, Audience string) (Claims *ServiceClaim, err error) {
+	Claims = &ServiceClaim{}
+	var Service string
+	_, err = jwt.ParseWithClaims(strings.TrimPrefix(Authorization, ServicePrefix), Claims, func(Token *jwt.Token) (interface{}, error) {
+		if Token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", Token.Header["alg"])
+		}
+
+		Service, _ = Token.Header["kid"].(string)
+		if !serviceAllowed(Service, Audience) {
+			return nil, ErrServiceNotAllowed
+		}
+
+		Secret := serviceSecret(Service)
+		if Secret == nil {
+			return nil, ErrServiceNotAllowed
+		}
+
+		return Secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret was picked by kid, the claims must name the same service
+	if Claims.ExpiresAt == 0 || Claims.Issuer != Service || Claims.Subject != "service:"+Service {
+		return nil, errors.New("invalid service token claims")
+	}
+
+	if !Claims.VerifyAudience(Audience, true) {
+		return nil, errors.New("service token is not for " + Audience)
+	}
+
+	return
+}
+
+// serviceSecret func
+func serviceSecret(Service string) []byte {
+	if Service == "" {
+		return nil
+	}
+
+	Secret := viper.GetString("auth.services." + Service + ".secret")
+	if Secret == "" {
+		return nil
+	}
+
+	return []byte(Secret)
+}
+
+// serviceAllowed func
+func serviceAllowed(Service string, Audience string) bool {
+	for _, Caller := range viper.GetStringSlice("auth.services." + Audience + ".allowed_callers") {
+		if Caller == Service {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// SigningKey struct, Algorithm is RS256 or EdDSA
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// SigningKeys struct, tokens are signed with Current, every key stays in the
+// JWKS so tokens signed before a rotation keep working until they expire
+type SigningKeys struct {
+	Current string
+	Keys    map[string]*SigningKey
+}
+
+// SigningKeysConfig struct, one item of auth.signing.keys
+type SigningKeysConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+}
+
+// Initialize Variable
+var (
+	defaultSigningKeys     *SigningKeys
+	defaultSigningKeysErr  error
+	defaultSigningKeysOnce sync.Once
+)
+
+// DefaultSigningKeys func load the keys of auth.signing once, an Ed25519 key
+// is generated for the process when none is configured
+func DefaultSigningKeys() (*SigningKeys, error) {
+	defaultSigningKeysOnce.Do(func() {
+		var Config []*SigningKeysConfig
+		if defaultSigningKeysErr = viper.UnmarshalKey("auth.signing.keys", &Config); defaultSigningKeysErr != nil {
+			return
+		}
+
+		if len(Config) == 0 {
+			log.WithFields(log.Fields{
+				"event": "auth.signing.keys is empty, using a temporary key",
+			}).Warn("tokens will not survive a restart of users services")
+			defaultSigningKeys, defaultSigningKeysErr = TemporarySigningKeys()
+			return
+		}
+
+		defaultSigningKeys, defaultSigningKeysErr = LoadSigningKeys(viper.GetString("auth.signing.current_kid"), Config)
+	})
+
+	return defaultSigningKeys, defaultSigningKeysErr
+}
+
+// LoadSigningKeys func read the PEM private keys of Config, Current default to the first key
+func LoadSigningKeys(Current string, Config []*SigningKeysConfig) (Keys *SigningKeys, err error) {
+	Keys = &SigningKeys{
+		Current: Current,
+		Keys:    map[string]*SigningKey{},
+	}
+
+	for _, KeyConfig := range Config {
+		PEM, err := ioutil.ReadFile(KeyConfig.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		PrivateKey, err := ParsePrivateKey(PEM)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %v", KeyConfig.Kid, err)
+		}
+
+		Key := &SigningKey{
+			ID:         KeyConfig.Kid,
+			Algorithm:  KeyConfig.Algorithm,
+			PrivateKey: PrivateKey,
+		}
+		if err = Key.check(); err != nil {
+			return nil, err
+		}
+
+		Keys.Keys[Key.ID] = Key
+		if Keys.Current == "" {
+			Keys.Current = Key.ID
+		}
+	}
+
+	if Keys.Keys[Keys.Current] == nil {
+		return nil, fmt.Errorf("current signing key %s is not configured", Keys.Current)
+	}
+
+	return
+}
+
+// TemporarySigningKeys func
+func TemporarySigningKeys() (Keys *SigningKeys, err error) {
+	_, PrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+
+	Kid := make([]byte, 8)
+	if _, err = rand.Read(Kid); err != nil {
+		return
+	}
+
+	Key := &SigningKey{
+		ID:         fmt.Sprintf("temporary-%x", Kid),
+		Algorithm:  SigningMethodEdDSA.Alg(),
+		PrivateKey: PrivateKey,
+	}
+
+	return &SigningKeys{
+		Current: Key.ID,
+		Keys:    map[string]*SigningKey{Key.ID: Key},
+	}, nil
+}
+
+// ParsePrivateKey func accept PKCS8 keys (RSA or Ed25519) and PKCS1 RSA keys
+func ParsePrivateKey(PEM []byte) (PrivateKey crypto.Signer, err error) {
+	Block, _ := pem.Decode(PEM)
+	if Block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if Block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(Block.Bytes)
+	}
+
+	Key, err := x509.ParsePKCS8PrivateKey(Block.Bytes)
+	if err != nil {
+		return
+	}
+
+	PrivateKey, ok := Key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return
+}
+
+// Sign func sign the claims with the current key, the kid header tell the
+// verifier which key of the JWKS to use
+func (k *SigningKeys) Sign(Claims jwt.Claims) (Token string, err error) {
+	Key := k.Keys[k.Current]
+
+	TokenData := jwt.NewWithClaims(jwt.GetSigningMethod(Key.Algorithm), Claims)
+	TokenData.Header["kid"] = Key.ID
+
+	return TokenData.SignedString(Key.PrivateKey)
+}
+
+// JWKS func
+func (k *SigningKeys) JWKS() (*JWKS, error) {
+	Keys := &JWKS{Keys: []*JWK{}}
+	for _, Key := range k.Keys {
+		Public, err := NewJWK(Key.Public())
+		if err != nil {
+			return nil, err
+		}
+		Keys.Keys = append(Keys.Keys, Public)
+	}
+
+	return Keys, nil
+}
+
+// Public func
+func (k *SigningKey) Public() *PublicKey {
+	return &PublicKey{
+		ID:        k.ID,
+		Algorithm: k.Algorithm,
+		Key:       k.PrivateKey.Public(),
+	}
+}
+
+// check func make sure the key type match the algorithm
+func (k *SigningKey) check() error {
+	switch k.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		if k.Algorithm == "RS256" {
+			return nil
+		}
+	case ed25519.PrivateKey:
+		if k.Algorithm == SigningMethodEdDSA.Alg() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signing key %s is not a %s key", k.ID, k.Algorithm)
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Verifier struct check the tokens signed by users services with the keys of
+// its JWKS. The keys are cached for CacheTTL, an unknown kid refresh them right
+// away (at most once per MinRefresh) so a rotation is picked up without waiting
+type Verifier struct {
+	Fetch      func(ctx context.Context) (*JWKS, error)
+	CacheTTL   time.Duration
+	MinRefresh time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]*PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshing  *verifierRefresh
+}
+
+// verifierRefresh struct, the fetch of the JWKS in flight. Err is set before
+// Done is closed
+type verifierRefresh struct {
+	Done chan struct{}
+	Err  error
+}
+
+// ErrUnknownKey returned when the kid of the token is not in the JWKS
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// NewDefaultVerifier func, verifier of auth.jwks_url (the JWKS of users
+// services by default). Each service build its own, see container.New
+func NewDefaultVerifier() *Verifier {
+	URL := viper.GetString("auth.jwks_url")
+	if URL == "" {
+		URL = viper.GetString("services.users.url") + "/.well-known/jwks.json"
+	}
+
+	return NewVerifier(FetchJWKS(URL, &http.Client{Timeout: time.Second * 10}))
+}
+
+// NewLocalVerifier func, verifier of the keys of this process for users services
+func NewLocalVerifier() *Verifier {
+	return NewVerifier(func(ctx context.Context) (*JWKS, error) {
+		Keys, err := DefaultSigningKeys()
+		if err != nil {
+			return nil, err
+		}
+		return Keys.JWKS()
+	})
+}
+
+// NewVerifier func, cache durations come from auth.jwks_cache_ttl and auth.jwks_min_refresh
+func NewVerifier(Fetch func(ctx context.Context) (*JWKS, error)) *Verifier {
+	CacheTTL := viper.GetDuration("auth.jwks_cache_ttl")
+	if CacheTTL == 0 {
+		CacheTTL = time.Minute * 5
+	}
+	MinRefresh := viper.GetDuration("auth.jwks_min_refresh")
+	if MinRefresh == 0 {
+		MinRefresh = time.Second * 10
+	}
+
+	return &Verifier{
+		Fetch:      Fetch,
+		CacheTTL:   CacheTTL,
+		MinRefresh: MinRefresh,
+	}
+}
+
+// FetchJWKS func get the JWKS from URL
+func FetchJWKS(URL string, Client *http.Client) func(ctx context.Context) (*JWKS, error) {
+	return func(ctx context.Context) (*JWKS, error) {
+		RequestHTTP, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		ResponseHTTP, err := Client.Do(RequestHTTP)
+		if err != nil {
+			return nil, err
+		}
+		defer ResponseHTTP.Body.Close()
+
+		if ResponseHTTP.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("get %s: %s", URL, ResponseHTTP.Status)
+		}
+
+		var Keys *JWKS
+		if err = json.NewDecoder(ResponseHTTP.Body).Decode(&Keys); err != nil {
+			return nil, err
+		}
+
+		return Keys, nil
+	}
+}
+
+// Parse func verify the token (with or without the "Bearer " prefix) into Claims
+func (v *Verifier) Parse(TokenString string, Claims jwt.Claims) (*jwt.Token, error) {
+	TokenString = strings.TrimPrefix(TokenString, "Bearer ")
+
+	return jwt.ParseWithClaims(TokenString, Claims, func(Token *jwt.Token) (interface{}, error) {
+		Kid, _ := Token.Header["kid"].(string)
+
+		Key, err := v.key(Kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// The algorithm is pinned by the key, never by the token
+		if Token.Method.Alg() != Key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", Token.Header["alg"])
+		}
+
+		return Key.Key, nil
+	})
+}
+
+// key func
+func (v *Verifier) key(Kid string) (*PublicKey, error) {
+	Key, Found, Fresh := v.cached(Kid)
+	if Found && Fresh {
+		return Key, nil
+	}
+
+	if err := v.refresh(); err != nil {
+		// Keep using the cached key while users services can not be reached
+		if Found {
+			return Key, nil
+		}
+		return nil, err
+	}
+
+	Key, Found, _ = v.cached(Kid)
+	if !Found {
+		return nil, ErrUnknownKey
+	}
+
+	return Key, nil
+}
+
+// cached func
+func (v *Verifier) cached(Kid string) (Key *PublicKey, Found bool, Fresh bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	Key, Found = v.keys[Kid]
+	return Key, Found, time.Since(v.fetchedAt) < v.CacheTTL
+}
+
+// refresh func fetch the JWKS without holding mu, at most once per MinRefresh.
+// The calls that come during the fetch wait for it instead of fetching again,
+// the new keys replace the old ones at once
+func (v *Verifier) refresh() error {
+	v.mu.Lock()
+	if Refresh := v.refreshing; Refresh != nil {
+		v.mu.Unlock()
+		<-Refresh.Done
+		return Refresh.Err
+	}
+
+	if time.Since(v.attemptedAt) < v.MinRefresh {
+		v.mu.Unlock()
+		return nil
+	}
+
+	Refresh := &verifierRefresh{Done: make(chan struct{})}
+	v.refreshing = Refresh
+	v.attemptedAt = time.Now()
+	v.mu.Unlock()
+
+	PublicKeys, err := v.fetch()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when refresh jwks",
+		}).Error(err)
+	}
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys = PublicKeys
+		v.fetchedAt = time.Now()
+	}
+	v.refreshing = nil
+	v.mu.Unlock()
+
+	Refresh.Err = err
+	close(Refresh.Done)
+	return err
+}
+
+// fetch func
+func (v *Verifier) fetch() (map[string]*PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	Keys, err := v.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	PublicKeys := map[string]*PublicKey{}
+	for _, JWK := range Keys.Keys {
+		Key, err := JWK.PublicKey()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event": "skip unsupported jwk",
+				"kid":   JWK.Kid,
+			}).Warn(err)
+			continue
+		}
+		PublicKeys[Key.ID] = Key
+	}
+
+	return PublicKeys, nil
+}
@@ -0,0 +1,222 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	dbr "github.com/gocraft/dbr/v2"
+	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout of the checks of one /readyz
+const DefaultTimeout = time.Second * 2
+
+// Status of a service or a dependency
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// draining int32, 1 once the shutdown started
+var draining int32
+
+// SetDraining func, the /readyz of this process answer 503 while Draining so
+// the load balancer stop sending requests before the servers shut down
+func SetDraining(Draining bool) {
+	var Value int32
+	if Draining {
+		Value = 1
+	}
+	atomic.StoreInt32(&draining, Value)
+}
+
+// Draining func
+func Draining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// Check func, nil when the dependency can be used
+type Check func(ctx context.Context) error
+
+// Checker struct, the checks of the dependencies of one service
+type Checker struct {
+	Service string
+	Timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+// Report struct, the data of /healthz and /readyz
+type Report struct {
+	Service string                  `json:"service"`
+	Status  string                  `json:"status"`
+	Checks  map[string]*CheckReport `json:"checks,omitempty"`
+}
+
+// CheckReport struct, /readyz is public so only the status is sent, the
+// latency and the error are logged
+type CheckReport struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"-"`
+	Error     string `json:"-"`
+}
+
+// New func
+func New(Service string) *Checker {
+	return &Checker{
+		Service: Service,
+		Timeout: DefaultTimeout,
+		checks:  map[string]Check{},
+	}
+}
+
+// Add func add the check of the dependency Name
+func (c *Checker) Add(Name string, Check Check) *Checker {
+	if _, ok := c.checks[Name]; !ok {
+		c.names = append(c.names, Name)
+	}
+	c.checks[Name] = Check
+	return c
+}
+
+// Ready func run every check together within Timeout
+func (c *Checker) Ready(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	Report := &Report{
+		Service: c.Service,
+		Status:  StatusUp,
+		Checks:  make(map[string]*CheckReport, len(c.names)),
+	}
+
+	var mu sync.Mutex
+	var Checks sync.WaitGroup
+	for _, Name := range c.names {
+		Checks.Add(1)
+		go func(Name string, Check Check) {
+			defer Checks.Done()
+
+			Start := time.Now()
+			err := Check(ctx)
+			CheckReport := &CheckReport{
+				Status:    StatusUp,
+				LatencyMs: time.Since(Start).Milliseconds(),
+			}
+			if err != nil {
+				CheckReport.Status = StatusDown
+				CheckReport.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			Report.Checks[Name] = CheckReport
+			if err != nil {
+				Report.Status = StatusDown
+			}
+		}(Name, c.checks[Name])
+	}
+	Checks.Wait()
+
+	return Report
+}
+
+// Check func, an error when a dependency is down, so a Checker can be the
+// Check of another one
+func (c *Checker) Check(ctx context.Context) error {
+	Report := c.Ready(ctx)
+	for _, Name := range c.names {
+		if CheckReport := Report.Checks[Name]; CheckReport.Status != StatusUp {
+			return fmt.Errorf("%s: %s", Name, CheckReport.Error)
+		}
+	}
+	return nil
+}
+
+// Liveness func handle /healthz, the process is up and serving. It does not
+// check the dependencies so an outage of postgres does not restart the service
+func (c *Checker) Liveness(res http.ResponseWriter, req *http.Request) {
+	pkg.Response(res, http.StatusOK, &pkg.JSONResponse{
+		Code:    http.StatusOK,
+		Message: i18n.T(req.Context(), "ok"),
+		Data:    &Report{Service: c.Service, Status: StatusUp},
+	})
+}
+
+// Readiness func handle /readyz, 200 when every dependency is up, 503 with
+// the up or down of every dependency otherwise or while draining. The errors of
+// the dependencies are only logged
+func (c *Checker) Readiness(res http.ResponseWriter, req *http.Request) {
+	if Draining() {
+		pkg.Response(res, http.StatusServiceUnavailable, &pkg.JSONResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: i18n.T(req.Context(), "service_draining"),
+			Data:    &Report{Service: c.Service, Status: StatusDraining},
+		})
+		return
+	}
+
+	Report := c.Ready(req.Context())
+	if Report.Status != StatusUp {
+		for _, Name := range c.names {
+			if CheckReport := Report.Checks[Name]; CheckReport.Status != StatusUp {
+				logging.FromContext(req.Context()).WithFields(log.Fields{
+					"event":      "dependency is down",
+					"service":    c.Service,
+					"check":      Name,
+					"latency_ms": CheckReport.LatencyMs,
+				}).Error(CheckReport.Error)
+			}
+		}
+
+		pkg.Response(res, http.StatusServiceUnavailable, &pkg.JSONResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: i18n.T(req.Context(), "service_not_ready"),
+			Data:    Report,
+		})
+		return
+	}
+
+	pkg.Response(res, http.StatusOK, &pkg.JSONResponse{
+		Code:    http.StatusOK,
+		Message: i18n.T(req.Context(), "ok"),
+		Data:    Report,
+	})
+}
+
+// Postgres func ping the database of Connection
+func Postgres(Connection *dbr.Connection) Check {
+	return func(ctx context.Context) error {
+		return Connection.PingContext(ctx)
+	}
+}
+
+// Redis func ping Client
+func Redis(Client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		return Client.WithContext(ctx).Ping().Err()
+	}
+}
+
+// IServiceClient interface, a *serviceclient.Client
+type IServiceClient interface {
+	Get(ctx context.Context, Path string, Query url.Values, Data interface{}) error
+}
+
+// Service func call the /healthz of the service of Client, the liveness of
+// the peer and not its readiness so two services never wait on each other
+func Service(Client IServiceClient) Check {
+	return func(ctx context.Context) error {
+		return Client.Get(ctx, "/healthz", nil, nil)
+	}
+}
@@ -0,0 +1,226 @@
+package i18n
+
+// Catalog of the messages sent to the users, keyed by the error code of
+// apperr or the event of a success response (the domain event when there is
+// one, e.g. OrderCreated). The messages are fmt formats of the Args of T
+var Catalog = map[Locale]map[string]string{
+	Indonesian: {
+		// success
+		"ok":                  "OK",
+		"UserRegistered":      "Berhasil terdaftar",
+		"UserLoggedIn":        "Berhasil login",
+		"UserLoggedOut":       "Berhasil logout",
+		"UserProfileUpdated":  "Profile berhasil diupdate",
+		"UserPasswordChanged": "Password berhasil diupdate",
+		"ProductCreated":      "Produk berhasil ditambahkan",
+		"ProductUpdated":      "Products berhasil diupdate",
+		"StockReserved":       "Stok berhasil direservasi",
+		"StockReleased":       "Reservasi stok berhasil dilepas",
+		"StockCommitted":      "Reservasi stok berhasil dikonfirmasi",
+		"OrderCreated":        "Orders berhasil dibuat",
+		"OrderUpdated":        "Orders berhasil di update",
+		"OrderApproved":       "Orders berhasil di approve",
+		"OrderRejected":       "Orders berhasil di reject",
+		"OrderCancelled":      "Orders berhasil di cancel",
+		"OrderPaid":           "Orders berhasil di pay",
+		"OrderShipped":        "Orders berhasil di ship",
+		"OrderDelivered":      "Orders berhasil di deliver",
+		"OrderCompleted":      "Orders berhasil di complete",
+		"OrderExpired":        "Orders berhasil di expire",
+
+		// request
+		"payload_invalid":          "Payload tidak sesuai",
+		"payload_malformed":        "Payload tidak valid",
+		"param_invalid":            "Parameter tidak valid",
+		"pagination_required":      "Limit dan Offset tidak bisa kosong",
+		"idempotency_key_too_long": "Idempotency-Key maksimal %d karakter",
+		"idempotency_key_reused":   "Idempotency-Key sudah dipakai untuk request yang berbeda",
+		"idempotency_in_progress":  "Request dengan Idempotency-Key yang sama sedang diproses, silahkan coba lagi",
+
+		// auth
+		"token_invalid":         "Token tidak valid atau sudah berakhir, silahkan login kembali",
+		"service_token_invalid": "Token service tidak valid",
+		"admin_only":            "Hanya admin yang dapat mengakses",
+		"client_cert_required":  "Sertifikat client dibutuhkan",
+
+		// users
+		"email_registered":      "Anda sudah terdaftar dengan email %s",
+		"user_not_found":        "Users tidak ditemukan",
+		"wrong_password":        "Password yang anda masukkan salah",
+		"refresh_token_invalid": "Refresh token tidak valid",
+		"session_ended":         "Sesi sudah berakhir, silahkan login kembali",
+		"refresh_token_reused":  "Refresh token sudah digunakan, silahkan login kembali",
+		"profile_empty":         "Nama dan Nomor Handphone tidak bisa kosong semua",
+
+		// products
+		"product_not_found":              "Product dengan ID %d tidak ditemukan",
+		"product_update_empty":           "Nama, harga, dan kuantitas tidak bisa kosong semua",
+		"product_has_pending_orders":     "Masih terdapat orders yang pending, tidak bisa menonaktifkan produk, silahkan mengurangi qty produk terlebih dahulu atau ubah status order",
+		"reservation_qty_empty":          "Kuantitas reservasi tidak bisa kosong",
+		"reservation_product_mismatch":   "Reservasi %s bukan untuk produk ini",
+		"reservation_adopt_invalid":      "Reservasi %s bukan pesanan lama yang memegang stok produk ini",
+		"reservation_not_found":          "Reservasi %s tidak ditemukan",
+		"reservation_expired":            "Reservasi %s sudah tidak berlaku",
+		"stock_insufficient_or_inactive": "Stok produk tidak mencukupi atau produk sedang tidak aktif",
+
+		// orders
+		"order_not_found":       "Order tidak ditemukan",
+		"order_not_owned":       "Order bukan milik users",
+		"order_not_pending":     "Order tidak sedang pending",
+		"order_status_invalid":  "Order tidak bisa di %s dari status saat ini",
+		"order_status_changed":  "Status order sudah berubah, silahkan coba lagi",
+		"order_event_unknown":   "Event %s tidak dikenal",
+		"order_actor_forbidden": "Anda tidak dapat melakukan aksi ini pada order",
+		"order_items_empty":     "Order harus memiliki minimal satu produk, silahkan cancel order",
+		"product_inactive":      "Product %s sedang tidak aktif, silahkan hubungi cs",
+		"stock_insufficient":    "Kuantitas %s yang di order lebih banyak daripada stok yang tersedia",
+		"price_changed":         "Harga %s sudah berubah, silahkan cek kembali order anda",
+		"amount_too_large":      "Total harga order terlalu besar",
+
+		// services
+		"products_unavailable": "Layanan produk sedang tidak tersedia, silahkan coba beberapa saat lagi",
+		"orders_unavailable":   "Layanan order sedang tidak tersedia, silahkan coba beberapa saat lagi",
+		"service_unavailable":  "Layanan sedang tidak tersedia, silahkan coba beberapa saat lagi",
+		"service_draining":     "Service sedang berhenti",
+		"service_not_ready":    "Service belum siap",
+		"upstream_error":       "Layanan lain mengembalikan kesalahan",
+		"not_found":            "Data tidak ditemukan",
+		"timeout":              "Waktu permintaan habis, silahkan coba beberapa saat lagi",
+		"internal_error":       "Terjadi kesalahan sistem",
+	},
+	English: {
+		// success
+		"ok":                  "OK",
+		"UserRegistered":      "Registered successfully",
+		"UserLoggedIn":        "Logged in successfully",
+		"UserLoggedOut":       "Logged out successfully",
+		"UserProfileUpdated":  "Profile updated",
+		"UserPasswordChanged": "Password updated",
+		"ProductCreated":      "Product added",
+		"ProductUpdated":      "Product updated",
+		"StockReserved":       "Stock reserved",
+		"StockReleased":       "Stock reservation released",
+		"StockCommitted":      "Stock reservation confirmed",
+		"OrderCreated":        "Order created",
+		"OrderUpdated":        "Order updated",
+		"OrderApproved":       "Order approved",
+		"OrderRejected":       "Order rejected",
+		"OrderCancelled":      "Order cancelled",
+		"OrderPaid":           "Order paid",
+		"OrderShipped":        "Order shipped",
+		"OrderDelivered":      "Order delivered",
+		"OrderCompleted":      "Order completed",
+		"OrderExpired":        "Order expired",
+
+		// request
+		"payload_invalid":          "The payload is not valid",
+		"payload_malformed":        "The payload is not valid JSON",
+		"param_invalid":            "Invalid parameter",
+		"pagination_required":      "Limit and offset are required",
+		"idempotency_key_too_long": "Idempotency-Key must be at most %d characters",
+		"idempotency_key_reused":   "Idempotency-Key was already used for a different request",
+		"idempotency_in_progress":  "A request with the same Idempotency-Key is being processed, please try again",
+
+		// auth
+		"token_invalid":         "The token is invalid or has expired, please log in again",
+		"service_token_invalid": "The service token is invalid",
+		"admin_only":            "Only admins can access this",
+		"client_cert_required":  "A client certificate is required",
+
+		// users
+		"email_registered":      "You are already registered with the email %s",
+		"user_not_found":        "User not found",
+		"wrong_password":        "The password you entered is wrong",
+		"refresh_token_invalid": "The refresh token is invalid",
+		"session_ended":         "The session has ended, please log in again",
+		"refresh_token_reused":  "The refresh token was already used, please log in again",
+		"profile_empty":         "Full name and phone number can not both be empty",
+
+		// products
+		"product_not_found":              "Product with ID %d not found",
+		"product_update_empty":           "Name, price and quantity can not all be empty",
+		"product_has_pending_orders":     "There are pending orders, the product can not be deactivated. Reduce the product qty first or change the status of the orders",
+		"reservation_qty_empty":          "The reservation quantity can not be empty",
+		"reservation_product_mismatch":   "Reservation %s is not for this product",
+		"reservation_adopt_invalid":      "Reservation %s is not a legacy order holding stock of this product",
+		"reservation_not_found":          "Reservation %s not found",
+		"reservation_expired":            "Reservation %s is no longer valid",
+		"stock_insufficient_or_inactive": "The product stock is insufficient or the product is inactive",
+
+		// orders
+		"order_not_found":       "Order not found",
+		"order_not_owned":       "The order does not belong to you",
+		"order_not_pending":     "The order is not pending",
+		"order_status_invalid":  "Can not %s the order from its current status",
+		"order_status_changed":  "The order status has changed, please try again",
+		"order_event_unknown":   "Unknown event %s",
+		"order_actor_forbidden": "You can not do this on the order",
+		"order_items_empty":     "An order must have at least one product, please cancel the order instead",
+		"product_inactive":      "Product %s is inactive, please contact customer service",
+		"stock_insufficient":    "The ordered quantity of %s is more than the available stock",
+		"price_changed":         "The price of %s has changed, please check your order again",
+		"amount_too_large":      "The order total is too large",
+
+		// services
+		"products_unavailable": "The products service is unavailable, please try again later",
+		"orders_unavailable":   "The orders service is unavailable, please try again later",
+		"service_unavailable":  "The service is unavailable, please try again later",
+		"service_draining":     "The service is shutting down",
+		"service_not_ready":    "The service is not ready yet",
+		"upstream_error":       "Another service returned an error",
+		"not_found":            "Data not found",
+		"timeout":              "The request timed out, please try again later",
+		"internal_error":       "Something went wrong on our side",
+	},
+}
+
+// ValidationCatalog of the messages of the validator tags, in the format of
+// universal-translator: {0} is the field and {1} the parameter of the tag. A
+// size tag has a message per kind of value (see FieldMessage), invalid is
+// used for the tags that are not listed
+var ValidationCatalog = map[Locale]map[string]string{
+	Indonesian: {
+		"required":         "{0} wajib diisi",
+		"required_without": "{0} wajib diisi jika {1} kosong",
+		"min-string":       "{0} minimal {1} karakter",
+		"min-items":        "{0} minimal berisi {1} item",
+		"min-number":       "{0} minimal {1}",
+		"max-string":       "{0} maksimal {1} karakter",
+		"max-items":        "{0} maksimal berisi {1} item",
+		"max-number":       "{0} maksimal {1}",
+		"gt-string":        "{0} harus lebih dari {1} karakter",
+		"gt-items":         "{0} harus berisi lebih dari {1} item",
+		"gt-number":        "{0} harus lebih besar dari {1}",
+		"gte-string":       "{0} minimal {1} karakter",
+		"gte-items":        "{0} minimal berisi {1} item",
+		"gte-number":       "{0} harus lebih besar atau sama dengan {1}",
+		"len-string":       "panjang {0} harus {1} karakter",
+		"len-items":        "{0} harus berisi {1} item",
+		"len-number":       "{0} harus sama dengan {1}",
+		"email":            "{0} harus berupa alamat email yang valid",
+		"oneof":            "{0} harus berupa salah satu dari [{1}]",
+		"invalid":          "{0} tidak valid",
+	},
+	English: {
+		"required":         "{0} is a required field",
+		"required_without": "{0} is required when {1} is empty",
+		"min-string":       "{0} must be at least {1} characters in length",
+		"min-items":        "{0} must contain at least {1} items",
+		"min-number":       "{0} must be {1} or greater",
+		"max-string":       "{0} must be a maximum of {1} characters in length",
+		"max-items":        "{0} must contain at maximum {1} items",
+		"max-number":       "{0} must be {1} or less",
+		"gt-string":        "{0} must be greater than {1} characters in length",
+		"gt-items":         "{0} must contain more than {1} items",
+		"gt-number":        "{0} must be greater than {1}",
+		"gte-string":       "{0} must be at least {1} characters in length",
+		"gte-items":        "{0} must contain at least {1} items",
+		"gte-number":       "{0} must be {1} or greater",
+		"len-string":       "{0} must be {1} characters in length",
+		"len-items":        "{0} must contain {1} items",
+		"len-number":       "{0} must be equal to {1}",
+		"email":            "{0} must be a valid email address",
+		"oneof":            "{0} must be one of [{1}]",
+		"invalid":          "{0} is not valid",
+	},
+}
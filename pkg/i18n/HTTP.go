@@ -0,0 +1,22 @@
+package i18n
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Middleware func negotiate the locale of every request from its
+// Accept-Language and put it in the context for the usecases and apperr. The
+// response tell the locale it was written in with Content-Language
+func Middleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			Locale := Negotiate(req.Header.Get(AcceptLanguageHeader))
+
+			res.Header().Set("Content-Language", string(Locale))
+			res.Header().Add("Vary", AcceptLanguageHeader)
+			next.ServeHTTP(res, req.WithContext(WithLocale(req.Context(), Locale)))
+		})
+	}
+}
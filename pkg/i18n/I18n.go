@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptLanguageHeader of the requests and of the calls between the services
+const AcceptLanguageHeader = "Accept-Language"
+
+// Locale of the messages
+type Locale string
+
+// Locales
+const (
+	Indonesian Locale = "id"
+	English    Locale = "en"
+)
+
+// Default locale when the request does not ask for a supported one
+const Default = Indonesian
+
+// Supported locales, every key of the Catalog has a message in each of them
+var Supported = []Locale{Indonesian, English}
+
+// contextKey type
+type contextKey int
+
+// Context keys
+const (
+	localeKey contextKey = iota
+)
+
+// WithLocale func, the messages of ctx are in Locale
+func WithLocale(ctx context.Context, Locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey, Locale)
+}
+
+// FromContext func, the locale of ctx or Default
+func FromContext(ctx context.Context) Locale {
+	if ctx == nil {
+		return Default
+	}
+	if Locale, ok := ctx.Value(localeKey).(Locale); ok {
+		return Locale
+	}
+	return Default
+}
+
+// T func, the message of Key in the locale of ctx
+func T(ctx context.Context, Key string, Args ...interface{}) string {
+	return Translate(FromContext(ctx), Key, Args...)
+}
+
+// Translate func, the message of Key in Locale formatted with Args. A key
+// missing in Locale use the Default one, a key missing in the Catalog is
+// returned as it is
+func Translate(Locale Locale, Key string, Args ...interface{}) string {
+	Message, ok := Catalog[Locale][Key]
+	if !ok {
+		Message, ok = Catalog[Default][Key]
+	}
+	if !ok {
+		return Key
+	}
+	if len(Args) == 0 {
+		return Message
+	}
+	return fmt.Sprintf(Message, Args...)
+}
+
+// Negotiate func, the supported locale the Accept-Language Header prefer
+// (e.g. "en-US,en;q=0.9,id;q=0.8" is English). The region is ignored and
+// Default is returned when no supported locale is asked
+func Negotiate(Header string) Locale {
+	type candidate struct {
+		Locale Locale
+		Q      float64
+	}
+
+	var Candidates []candidate
+	for _, Part := range strings.Split(Header, ",") {
+		Fields := strings.Split(strings.TrimSpace(Part), ";")
+		Tag := strings.ToLower(strings.TrimSpace(Fields[0]))
+		if Tag == "" {
+			continue
+		}
+
+		Q := 1.0
+		for _, Param := range Fields[1:] {
+			Param = strings.TrimSpace(Param)
+			if !strings.HasPrefix(Param, "q=") {
+				continue
+			}
+			Value, err := strconv.ParseFloat(strings.TrimPrefix(Param, "q="), 64)
+			if err != nil {
+				Value = 0
+			}
+			Q = Value
+		}
+		if Q <= 0 {
+			continue
+		}
+
+		if Tag == "*" {
+			Candidates = append(Candidates, candidate{Locale: Default, Q: Q})
+			continue
+		}
+		Language := Locale(strings.SplitN(Tag, "-", 2)[0])
+		if supported(Language) {
+			Candidates = append(Candidates, candidate{Locale: Language, Q: Q})
+		}
+	}
+
+	if len(Candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(Candidates, func(i, j int) bool {
+		return Candidates[i].Q > Candidates[j].Q
+	})
+	return Candidates[0].Locale
+}
+
+// supported func
+func supported(Locale Locale) bool {
+	for _, Supported := range Supported {
+		if Locale == Supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"reflect"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+)
+
+// translators of the ValidationCatalog, one per supported locale
+var translators = map[Locale]ut.Translator{}
+
+func init() {
+	Universal := ut.New(id.New(), id.New(), en.New())
+	for _, Locale := range Supported {
+		Translator, _ := Universal.GetTranslator(string(Locale))
+		for Key, Text := range ValidationCatalog[Locale] {
+			if err := Translator.Add(Key, Text, false); err != nil {
+				panic("i18n: " + string(Locale) + " " + Key + ": " + err.Error())
+			}
+		}
+		translators[Locale] = Translator
+	}
+}
+
+// ValidationMessage func, the message of a validator error in Locale (e.g.
+// "qty wajib diisi")
+func ValidationMessage(Locale Locale, FieldError validator.FieldError) string {
+	return FieldMessage(Locale, FieldError.Field(), FieldError.Tag(), FieldError.Kind(), FieldError.Param())
+}
+
+// FieldMessage func, the message of the Tag rule (a validator tag like
+// required or min) failing on Field with Param in Locale. The size rules have
+// a message for the characters of a string, the items of a list and the value
+// of a number, Kind choose it
+func FieldMessage(Locale Locale, Field string, Tag string, Kind reflect.Kind, Param string) string {
+	Translator, ok := translators[Locale]
+	if !ok {
+		Translator = translators[Default]
+	}
+
+	for _, Key := range []string{Tag + "-" + sizeOf(Kind), Tag, "invalid"} {
+		if Message, err := Translator.T(Key, Field, Param); err == nil {
+			return Message
+		}
+	}
+	return Field
+}
+
+// sizeOf func, what the size rules count on a value of Kind
+func sizeOf(Kind reflect.Kind) string {
+	switch Kind {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return "number"
+}
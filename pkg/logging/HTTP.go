@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxLoggedBody bytes of a request body are logged, the handler still read it all
+const MaxLoggedBody = 64 << 10
+
+// Middleware func give every request of the router of Service a request id
+// (the X-Request-ID of the caller when it is valid), set it on the response
+// and on the logger of the context, then log the request with its status,
+// duration and redacted body. Put it after tracing.Middleware so the lines
+// carry the trace id
+func Middleware(Service string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			Start := time.Now()
+
+			ID := req.Header.Get(RequestIDHeader)
+			if !validRequestID(ID) {
+				ID = NewRequestID()
+			}
+			res.Header().Set(RequestIDHeader, ID)
+			ctx := WithService(WithRequestID(req.Context(), ID), Service)
+
+			Body := readBody(req)
+
+			Route := req.URL.Path
+			if Current := mux.CurrentRoute(req); Current != nil {
+				if Template, err := Current.GetPathTemplate(); err == nil {
+					Route = Template
+				}
+			}
+
+			Recorder := &statusRecorder{ResponseWriter: res, Status: http.StatusOK}
+			next.ServeHTTP(Recorder, req.WithContext(ctx))
+
+			Fields := log.Fields{
+				"event":    "request",
+				"method":   req.Method,
+				"route":    Route,
+				"path":     req.URL.Path,
+				"status":   Recorder.Status,
+				"duration": time.Since(Start).String(),
+			}
+			if Body != "" {
+				Fields["body"] = Body
+			}
+			FromContext(ctx).WithFields(Fields).Info(req.Method + " " + req.URL.Path)
+		})
+	}
+}
+
+// readBody func, the redacted body of req for the logs. What was read is put
+// back in front of the rest of the body for the handler
+func readBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+
+	Head, err := ioutil.ReadAll(io.LimitReader(req.Body, MaxLoggedBody+1))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(Head), req.Body), Closer: req.Body}
+	if err != nil {
+		return ""
+	}
+	if len(Head) > MaxLoggedBody {
+		return "[more than " + strconv.Itoa(MaxLoggedBody) + " bytes not logged]"
+	}
+	return Redact(Head)
+}
+
+// readCloser struct
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusRecorder struct keep the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// WriteHeader func
+func (r *statusRecorder) WriteHeader(Status int) {
+	r.Status = Status
+	r.ResponseWriter.WriteHeader(Status)
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader of the requests and of the calls between the services
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey type
+type contextKey int
+
+// Context keys
+const (
+	requestIDKey contextKey = iota
+	serviceKey
+)
+
+// WithRequestID func, the logs of ctx carry ID
+func WithRequestID(ctx context.Context, ID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, ID)
+}
+
+// RequestID func, the request id of ctx or ""
+func RequestID(ctx context.Context) string {
+	ID, _ := ctx.Value(requestIDKey).(string)
+	return ID
+}
+
+// WithService func, the logs of ctx carry Service
+func WithService(ctx context.Context, Service string) context.Context {
+	return context.WithValue(ctx, serviceKey, Service)
+}
+
+// FromContext func, the logger of the usecases and repositories: the request
+// id, the service and the trace of ctx are set on every line
+func FromContext(ctx context.Context) *log.Entry {
+	Fields := log.Fields{}
+	if ctx == nil {
+		return log.WithFields(Fields)
+	}
+
+	if ID := RequestID(ctx); ID != "" {
+		Fields["request_id"] = ID
+	}
+	if Service, ok := ctx.Value(serviceKey).(string); ok {
+		Fields["service"] = Service
+	}
+	if Span := tracing.FromContext(ctx); Span != nil {
+		Fields["trace_id"] = Span.TraceID
+		Fields["span_id"] = Span.SpanID
+	}
+	return log.WithFields(Fields)
+}
+
+// NewRequestID func, 16 random bytes in hex
+func NewRequestID() string {
+	var ID [16]byte
+	rand.Read(ID[:])
+	return hex.EncodeToString(ID[:])
+}
+
+// validRequestID func, the X-Request-ID of the caller is kept when it is
+// short and printable, else a new one is given so it can not break the logs
+func validRequestID(ID string) bool {
+	if ID == "" || len(ID) > 128 {
+		return false
+	}
+	for _, Char := range ID {
+		if Char < 0x21 || Char > 0x7e {
+			return false
+		}
+	}
+	return true
+}
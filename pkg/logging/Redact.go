@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Redacted replace the value of the sensitive fields in the logs
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields when logging.redact_fields is not configured
+var DefaultRedactFields = []string{
+	"password",
+	"old_password",
+	"new_password",
+	"token",
+	"access_token",
+	"refresh_token",
+	"authorization",
+}
+
+// RedactFields func, the fields (any case, at any depth) whose value is never
+// logged, logging.redact_fields
+func RedactFields() []string {
+	if viper.IsSet("logging.redact_fields") {
+		return viper.GetStringSlice("logging.redact_fields")
+	}
+	return DefaultRedactFields
+}
+
+// Redact func, Body for the logs: a JSON body with the values of the
+// RedactFields replaced by Redacted. A body that is not JSON is not logged,
+// only its size, as the fields in it can not be found
+func Redact(Body []byte) string {
+	Body = bytes.TrimSpace(Body)
+	if len(Body) == 0 {
+		return ""
+	}
+
+	var Value interface{}
+	Decoder := json.NewDecoder(bytes.NewReader(Body))
+	Decoder.UseNumber()
+	if err := Decoder.Decode(&Value); err != nil {
+		return "[" + strconv.Itoa(len(Body)) + " bytes not JSON]"
+	}
+
+	Fields := map[string]bool{}
+	for _, Field := range RedactFields() {
+		Fields[strings.ToLower(Field)] = true
+	}
+
+	Result, _ := json.Marshal(redact(Value, Fields))
+	return string(Result)
+}
+
+// redact func
+func redact(Value interface{}, Fields map[string]bool) interface{} {
+	switch Value := Value.(type) {
+	case map[string]interface{}:
+		for Key, Item := range Value {
+			if Fields[strings.ToLower(Key)] {
+				Value[Key] = Redacted
+				continue
+			}
+			Value[Key] = redact(Item, Fields)
+		}
+	case []interface{}:
+		for i, Item := range Value {
+			Value[i] = redact(Item, Fields)
+		}
+	}
+	return Value
+}
@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Set func write the columns of Payload on Record, a pointer to a struct with
+// db tags, the same way a SetMap update write them on a row
+func Set(Record interface{}, Payload map[string]interface{}) error {
+	Value := reflect.ValueOf(Record).Elem()
+	for Column, Data := range Payload {
+		Field, ok := field(Value, Column)
+		if !ok {
+			return fmt.Errorf("memstore: %s has no column %s", Value.Type(), Column)
+		}
+
+		Converted, ok := convert(Data, Field.Type())
+		if !ok {
+			return fmt.Errorf("memstore: can not set column %s of %s to %T", Column, Value.Type(), Data)
+		}
+		Field.Set(Converted)
+	}
+	return nil
+}
+
+// Match func, every column of Condition is equal in Record
+func Match(Record interface{}, Condition map[string]interface{}) bool {
+	Value := reflect.Indirect(reflect.ValueOf(Record))
+	for Column, Data := range Condition {
+		Field, ok := field(Value, Column)
+		if !ok {
+			return false
+		}
+
+		Converted, ok := convert(Data, Field.Type())
+		if !ok || !reflect.DeepEqual(Field.Interface(), Converted.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Page func, the Limit records after Offset like a LIMIT OFFSET query
+func Page(Length int, Limit int, Offset int) (From int, To int) {
+	From, To = Offset, Offset+Limit
+	if From > Length {
+		From = Length
+	}
+	if To > Length || Limit <= 0 {
+		To = Length
+	}
+	return
+}
+
+// field func
+func field(Value reflect.Value, Column string) (reflect.Value, bool) {
+	for i := 0; i < Value.NumField(); i++ {
+		if strings.SplitN(Value.Type().Field(i).Tag.Get("db"), ",", 2)[0] == Column {
+			return Value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// convert func
+func convert(Data interface{}, Type reflect.Type) (reflect.Value, bool) {
+	if Data == nil {
+		return reflect.Zero(Type), true
+	}
+
+	Value := reflect.ValueOf(Data)
+	if Value.Kind() == reflect.Ptr && Value.Type() != Type {
+		// the driver write the value a pointer point to
+		if Value.IsNil() {
+			return reflect.Zero(Type), true
+		}
+		Value = Value.Elem()
+	}
+
+	switch {
+	case Value.Type().AssignableTo(Type):
+		return Value, true
+	case Value.Kind() == Type.Kind() && Value.Type().ConvertibleTo(Type):
+		// the named types of the entities e.g. an int status
+		return Value.Convert(Type), true
+	}
+	return reflect.Value{}, false
+}
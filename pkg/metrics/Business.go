@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Business metrics, recorded once the transaction of the change is committed
+var (
+	OrdersEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_events_total",
+		Help: "Orders that moved, by event (create, update, approve, reject, cancel, expire, ...)",
+	}, []string{"event"})
+
+	StockChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stock_changes_total",
+		Help: "Units of stock that moved, by change (added, set, reserved, released, committed, expired)",
+	}, []string{"change"})
+)
+
+// OrdersEvent func count an order that moved with Event
+func OrdersEvent(Event string) {
+	OrdersEvents.WithLabelValues(strings.ToLower(Event)).Inc()
+}
+
+// StockChange func count Qty units of stock that moved with Change, the sign
+// of Qty is dropped as a counter only go up
+func StockChange(Change string, Qty int) {
+	if Qty < 0 {
+		Qty = -Qty
+	}
+	if Qty == 0 {
+		return
+	}
+	StockChanges.WithLabelValues(Change).Add(float64(Qty))
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry of every metric of this process, the services of serveAll share it
+// and tell their series apart with the service label
+var Registry = prometheus.NewRegistry()
+
+// HTTP metrics
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route template and status code",
+	}, []string{"service", "method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests served, by route template and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method", "route", "code"})
+)
+
+// Peer calls metrics, see serviceclient.Metrics
+var (
+	PeerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peer_requests_total",
+		Help: "Calls to the other services, by status code (error when there is no response)",
+	}, []string{"caller", "service", "method", "code"})
+
+	PeerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "peer_request_duration_seconds",
+		Help:    "Duration of the calls to the other services, retries included",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"caller", "service", "method", "code"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		DBQueries,
+		DBQueryErrors,
+		DBQueryDuration,
+		CacheRequests,
+		PeerRequests,
+		PeerRequestDuration,
+		OrdersEvents,
+		StockChanges,
+	)
+}
+
+// Handler func, the /metrics of the Registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware func record every request of the router of Service by its route
+// template (/orders/{id}, not /orders/12) so the series stay few
+func Middleware(Service string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			Start := time.Now()
+			Recorder := &statusRecorder{ResponseWriter: res, Status: http.StatusOK}
+			next.ServeHTTP(Recorder, req)
+
+			Route := "unknown"
+			if Current := mux.CurrentRoute(req); Current != nil {
+				if Template, err := Current.GetPathTemplate(); err == nil {
+					Route = Template
+				}
+			}
+
+			Code := strconv.Itoa(Recorder.Status)
+			HTTPRequests.WithLabelValues(Service, req.Method, Route, Code).Inc()
+			HTTPRequestDuration.WithLabelValues(Service, req.Method, Route, Code).Observe(time.Since(Start).Seconds())
+		})
+	}
+}
+
+// statusRecorder struct keep the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// WriteHeader func
+func (r *statusRecorder) WriteHeader(Status int) {
+	r.Status = Status
+	r.ResponseWriter.WriteHeader(Status)
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Database and cache metrics
+var (
+	DBQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_queries_total",
+		Help: "Queries run by the dbr sessions, by operation (select, exec)",
+	}, []string{"service", "operation"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Queries of the dbr sessions that failed, by operation (select, exec)",
+	}, []string{"service", "operation"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of the queries run by the dbr sessions",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"service", "operation"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Redis cache lookups, by cache and result (hit, miss, error)",
+	}, []string{"service", "cache", "result"})
+)
+
+// DBReceiver struct, a dbr.EventReceiver that record the queries of the
+// sessions of Service, give it to Connection.NewSession
+type DBReceiver struct {
+	Service string
+}
+
+// NewDBReceiver func
+func NewDBReceiver(Service string) *DBReceiver {
+	return &DBReceiver{Service: Service}
+}
+
+// Event func
+func (r *DBReceiver) Event(EventName string) {}
+
+// EventKv func
+func (r *DBReceiver) EventKv(EventName string, Kvs map[string]string) {}
+
+// EventErr func
+func (r *DBReceiver) EventErr(EventName string, err error) error {
+	return r.EventErrKv(EventName, err, nil)
+}
+
+// EventErrKv func count the failed query, dbr name the events
+// dbr.<operation>.<step> e.g. dbr.select.load.query
+func (r *DBReceiver) EventErrKv(EventName string, err error, Kvs map[string]string) error {
+	if Operation := dbrOperation(EventName); Operation != "" {
+		DBQueryErrors.WithLabelValues(r.Service, Operation).Inc()
+	}
+	return err
+}
+
+// Timing func
+func (r *DBReceiver) Timing(EventName string, Nanoseconds int64) {
+	r.TimingKv(EventName, Nanoseconds, nil)
+}
+
+// TimingKv func record a query that ran (failed or not), dbr.select or dbr.exec
+func (r *DBReceiver) TimingKv(EventName string, Nanoseconds int64, Kvs map[string]string) {
+	if Operation := dbrOperation(EventName); Operation != "" {
+		DBQueries.WithLabelValues(r.Service, Operation).Inc()
+		DBQueryDuration.WithLabelValues(r.Service, Operation).Observe(time.Duration(Nanoseconds).Seconds())
+	}
+}
+
+// dbrOperation func
+func dbrOperation(EventName string) string {
+	Parts := strings.Split(EventName, ".")
+	if len(Parts) < 2 || Parts[0] != "dbr" {
+		return ""
+	}
+	switch Parts[1] {
+	case "select", "exec":
+		return Parts[1]
+	}
+	return ""
+}
+
+// Cache func record the result of the redis Get of the cache, err is the
+// error of the Get (redis.Nil for a miss)
+func Cache(Service string, Cache string, err error) {
+	Result := "hit"
+	switch {
+	case err == redis.Nil:
+		Result = "miss"
+	case err != nil:
+		Result = "error"
+	}
+	CacheRequests.WithLabelValues(Service, Cache, Result).Inc()
+}
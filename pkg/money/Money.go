@@ -0,0 +1,326 @@
+package money
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Money struct, an exact amount in the minor unit of Currency (cents for USD,
+// sen for IDR). In JSON it is a plain number with the decimals of the currency
+// e.g. 15000.00, in the database a NUMERIC
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// RoundingMode int, how the digits past the minor unit are dropped
+type RoundingMode int
+
+// RoundingMode Master
+const (
+	// HalfUp round half away from zero: 0.125 -> 0.13, -0.125 -> -0.13
+	HalfUp RoundingMode = iota + 1
+	// HalfEven round half to the even digit: 0.125 -> 0.12, 0.135 -> 0.14
+	HalfEven
+	// Down drop the digits: 0.129 -> 0.12
+	Down
+)
+
+// Rounding used by Parse, Scan and UnmarshalJSON
+var Rounding = HalfUp
+
+// Exponents of the currencies, the number of digits of their minor unit
+var Exponents = map[string]int{
+	"IDR": 2,
+	"USD": 2,
+	"SGD": 2,
+	"EUR": 2,
+	"JPY": 0,
+}
+
+// ErrCurrencyMismatch returned when two amounts of different currencies meet
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// ErrOverflow returned when an amount does not fit in its minor units
+var ErrOverflow = errors.New("money: amount is too large")
+
+// DefaultCurrency func, money.currency or IDR
+func DefaultCurrency() string {
+	if Currency := viper.GetString("money.currency"); Currency != "" {
+		return strings.ToUpper(Currency)
+	}
+	return "IDR"
+}
+
+// CheckCurrency func compare money.currency with the currency pinned in the
+// money_currency table of DB by the migrations, the amounts of DB are read in
+// money.currency so they must be the same
+func CheckCurrency(DB *sql.DB) error {
+	var Pinned string
+	if err := DB.QueryRow("SELECT currency FROM money_currency").Scan(&Pinned); err != nil {
+		return fmt.Errorf("money: can not read the pinned currency, run the migrations: %w", err)
+	}
+
+	if Currency := DefaultCurrency(); strings.ToUpper(Pinned) != Currency {
+		return fmt.Errorf("%w: money.currency is %s but the amounts are stored in %s", ErrCurrencyMismatch, Currency, Pinned)
+	}
+	return nil
+}
+
+// Exponent func
+func Exponent(Currency string) int {
+	if Exponent, ok := Exponents[Currency]; ok {
+		return Exponent
+	}
+	return 2
+}
+
+// New func, Amount is in the minor unit of Currency
+func New(Amount int64, Currency string) Money {
+	return Money{Amount: Amount, Currency: Currency}
+}
+
+// FromMajor func, Amount is in the major unit (rupiah, dollar) of Currency
+func FromMajor(Amount int64, Currency string) Money {
+	return Money{Amount: Amount * pow10(Exponent(Currency)), Currency: Currency}
+}
+
+// Parse func read a decimal like "15000", "15000.5" or "-0.125" in Currency,
+// the digits past the minor unit are rounded with Rounding
+func Parse(Value string, Currency string) (Money, error) {
+	return ParseRound(Value, Currency, Rounding)
+}
+
+// MaxLength of the decimals read by ParseRound, every int64 amount fit in it
+// with its decimals
+const MaxLength = 64
+
+// decimalPattern of the decimals read by ParseRound, no fractions like 1/3 and
+// no exponents like 1e999999
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// ParseRound func, an amount past int64 is ErrOverflow
+func ParseRound(Value string, Currency string, Mode RoundingMode) (Money, error) {
+	Trimmed := strings.TrimSpace(Value)
+	if len(Trimmed) > MaxLength || !decimalPattern.MatchString(Trimmed) {
+		return Money{}, fmt.Errorf("money: invalid amount %q", Value)
+	}
+
+	Rat, ok := new(big.Rat).SetString(Trimmed)
+	if !ok {
+		return Money{}, fmt.Errorf("money: invalid amount %q", Value)
+	}
+
+	Amount, err := round(Rat.Mul(Rat, new(big.Rat).SetInt64(pow10(Exponent(Currency)))), Mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: Amount, Currency: Currency}, nil
+}
+
+// MustParse func, Parse that panic, for constants and tests
+func MustParse(Value string, Currency string) Money {
+	Money, err := Parse(Value, Currency)
+	if err != nil {
+		panic(err)
+	}
+	return Money
+}
+
+// String func, the amount with the decimals of its currency e.g. 15000.00
+func (m Money) String() string {
+	Exponent := Exponent(m.currency())
+	Sign := ""
+	Amount := m.Amount
+	if Amount < 0 {
+		Sign = "-"
+		Amount = -Amount
+	}
+
+	Digits := strconv.FormatUint(uint64(Amount), 10)
+	if Exponent == 0 {
+		return Sign + Digits
+	}
+	if len(Digits) <= Exponent {
+		Digits = strings.Repeat("0", Exponent-len(Digits)+1) + Digits
+	}
+	return Sign + Digits[:len(Digits)-Exponent] + "." + Digits[len(Digits)-Exponent:]
+}
+
+// IsZero func
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive func
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Equal func, the same amount in the same currency
+func (m Money) Equal(Other Money) bool {
+	return m.Amount == Other.Amount && m.currency() == Other.currency()
+}
+
+// Add func, two currencies return ErrCurrencyMismatch and a sum past int64
+// ErrOverflow
+func (m Money) Add(Other Money) (Money, error) {
+	Currency, err := m.sameCurrency(Other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	Amount := m.Amount + Other.Amount
+	if (Other.Amount > 0 && Amount < m.Amount) || (Other.Amount < 0 && Amount > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: Amount, Currency: Currency}, nil
+}
+
+// Sub func, see Add
+func (m Money) Sub(Other Money) (Money, error) {
+	if Other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{Amount: -Other.Amount, Currency: Other.Currency})
+}
+
+// Mul func multiply the amount by a quantity, exact or ErrOverflow
+func (m Money) Mul(Qty int) (Money, error) {
+	Amount := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(Qty)))
+	if !Amount.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: Amount.Int64(), Currency: m.Currency}, nil
+}
+
+// Sum func
+func Sum(Currency string, Amounts ...Money) (Total Money, err error) {
+	Total = Money{Currency: Currency}
+	for _, Amount := range Amounts {
+		if Total, err = Total.Add(Amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return
+}
+
+// MarshalJSON func write the amount as a JSON number, never through a float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON func accept a number or a string, in the default currency
+func (m *Money) UnmarshalJSON(Data []byte) error {
+	Value := strings.Trim(string(Data), `"`)
+	if Value == "null" || Value == "" {
+		return nil
+	}
+
+	Parsed, err := Parse(Value, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = Parsed
+	return nil
+}
+
+// Value func, stored as a decimal string in a NUMERIC column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan func read a NUMERIC (or an old float column) in the default currency
+func (m *Money) Scan(Src interface{}) (err error) {
+	var Value string
+	switch Src := Src.(type) {
+	case nil:
+		*m = Money{Currency: m.currency()}
+		return nil
+	case []byte:
+		Value = string(Src)
+	case string:
+		Value = Src
+	case int64:
+		*m = FromMajor(Src, m.currency())
+		return nil
+	case float64:
+		Value = strconv.FormatFloat(Src, 'f', -1, 64)
+	default:
+		return fmt.Errorf("money: can not scan %T", Src)
+	}
+
+	Parsed, err := Parse(Value, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = Parsed
+	return nil
+}
+
+// ValidateValuer func, register it with validator RegisterCustomTypeFunc so the
+// tags (required, gt=0, ...) check the amount
+func ValidateValuer(Field reflect.Value) interface{} {
+	if Money, ok := Field.Interface().(Money); ok {
+		return Money.Amount
+	}
+	return nil
+}
+
+// currency func
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency()
+	}
+	return m.Currency
+}
+
+// sameCurrency func
+func (m Money) sameCurrency(Other Money) (string, error) {
+	switch {
+	case m.Currency == "":
+		return Other.Currency, nil
+	case Other.Currency == "" || Other.Currency == m.Currency:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, Other.Currency)
+}
+
+// round func
+func round(Rat *big.Rat, Mode RoundingMode) (int64, error) {
+	Quotient, Remainder := new(big.Int).QuoRem(Rat.Num(), Rat.Denom(), new(big.Int))
+
+	if Remainder.Sign() != 0 && Mode != Down {
+		// compare the remainder with the half of the denominator
+		Half := new(big.Int).Abs(Remainder)
+		Half.Mul(Half, big.NewInt(2))
+		Compare := Half.Cmp(Rat.Denom())
+
+		RoundAway := Compare > 0 ||
+			(Compare == 0 && Mode == HalfUp) ||
+			(Compare == 0 && Mode == HalfEven && Quotient.Bit(0) == 1)
+		if RoundAway {
+			Quotient.Add(Quotient, big.NewInt(int64(Rat.Sign())))
+		}
+	}
+
+	if !Quotient.IsInt64() {
+		return 0, fmt.Errorf("%w: %s", ErrOverflow, Rat.FloatString(0))
+	}
+	return Quotient.Int64(), nil
+}
+
+// pow10 func
+func pow10(Exponent int) int64 {
+	return int64(math.Pow10(Exponent))
+}
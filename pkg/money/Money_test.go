@@ -0,0 +1,265 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseRound(t *testing.T) {
+	Tests := []struct {
+		Name     string
+		Value    string
+		Currency string
+		Mode     RoundingMode
+		Want     int64
+		Err      error
+	}{
+		{Name: "integer", Value: "15000", Currency: "IDR", Mode: HalfUp, Want: 1500000},
+		{Name: "one decimal", Value: "15000.5", Currency: "IDR", Mode: HalfUp, Want: 1500050},
+		{Name: "spaces", Value: " 1.25 ", Currency: "USD", Mode: HalfUp, Want: 125},
+		{Name: "half up", Value: "0.125", Currency: "USD", Mode: HalfUp, Want: 13},
+		{Name: "half up negative", Value: "-0.125", Currency: "USD", Mode: HalfUp, Want: -13},
+		{Name: "half even down", Value: "0.125", Currency: "USD", Mode: HalfEven, Want: 12},
+		{Name: "half even up", Value: "0.135", Currency: "USD", Mode: HalfEven, Want: 14},
+		{Name: "half even past half", Value: "0.1251", Currency: "USD", Mode: HalfEven, Want: 13},
+		{Name: "down", Value: "0.129", Currency: "USD", Mode: Down, Want: 12},
+		{Name: "down negative", Value: "-0.129", Currency: "USD", Mode: Down, Want: -12},
+		{Name: "no minor unit", Value: "100.5", Currency: "JPY", Mode: HalfUp, Want: 101},
+		{Name: "fraction", Value: "1/3", Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "exponent", Value: "1e999999", Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "small exponent", Value: "1.5e2", Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "plus sign", Value: "+1", Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "no integer part", Value: ".5", Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "too long", Value: "0." + strings.Repeat("1", MaxLength), Currency: "USD", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "longest", Value: "0." + strings.Repeat("1", MaxLength-2), Currency: "USD", Mode: HalfUp, Want: 11},
+		{Name: "invalid", Value: "15.000,00", Currency: "IDR", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "empty", Value: "", Currency: "IDR", Mode: HalfUp, Err: errors.New("invalid")},
+		{Name: "too large", Value: "92233720368547758.08", Currency: "IDR", Mode: HalfUp, Err: ErrOverflow},
+		{Name: "largest", Value: "92233720368547758.07", Currency: "IDR", Mode: HalfUp, Want: math.MaxInt64},
+		{Name: "far too large", Value: strings.Repeat("9", 40), Currency: "IDR", Mode: HalfUp, Err: ErrOverflow},
+		{Name: "smallest", Value: "-92233720368547758.08", Currency: "IDR", Mode: HalfUp, Want: math.MinInt64},
+		{Name: "too small", Value: "-92233720368547758.09", Currency: "IDR", Mode: HalfUp, Err: ErrOverflow},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			Money, err := ParseRound(Test.Value, Test.Currency, Test.Mode)
+			if Test.Err != nil {
+				if err == nil {
+					t.Fatalf("ParseRound(%q) = %v, want an error", Test.Value, Money)
+				}
+				if errors.Is(Test.Err, ErrOverflow) && !errors.Is(err, ErrOverflow) {
+					t.Fatalf("ParseRound(%q) error = %v, want %v", Test.Value, err, ErrOverflow)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRound(%q) error = %v", Test.Value, err)
+			}
+			if Money.Amount != Test.Want || Money.Currency != Test.Currency {
+				t.Fatalf("ParseRound(%q) = %d %s, want %d %s", Test.Value, Money.Amount, Money.Currency, Test.Want, Test.Currency)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	Tests := []struct {
+		Money Money
+		Want  string
+	}{
+		{Money: New(1500000, "IDR"), Want: "15000.00"},
+		{Money: New(5, "USD"), Want: "0.05"},
+		{Money: New(-5, "USD"), Want: "-0.05"},
+		{Money: New(-1250, "USD"), Want: "-12.50"},
+		{Money: New(0, "IDR"), Want: "0.00"},
+		{Money: New(101, "JPY"), Want: "101"},
+		{Money: New(math.MinInt64, "USD"), Want: "-92233720368547758.08"},
+		{Money: FromMajor(30000, "IDR"), Want: "30000.00"},
+	}
+
+	for _, Test := range Tests {
+		if Got := Test.Money.String(); Got != Test.Want {
+			t.Errorf("%d %s String() = %s, want %s", Test.Money.Amount, Test.Money.Currency, Got, Test.Want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	Tests := []struct {
+		Name string
+		Run  func() (Money, error)
+		Want Money
+		Err  error
+	}{
+		{
+			Name: "add",
+			Run:  func() (Money, error) { return New(150, "IDR").Add(New(50, "IDR")) },
+			Want: New(200, "IDR"),
+		},
+		{
+			Name: "add to an empty currency",
+			Run:  func() (Money, error) { return Money{}.Add(New(50, "USD")) },
+			Want: New(50, "USD"),
+		},
+		{
+			Name: "add another currency",
+			Run:  func() (Money, error) { return New(150, "IDR").Add(New(50, "USD")) },
+			Err:  ErrCurrencyMismatch,
+		},
+		{
+			Name: "add past int64",
+			Run:  func() (Money, error) { return New(math.MaxInt64, "IDR").Add(New(1, "IDR")) },
+			Err:  ErrOverflow,
+		},
+		{
+			Name: "add past -int64",
+			Run:  func() (Money, error) { return New(math.MinInt64, "IDR").Add(New(-1, "IDR")) },
+			Err:  ErrOverflow,
+		},
+		{
+			Name: "sub",
+			Run:  func() (Money, error) { return New(150, "IDR").Sub(New(200, "IDR")) },
+			Want: New(-50, "IDR"),
+		},
+		{
+			Name: "sub the smallest",
+			Run:  func() (Money, error) { return New(0, "IDR").Sub(New(math.MinInt64, "IDR")) },
+			Err:  ErrOverflow,
+		},
+		{
+			Name: "sub another currency",
+			Run:  func() (Money, error) { return New(150, "IDR").Sub(New(50, "USD")) },
+			Err:  ErrCurrencyMismatch,
+		},
+		{
+			Name: "mul",
+			Run:  func() (Money, error) { return MustParse("15000.50", "IDR").Mul(3) },
+			Want: New(4500150, "IDR"),
+		},
+		{
+			Name: "mul by zero",
+			Run:  func() (Money, error) { return New(150, "IDR").Mul(0) },
+			Want: New(0, "IDR"),
+		},
+		{
+			Name: "mul negative",
+			Run:  func() (Money, error) { return New(150, "IDR").Mul(-2) },
+			Want: New(-300, "IDR"),
+		},
+		{
+			Name: "mul past int64",
+			Run:  func() (Money, error) { return New(math.MaxInt64/2+1, "IDR").Mul(2) },
+			Err:  ErrOverflow,
+		},
+		{
+			Name: "mul the smallest by -1",
+			Run:  func() (Money, error) { return New(math.MinInt64, "IDR").Mul(-1) },
+			Err:  ErrOverflow,
+		},
+		{
+			Name: "sum",
+			Run:  func() (Money, error) { return Sum("IDR", New(100, "IDR"), New(200, ""), New(-50, "IDR")) },
+			Want: New(250, "IDR"),
+		},
+		{
+			Name: "sum of nothing",
+			Run:  func() (Money, error) { return Sum("USD") },
+			Want: New(0, "USD"),
+		},
+		{
+			Name: "sum another currency",
+			Run:  func() (Money, error) { return Sum("IDR", New(100, "IDR"), New(200, "USD")) },
+			Err:  ErrCurrencyMismatch,
+		},
+		{
+			Name: "sum past int64",
+			Run:  func() (Money, error) { return Sum("IDR", New(math.MaxInt64, "IDR"), New(1, "IDR")) },
+			Err:  ErrOverflow,
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			Got, err := Test.Run()
+			if !errors.Is(err, Test.Err) {
+				t.Fatalf("error = %v, want %v", err, Test.Err)
+			}
+			if Test.Err == nil && Got != Test.Want {
+				t.Fatalf("got %d %s, want %d %s", Got.Amount, Got.Currency, Test.Want.Amount, Test.Want.Currency)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	Data, err := json.Marshal(struct{ Price Money }{Price: MustParse("15000.5", "IDR")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(Data) != `{"Price":15000.50}` {
+		t.Fatalf("Marshal = %s", Data)
+	}
+
+	Tests := []struct {
+		Data string
+		Want int64
+	}{
+		{Data: `15000.5`, Want: 1500050},
+		{Data: `"15000.5"`, Want: 1500050},
+		{Data: `0.125`, Want: 13},
+		{Data: `null`, Want: 0},
+	}
+	for _, Test := range Tests {
+		var Money Money
+		if err := json.Unmarshal([]byte(Test.Data), &Money); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", Test.Data, err)
+		}
+		if Money.Amount != Test.Want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", Test.Data, Money.Amount, Test.Want)
+		}
+	}
+
+	var Money Money
+	if err := json.Unmarshal([]byte(`"abc"`), &Money); err == nil {
+		t.Fatal("Unmarshal(abc) want an error")
+	}
+}
+
+func TestScan(t *testing.T) {
+	Tests := []struct {
+		Name string
+		Src  interface{}
+		Want int64
+		Err  bool
+	}{
+		{Name: "numeric", Src: []byte("15000.50"), Want: 1500050},
+		{Name: "string", Src: "0.125", Want: 13},
+		{Name: "integer", Src: int64(15000), Want: 1500000},
+		{Name: "float", Src: 15000.5, Want: 1500050},
+		{Name: "null", Src: nil, Want: 0},
+		{Name: "unknown type", Src: true, Err: true},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			var Money Money
+			err := Money.Scan(Test.Src)
+			if Test.Err {
+				if err == nil {
+					t.Fatalf("Scan(%v) want an error", Test.Src)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) error = %v", Test.Src, err)
+			}
+			if Money.Amount != Test.Want || Money.Currency != DefaultCurrency() {
+				t.Fatalf("Scan(%v) = %d %s, want %d %s", Test.Src, Money.Amount, Money.Currency, Test.Want, DefaultCurrency())
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	dbr "github.com/gocraft/dbr/v2"
+	log "github.com/sirupsen/logrus"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Message struct, one domain event waiting in the outbox table to be published
+type Message struct {
+	ID          int       `db:"id" json:"id"`
+	Aggregate   string    `db:"aggregate" json:"aggregate"`
+	AggregateID int       `db:"aggregate_id" json:"aggregate_id"`
+	Event       string    `db:"event" json:"event"`
+	Payload     string    `db:"payload" json:"payload"`
+	PublishedAt null.Time `db:"published_at" json:"published_at"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// New func build a message with the JSON of Payload
+func New(Aggregate string, AggregateID int, Event string, Payload interface{}) (*Message, error) {
+	PayloadJSON, err := json.Marshal(Payload)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when marshal outbox payload",
+		}).Error(err)
+		return nil, err
+	}
+
+	return &Message{
+		Aggregate:   Aggregate,
+		AggregateID: AggregateID,
+		Event:       Event,
+		Payload:     string(PayloadJSON),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Store func insert the message in the same transaction as the state change
+func Store(ctx context.Context, db *dbr.Tx, Message *Message) (ID int, err error) {
+	if err = db.InsertInto("outbox").
+		Columns(
+			"aggregate",
+			"aggregate_id",
+			"event",
+			"payload",
+			"created_at",
+		).
+		Record(Message).
+		Returning("id").
+		LoadContext(ctx, &ID); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when store outbox",
+		}).Error(err)
+	}
+
+	return
+}
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	dbr "github.com/gocraft/dbr/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// IPostgresConnection interface
+type IPostgresConnection interface {
+	PostgresTrade() *dbr.Session
+}
+
+// Relay struct publish the outbox of one service to a Redis Stream
+type Relay struct {
+	Service string
+	PG      IPostgresConnection
+	Redis   *redis.Client
+	Stream  string
+	MaxLen  int64
+}
+
+// Publish func send up to Batch unpublished messages in order. Messages are
+// locked while publishing so several relays can run, and a message can be
+// published twice when the commit fail, consumers dedupe on the "id" field
+func (r *Relay) Publish(ctx context.Context, Batch int) (Published int, err error) {
+	db := r.PG.PostgresTrade()
+
+	Tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when begin transaction in postgres",
+		}).Error(err)
+		return
+	}
+	defer Tx.RollbackUnlessCommitted()
+
+	var Messages []*Message
+	_, err = Tx.
+		Select("*").
+		From("outbox").
+		Where("published_at IS NULL").
+		OrderAsc("id").
+		Limit(uint64(Batch)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		LoadContext(ctx, &Messages)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event":   "error when query unpublished outbox",
+			"service": r.Service,
+		}).Error(err)
+		return
+	}
+
+	Redis := r.Redis.WithContext(ctx)
+	for _, Message := range Messages {
+		err = Redis.XAdd(&redis.XAddArgs{
+			Stream:       r.Stream,
+			MaxLenApprox: r.MaxLen,
+			Values: map[string]interface{}{
+				"id":           r.Service + ":" + strconv.Itoa(Message.ID),
+				"service":      r.Service,
+				"aggregate":    Message.Aggregate,
+				"aggregate_id": Message.AggregateID,
+				"event":        Message.Event,
+				"payload":      Message.Payload,
+				"created_at":   Message.CreatedAt.Format(time.RFC3339Nano),
+			},
+		}).Err()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event":   "error when publish outbox to redis stream",
+				"service": r.Service,
+				"id":      Message.ID,
+			}).Error(err)
+			break
+		}
+
+		_, err = Tx.Update("outbox").
+			Set("published_at", time.Now()).
+			Where("id = ?", Message.ID).
+			ExecContext(ctx)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"event":   "error when mark outbox published",
+				"service": r.Service,
+				"id":      Message.ID,
+			}).Error(err)
+			break
+		}
+		Published++
+	}
+
+	// Keep what was published before the error
+	if CommitErr := Tx.Commit(); CommitErr != nil {
+		log.WithFields(log.Fields{
+			"event":   "error when commit outbox published",
+			"service": r.Service,
+		}).Error(CommitErr)
+		return 0, CommitErr
+	}
+
+	return
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Initialize Variable
+var (
+	reloaders   []func() error
+	reloadersMu sync.Mutex
+)
+
+// OnReload func add Reloader to the funcs run by Reload
+func OnReload(Reloader func() error) {
+	reloadersMu.Lock()
+	defer reloadersMu.Unlock()
+	reloaders = append(reloaders, Reloader)
+}
+
+// Reload func read again every certificate of this process (servers and
+// clients), a certificate that can not be read keep the previous one
+func Reload() (err error) {
+	reloadersMu.Lock()
+	Reloaders := append([]func() error{}, reloaders...)
+	reloadersMu.Unlock()
+
+	for _, Reloader := range Reloaders {
+		if ReloadErr := Reloader(); ReloadErr != nil {
+			log.WithFields(log.Fields{
+				"event": "error when reload certificate",
+			}).Error(ReloadErr)
+			err = ReloadErr
+		}
+	}
+	return
+}
+
+// KeyPair struct, a certificate and its key read from files and kept until Reload
+type KeyPair struct {
+	CertFile    string
+	KeyFile     string
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+}
+
+// LoadKeyPair func read the key pair, it is read again on every Reload
+func LoadKeyPair(CertFile string, KeyFile string) (*KeyPair, error) {
+	KeyPair := &KeyPair{CertFile: CertFile, KeyFile: KeyFile}
+	if err := KeyPair.Reload(); err != nil {
+		return nil, err
+	}
+
+	OnReload(KeyPair.Reload)
+	return KeyPair, nil
+}
+
+// Reload func
+func (k *KeyPair) Reload() error {
+	Certificate, err := tls.LoadX509KeyPair(k.CertFile, k.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair %s: %w", k.CertFile, err)
+	}
+
+	k.mu.Lock()
+	k.certificate = &Certificate
+	k.mu.Unlock()
+	return nil
+}
+
+// Certificate func
+func (k *KeyPair) Certificate() *tls.Certificate {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.certificate
+}
+
+// GetCertificate func, for tls.Config of a server
+func (k *KeyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return k.Certificate(), nil
+}
+
+// GetClientCertificate func, for tls.Config of a client
+func (k *KeyPair) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return k.Certificate(), nil
+}
+
+// LoadCertPool func read the PEM certificates of File
+func LoadCertPool(File string) (*x509.CertPool, error) {
+	PEM, err := ioutil.ReadFile(File)
+	if err != nil {
+		return nil, err
+	}
+
+	Pool := x509.NewCertPool()
+	if !Pool.AppendCertsFromPEM(PEM) {
+		return nil, fmt.Errorf("no certificate found in %s", File)
+	}
+	return Pool, nil
+}
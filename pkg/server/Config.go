@@ -0,0 +1,83 @@
+package server
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Defaults of a server when <service>Services.server is not configured
+const (
+	DefaultReadTimeout   = time.Second * 15
+	DefaultWriteTimeout  = time.Second * 15
+	DefaultIdleTimeout   = time.Second * 60
+	DefaultShutdownGrace = time.Second * 30
+)
+
+// Config struct of the http server of one service
+type Config struct {
+	Addr          string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	IdleTimeout   time.Duration
+	ShutdownGrace time.Duration
+	DrainDelay    time.Duration
+	TLS           TLSConfig
+}
+
+// TLSConfig struct, the server serve https when CertFile and KeyFile are set.
+// With ClientCAFile the requests under ClientAuthPaths must present a client
+// certificate signed by it, the other paths do not ask one
+type TLSConfig struct {
+	CertFile        string
+	KeyFile         string
+	ClientCAFile    string
+	ClientAuthPaths []string
+}
+
+// Enabled func
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// LoadConfig func read <service>Services.server, Addr is used when address is
+// not configured. The client certificate is asked on /<service>/internal by default
+func LoadConfig(Service string, Addr string) Config {
+	Prefix := Service + "Services.server."
+
+	Config := Config{
+		Addr:          viper.GetString(Prefix + "address"),
+		ReadTimeout:   viper.GetDuration(Prefix + "read_timeout"),
+		WriteTimeout:  viper.GetDuration(Prefix + "write_timeout"),
+		IdleTimeout:   viper.GetDuration(Prefix + "idle_timeout"),
+		ShutdownGrace: viper.GetDuration(Prefix + "shutdown_grace"),
+		DrainDelay:    viper.GetDuration(Prefix + "drain_delay"),
+		TLS: TLSConfig{
+			CertFile:        viper.GetString(Prefix + "tls.cert_file"),
+			KeyFile:         viper.GetString(Prefix + "tls.key_file"),
+			ClientCAFile:    viper.GetString(Prefix + "tls.client_ca_file"),
+			ClientAuthPaths: viper.GetStringSlice(Prefix + "tls.client_auth_paths"),
+		},
+	}
+
+	if Config.Addr == "" {
+		Config.Addr = Addr
+	}
+	if Config.ReadTimeout == 0 {
+		Config.ReadTimeout = DefaultReadTimeout
+	}
+	if Config.WriteTimeout == 0 {
+		Config.WriteTimeout = DefaultWriteTimeout
+	}
+	if Config.IdleTimeout == 0 {
+		Config.IdleTimeout = DefaultIdleTimeout
+	}
+	if Config.ShutdownGrace == 0 {
+		Config.ShutdownGrace = DefaultShutdownGrace
+	}
+	if Config.TLS.ClientCAFile != "" && len(Config.TLS.ClientAuthPaths) == 0 {
+		Config.TLS.ClientAuthPaths = []string{"/" + Service + "/internal"}
+	}
+
+	return Config
+}
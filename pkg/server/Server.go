@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	log "github.com/sirupsen/logrus"
+)
+
+// Server struct, the http server of one service built from its Config
+type Server struct {
+	Config     Config
+	HTTPServer *http.Server
+	KeyPair    *KeyPair
+	mu         sync.RWMutex
+	clientCAs  *x509.CertPool
+}
+
+// New func, with TLS the key pair (and the client CA) is read now and again
+// on every Reload
+func New(Config Config, Handler http.Handler) (s *Server, err error) {
+	s = &Server{Config: Config}
+
+	if Config.TLS.ClientCAFile != "" {
+		if !Config.TLS.Enabled() {
+			return nil, fmt.Errorf("tls.client_ca_file of %s need tls.cert_file and tls.key_file", Config.Addr)
+		}
+		if err = s.reloadClientCAs(); err != nil {
+			return nil, err
+		}
+		OnReload(s.reloadClientCAs)
+		Handler = RequireClientCert(Config.TLS.ClientAuthPaths...)(Handler)
+	}
+
+	s.HTTPServer = &http.Server{
+		Handler:      Handler,
+		Addr:         Config.Addr,
+		ReadTimeout:  Config.ReadTimeout,
+		WriteTimeout: Config.WriteTimeout,
+		IdleTimeout:  Config.IdleTimeout,
+	}
+
+	if Config.TLS.Enabled() {
+		if s.KeyPair, err = LoadKeyPair(Config.TLS.CertFile, Config.TLS.KeyFile); err != nil {
+			return nil, err
+		}
+		s.HTTPServer.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetCertificate:     s.KeyPair.GetCertificate,
+			GetConfigForClient: s.tlsConfig,
+		}
+	}
+
+	return s, nil
+}
+
+// ListenAndServe func, https when TLS is configured
+func (s *Server) ListenAndServe() error {
+	if s.Config.TLS.Enabled() {
+		return s.HTTPServer.ListenAndServeTLS("", "")
+	}
+	return s.HTTPServer.ListenAndServe()
+}
+
+// Shutdown func wait the running requests for ShutdownGrace then close the
+// connections that are left
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.ShutdownGrace)
+	defer cancel()
+
+	if err := s.HTTPServer.Shutdown(ctx); err != nil {
+		s.HTTPServer.Close()
+		return err
+	}
+	return nil
+}
+
+// tlsConfig func, the config of a handshake with the client CA of now
+func (s *Server) tlsConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	Config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.KeyPair.GetCertificate,
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.clientCAs != nil {
+		// the certificate is asked on every path, RequireClientCert reject the
+		// requests without one on the paths that need it
+		Config.ClientAuth = tls.VerifyClientCertIfGiven
+		Config.ClientCAs = s.clientCAs
+	}
+	return Config, nil
+}
+
+// reloadClientCAs func
+func (s *Server) reloadClientCAs() error {
+	Pool, err := LoadCertPool(s.Config.TLS.ClientCAFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.clientCAs = Pool
+	s.mu.Unlock()
+	return nil
+}
+
+// RequireClientCert func, a middleware that answer 403 to the requests under
+// Paths that did not present a verified client certificate
+func RequireClientCert(Paths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			for _, Path := range Paths {
+				if !strings.HasPrefix(req.URL.Path, Path) {
+					continue
+				}
+				if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+					apperr.Write(res, req, apperr.Forbidden("client_cert_required"))
+					return
+				}
+				break
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// Run func serve Servers until SIGTERM or SIGINT then drain and shut them down
+// together, SIGHUP reload the certificates without dropping a connection
+func Run(Servers ...*Server) {
+	for _, s := range Servers {
+		go func(s *Server) {
+			log.WithFields(log.Fields{
+				"event": "serve http",
+				"addr":  s.Config.Addr,
+				"tls":   s.Config.TLS.Enabled(),
+			}).Info("listening")
+			if err := s.ListenAndServe(); err != http.ErrServerClosed {
+				log.WithFields(log.Fields{
+					"event": "error on listen and serve",
+					"addr":  s.Config.Addr,
+				}).Fatal(err)
+			}
+		}(s)
+	}
+
+	var Signals = make(chan os.Signal, 1)
+	signal.Notify(Signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(Signals)
+
+	for Signal := range Signals {
+		if Signal != syscall.SIGHUP {
+			break
+		}
+		if err := Reload(); err == nil {
+			log.WithFields(log.Fields{
+				"event": "reload certificate",
+			}).Info("certificates reloaded")
+		}
+	}
+
+	// /readyz answer 503 from now, the load balancer get DrainDelay to notice
+	// it before the servers stop accepting connections
+	health.SetDraining(true)
+	var DrainDelay time.Duration
+	for _, s := range Servers {
+		if s.Config.DrainDelay > DrainDelay {
+			DrainDelay = s.Config.DrainDelay
+		}
+	}
+	if DrainDelay > 0 {
+		log.WithFields(log.Fields{
+			"event": "drain http server",
+			"delay": DrainDelay.String(),
+		}).Info("draining")
+		time.Sleep(DrainDelay)
+	}
+
+	var Shutdown sync.WaitGroup
+	for _, s := range Servers {
+		Shutdown.Add(1)
+		go func(s *Server) {
+			defer Shutdown.Done()
+			if err := s.Shutdown(); err != nil {
+				log.WithFields(log.Fields{
+					"event": "error when shutdown http server",
+					"addr":  s.Config.Addr,
+				}).Error(err)
+			}
+		}(s)
+	}
+	Shutdown.Wait()
+}
@@ -0,0 +1,217 @@
+package serviceclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// BreakerState type
+type BreakerState string
+
+// BreakerState const
+const (
+	BreakerClosed   BreakerState = "CLOSED"
+	BreakerOpen     BreakerState = "OPEN"
+	BreakerHalfOpen BreakerState = "HALF_OPEN"
+)
+
+// ErrCircuitOpen returned without calling the service while its breaker is
+// open, it is a 503 for apperr
+var ErrCircuitOpen error = &unavailableError{Message: "circuit breaker is open"}
+
+// Breaker struct stop calling a service after Failures failed calls in a row.
+// After OpenFor it let HalfOpenProbes calls through, the breaker close when
+// all of them succeed and open again on the first failure
+type Breaker struct {
+	Service        string
+	Failures       int
+	OpenFor        time.Duration
+	HalfOpenProbes int
+
+	mu         sync.Mutex
+	state      BreakerState
+	generation int
+	failures   int
+	probes     int
+	successes  int
+	openedAt   time.Time
+}
+
+// Initialize Variable
+var (
+	breakers   = map[string]*Breaker{}
+	breakersMu sync.Mutex
+)
+
+// NewBreaker func
+func NewBreaker(Service string, Failures int, OpenFor time.Duration, HalfOpenProbes int) *Breaker {
+	if Failures < 1 {
+		Failures = 1
+	}
+	if HalfOpenProbes < 1 {
+		HalfOpenProbes = 1
+	}
+
+	return &Breaker{
+		Service:        Service,
+		Failures:       Failures,
+		OpenFor:        OpenFor,
+		HalfOpenProbes: HalfOpenProbes,
+		state:          BreakerClosed,
+	}
+}
+
+// BreakerFor func, the breaker of Service shared by every client of this
+// process, configured with services.<service>.breaker
+func BreakerFor(Service string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	if Breaker, ok := breakers[Service]; ok {
+		return Breaker
+	}
+
+	Failures := viper.GetInt("services." + Service + ".breaker.failures")
+	if Failures == 0 {
+		Failures = 5
+	}
+	OpenFor := viper.GetDuration("services." + Service + ".breaker.open_for")
+	if OpenFor == 0 {
+		OpenFor = time.Second * 30
+	}
+
+	Breaker := NewBreaker(Service, Failures, OpenFor, viper.GetInt("services."+Service+".breaker.half_open_probes"))
+	breakers[Service] = Breaker
+	return Breaker
+}
+
+// State func
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	return b.state
+}
+
+// Allow func reserve a call, the generation is given back to Done so the result
+// of a call started before the state changed is ignored
+func (b *Breaker) Allow() (Generation int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return b.generation, fmt.Errorf("%w: %s services", ErrCircuitOpen, b.Service)
+	case BreakerHalfOpen:
+		if b.probes+b.successes >= b.HalfOpenProbes {
+			return b.generation, fmt.Errorf("%w: %s services", ErrCircuitOpen, b.Service)
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// Done func record the result of a call allowed in Generation
+func (b *Breaker) Done(Generation int, Success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if Generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case BreakerClosed:
+		if Success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.Failures {
+			b.open(time.Now())
+		}
+	case BreakerHalfOpen:
+		b.probes--
+		if !Success {
+			b.open(time.Now())
+			return
+		}
+		b.successes++
+		if b.successes >= b.HalfOpenProbes {
+			b.change(BreakerClosed)
+		}
+	}
+}
+
+// Release func give back a call of Generation without result
+func (b *Breaker) Release(Generation int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if Generation == b.generation && b.state == BreakerHalfOpen {
+		b.probes--
+	}
+}
+
+// refresh func move an open breaker to half open once OpenFor passed
+func (b *Breaker) refresh(Now time.Time) {
+	if b.state == BreakerOpen && Now.Sub(b.openedAt) >= b.OpenFor {
+		b.change(BreakerHalfOpen)
+	}
+}
+
+// open func
+func (b *Breaker) open(Now time.Time) {
+	b.change(BreakerOpen)
+	b.openedAt = Now
+}
+
+// change func
+func (b *Breaker) change(State BreakerState) {
+	log.WithFields(log.Fields{
+		"event":   "circuit breaker state changed",
+		"service": b.Service,
+		"from":    b.state,
+		"to":      State,
+	}).Warn("circuit breaker " + string(State))
+
+	b.state = State
+	b.generation++
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+}
+
+// CircuitBreaker func fail fast with ErrCircuitOpen while Breaker is open. A
+// call fail when the service can not be reached or respond with a 5xx
+func CircuitBreaker(Breaker *Breaker) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			Generation, err := Breaker.Allow()
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := Next.RoundTrip(req)
+			switch {
+			case err != nil && errors.Is(err, context.Canceled):
+				// the caller gave up, it say nothing about the service
+				Breaker.Release(Generation)
+			case err != nil:
+				Breaker.Done(Generation, false)
+			default:
+				Breaker.Done(Generation, res.StatusCode < 500)
+			}
+			return res, err
+		})
+	}
+}
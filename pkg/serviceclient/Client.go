@@ -0,0 +1,212 @@
+package serviceclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg"
+	"github.com/mrdhira/warpin-test/pkg/i18n"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// DefaultTimeout of a call when services.<service>.timeout is not configured
+const DefaultTimeout = time.Second * 10
+
+// DefaultTransport shared by every client so the connections to a service are
+// pooled and kept alive between calls
+var DefaultTransport http.RoundTripper = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   time.Second * 5,
+		KeepAlive: time.Second * 30,
+	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   20,
+	IdleConnTimeout:       time.Second * 90,
+	TLSHandshakeTimeout:   time.Second * 5,
+	ExpectContinueTimeout: time.Second,
+}
+
+// ErrUnexpectedResponse returned when the body of the service is not a
+// JSONResponse, it is a 502 for apperr
+var ErrUnexpectedResponse error = &upstreamError{Message: "unexpected response"}
+
+// ResponseError struct, a JSONResponse of the service that is not a success
+type ResponseError struct {
+	Service    string
+	StatusCode int
+	Code       int
+	Message    string
+	Err        string
+	ErrorCode  string
+}
+
+// Error func
+func (e *ResponseError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s services responded %d", e.Service, e.Code)
+	}
+	return e.Message
+}
+
+// Client struct call one service, every call go through the Middlewares (the
+// first one is the outermost) before the Transport
+type Client struct {
+	Service     string
+	BaseURL     string
+	Timeout     time.Duration
+	Transport   http.RoundTripper
+	Middlewares []Middleware
+}
+
+// New func create the client of Service with services.<service>.url,
+// services.<service>.timeout and services.<service>.tls (see TLSTransport)
+func New(Service string, Middlewares ...Middleware) *Client {
+	Timeout := viper.GetDuration("services." + Service + ".timeout")
+	if Timeout == 0 {
+		Timeout = DefaultTimeout
+	}
+
+	Transport, err := TLSTransport(Service)
+	if err != nil {
+		// the calls fail on the handshake until the files are fixed
+		log.WithFields(log.Fields{
+			"event":   "error when load tls of service client",
+			"service": Service,
+		}).Error(err)
+		Transport = DefaultTransport
+	}
+
+	return &Client{
+		Service:     Service,
+		BaseURL:     viper.GetString("services." + Service + ".url"),
+		Timeout:     Timeout,
+		Transport:   Transport,
+		Middlewares: Middlewares,
+	}
+}
+
+// Do func send Payload as JSON to Path and decode the JSONResponse, its data is
+// decoded into Data (a pointer, can be nil). A response code outside 2xx is
+// returned as *ResponseError. The call is bound to ctx and cut after Timeout,
+// it ask for the locale of ctx so the messages of the service can be sent on
+func (c *Client) Do(ctx context.Context, Method string, Path string, Query url.Values, Payload interface{}, Data interface{}) (err error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var RequestBody io.Reader
+	if Payload != nil {
+		Body, err := json.Marshal(Payload)
+		if err != nil {
+			return err
+		}
+		RequestBody = bytes.NewReader(Body)
+	}
+
+	RequestURL := c.BaseURL + Path
+	if len(Query) > 0 {
+		RequestURL += "?" + Query.Encode()
+	}
+
+	RequestHTTP, err := http.NewRequestWithContext(ctx, Method, RequestURL, RequestBody)
+	if err != nil {
+		return err
+	}
+	RequestHTTP.Header.Set("Accept", "application/json")
+	RequestHTTP.Header.Set(i18n.AcceptLanguageHeader, string(i18n.FromContext(ctx)))
+	if Payload != nil {
+		RequestHTTP.Header.Set("Content-Type", "application/json")
+	}
+
+	ResponseHTTP, err := c.roundTripper().RoundTrip(RequestHTTP)
+	if err != nil {
+		return &CallError{Service: c.Service, Err: err}
+	}
+	defer ResponseHTTP.Body.Close()
+
+	ResponseBody, err := ioutil.ReadAll(ResponseHTTP.Body)
+	if err != nil {
+		return err
+	}
+
+	Response := &pkg.JSONResponse{Data: Data}
+	if err = json.Unmarshal(ResponseBody, Response); err != nil {
+		return fmt.Errorf("%w from %s services (%d): %s", ErrUnexpectedResponse, c.Service, ResponseHTTP.StatusCode, truncate(ResponseBody, 128))
+	}
+
+	Code := Response.Code
+	if Code == 0 {
+		Code = ResponseHTTP.StatusCode
+	}
+	if Code < 200 || Code > 299 {
+		return &ResponseError{
+			Service:    c.Service,
+			StatusCode: ResponseHTTP.StatusCode,
+			Code:       Code,
+			Message:    Response.Message,
+			Err:        Response.Error,
+			ErrorCode:  Response.ErrorCode,
+		}
+	}
+
+	return nil
+}
+
+// Get func
+func (c *Client) Get(ctx context.Context, Path string, Query url.Values, Data interface{}) error {
+	return c.Do(ctx, http.MethodGet, Path, Query, nil, Data)
+}
+
+// Post func
+func (c *Client) Post(ctx context.Context, Path string, Payload interface{}, Data interface{}) error {
+	return c.Do(ctx, http.MethodPost, Path, nil, Payload, Data)
+}
+
+// Put func
+func (c *Client) Put(ctx context.Context, Path string, Payload interface{}, Data interface{}) error {
+	return c.Do(ctx, http.MethodPut, Path, nil, Payload, Data)
+}
+
+// roundTripper func wrap the transport with the middlewares, the transport is
+// the handler of the service when it is served in this process (see ServeInProcess)
+func (c *Client) roundTripper() http.RoundTripper {
+	Transport := c.Transport
+	if Handler := inProcessHandler(c.Service); Handler != nil {
+		Transport = HandlerTransport(Handler)
+	}
+	if Transport == nil {
+		Transport = DefaultTransport
+	}
+
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		Transport = c.Middlewares[i](Transport)
+	}
+	return Transport
+}
+
+// truncate func
+func truncate(Body []byte, Max int) string {
+	if len(Body) > Max {
+		return string(Body[:Max]) + "..."
+	}
+	return string(Body)
+}
+
+// IsStatus func report if err is a *ResponseError with Code
+func IsStatus(err error, Code int) bool {
+	var ResponseErr *ResponseError
+	return errors.As(err, &ResponseErr) && ResponseErr.Code == Code
+}
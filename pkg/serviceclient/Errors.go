@@ -0,0 +1,79 @@
+package serviceclient
+
+import (
+	"github.com/mrdhira/warpin-test/pkg/apperr"
+)
+
+// AppError func, the error of the service as seen by the caller: its 404, 409
+// and 422 are kept with their error code and message (in the locale the call
+// asked for), the other codes are an upstream error
+func (e *ResponseError) AppError() *apperr.Error {
+	Code := e.ErrorCode
+	if Code == "" {
+		Code = e.Service + "_error"
+	}
+
+	switch e.Code {
+	case 404:
+		return apperr.NotFound(Code).WithMessage(e.Message).Wrap(e)
+	case 409:
+		return apperr.Conflict(Code).WithMessage(e.Message).Wrap(e)
+	case 422:
+		return apperr.Validation(Code).WithMessage(e.Message).Wrap(e)
+	case 503:
+		return apperr.Unavailable(Code, e).WithKey("service_unavailable")
+	}
+	return apperr.Upstream(Code, e).WithKey("upstream_error")
+}
+
+// CallError struct, the call to Service got no response (connection refused,
+// timeout, breaker open, ...)
+type CallError struct {
+	Service string
+	Err     error
+}
+
+// Error func
+func (e *CallError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap func
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// AppError func
+func (e *CallError) AppError() *apperr.Error {
+	return apperr.Unavailable("service_unavailable", e)
+}
+
+// unavailableError struct, a sentinel error that is a 503
+type unavailableError struct {
+	Message string
+}
+
+// Error func
+func (e *unavailableError) Error() string {
+	return e.Message
+}
+
+// AppError func
+func (e *unavailableError) AppError() *apperr.Error {
+	return apperr.Unavailable("service_unavailable", e)
+}
+
+// upstreamError struct, a sentinel error that is a 502
+type upstreamError struct {
+	Message string
+}
+
+// Error func
+func (e *upstreamError) Error() string {
+	return e.Message
+}
+
+// AppError func
+func (e *upstreamError) AppError() *apperr.Error {
+	return apperr.Upstream("upstream_error", e)
+}
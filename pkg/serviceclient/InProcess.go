@@ -0,0 +1,57 @@
+package serviceclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Initialize Variable
+var (
+	inProcessHandlers = map[string]http.Handler{}
+	inProcessMu       sync.RWMutex
+)
+
+// ServeInProcess func send the calls of every client of Service to Handler in
+// this process instead of services.<service>.url. The middlewares of the
+// clients (auth, breaker, retry, logging) still run, only the network is skipped
+func ServeInProcess(Service string, Handler http.Handler) {
+	inProcessMu.Lock()
+	defer inProcessMu.Unlock()
+
+	if Handler == nil {
+		delete(inProcessHandlers, Service)
+		return
+	}
+	inProcessHandlers[Service] = Handler
+}
+
+// inProcessHandler func
+func inProcessHandler(Service string) http.Handler {
+	inProcessMu.RLock()
+	defer inProcessMu.RUnlock()
+	return inProcessHandlers[Service]
+}
+
+// HandlerTransport func, a round tripper that serve the request with Handler
+func HandlerTransport(Handler http.Handler) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// the handler see the request the way a server would give it
+		req = req.Clone(req.Context())
+		req.RequestURI = req.URL.RequestURI()
+		req.RemoteAddr = "in-process"
+		if req.Body == nil {
+			req.Body = http.NoBody
+		}
+
+		Recorder := httptest.NewRecorder()
+		Handler.ServeHTTP(Recorder, req)
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+
+		res := Recorder.Result()
+		res.Request = req
+		return res, nil
+	})
+}
@@ -0,0 +1,34 @@
+package serviceclient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/metrics"
+)
+
+// Metrics func record every call of Caller to Service, put it first so the
+// duration cover the retries and a call refused by the breaker is counted as
+// circuit_open
+func Metrics(Caller string, Service string) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			Start := time.Now()
+			res, err := Next.RoundTrip(req)
+
+			Code := "error"
+			switch {
+			case errors.Is(err, ErrCircuitOpen):
+				Code = "circuit_open"
+			case err == nil:
+				Code = strconv.Itoa(res.StatusCode)
+			}
+
+			metrics.PeerRequests.WithLabelValues(Caller, Service, req.Method, Code).Inc()
+			metrics.PeerRequestDuration.WithLabelValues(Caller, Service, req.Method, Code).Observe(time.Since(Start).Seconds())
+			return res, err
+		})
+	}
+}
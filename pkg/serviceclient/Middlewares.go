@@ -0,0 +1,70 @@
+package serviceclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// Middleware func wrap the round tripper of a client, used for auth, retries,
+// tracing and logging of the calls
+type Middleware func(Next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc type
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip func
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// ServiceAuth func sign every call with a service token of Caller for Audience
+func ServiceAuth(Caller string, Audience string) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			Authorization, err := auth.IssueServiceToken(Caller, Audience)
+			if err != nil {
+				return nil, err
+			}
+
+			// a round tripper must not modify the request it was given
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", Authorization)
+			return Next.RoundTrip(req)
+		})
+	}
+}
+
+// Logging func log every call with its status and duration on the logger of
+// the context, and send the request id so the logs of Service carry it too
+func Logging(Service string) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			Start := time.Now()
+			if ID := logging.RequestID(req.Context()); ID != "" {
+				// a round tripper must not modify the request it was given
+				req = req.Clone(req.Context())
+				req.Header.Set(logging.RequestIDHeader, ID)
+			}
+			res, err := Next.RoundTrip(req)
+
+			Fields := log.Fields{
+				"event":    "call to " + Service + " services",
+				"method":   req.Method,
+				"path":     req.URL.Path,
+				"duration": time.Since(Start).String(),
+			}
+			if err != nil {
+				logging.FromContext(req.Context()).WithFields(Fields).Error(err)
+				return nil, err
+			}
+
+			Fields["status"] = res.StatusCode
+			logging.FromContext(req.Context()).WithFields(Fields).Info(res.Status)
+			return res, nil
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package serviceclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RetryPolicy struct, a call is tried at most Attempts times. The wait before
+// the next attempt start at BaseDelay and double up to MaxDelay
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// RetryPolicyFor func, the policy of Service from services.<service>.retry
+func RetryPolicyFor(Service string) RetryPolicy {
+	Policy := RetryPolicy{
+		Attempts:  viper.GetInt("services." + Service + ".retry.attempts"),
+		BaseDelay: viper.GetDuration("services." + Service + ".retry.base_delay"),
+		MaxDelay:  viper.GetDuration("services." + Service + ".retry.max_delay"),
+	}
+	if Policy.Attempts == 0 {
+		Policy.Attempts = 3
+	}
+	if Policy.BaseDelay == 0 {
+		Policy.BaseDelay = time.Millisecond * 100
+	}
+	if Policy.MaxDelay == 0 {
+		Policy.MaxDelay = time.Second
+	}
+
+	return Policy
+}
+
+// Delay func, the wait before the attempt after Attempt (starting at 1), with
+// jitter so the callers do not retry all at once
+func (p RetryPolicy) Delay(Attempt int) time.Duration {
+	Delay := p.BaseDelay
+	for i := 1; i < Attempt && Delay < p.MaxDelay; i++ {
+		Delay *= 2
+	}
+	if Delay > p.MaxDelay {
+		Delay = p.MaxDelay
+	}
+
+	return Delay/2 + time.Duration(rand.Int63n(int64(Delay/2)+1))
+}
+
+// Retry func retry the idempotent calls (GET and HEAD) that could not reach the
+// service or got a 502, 503 or 504. The calls are never retried past the
+// deadline of the request or while the breaker is open
+func Retry(Policy RetryPolicy) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (res *http.Response, err error) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return Next.RoundTrip(req)
+			}
+
+			for Attempt := 1; ; Attempt++ {
+				res, err = Next.RoundTrip(req)
+				if Attempt >= Policy.Attempts || !retryable(res, err) {
+					return
+				}
+
+				Delay := Policy.Delay(Attempt)
+				log.WithFields(log.Fields{
+					"event":   "retry call to service",
+					"method":  req.Method,
+					"path":    req.URL.Path,
+					"attempt": Attempt,
+					"delay":   Delay.String(),
+				}).Warn(retryReason(res, err))
+
+				if res != nil {
+					io.Copy(ioutil.Discard, res.Body)
+					res.Body.Close()
+				}
+
+				Timer := time.NewTimer(Delay)
+				select {
+				case <-req.Context().Done():
+					Timer.Stop()
+					return nil, req.Context().Err()
+				case <-Timer.C:
+				}
+			}
+		})
+	}
+}
+
+// retryable func
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) &&
+			!errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryReason func
+func retryReason(res *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return res.Status
+}
@@ -0,0 +1,47 @@
+package serviceclient
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/mrdhira/warpin-test/pkg/server"
+	"github.com/spf13/viper"
+)
+
+// TLSTransport func, the transport of Service with services.<service>.tls:
+// ca_file verify the certificate of the service (the system roots otherwise)
+// and cert_file / key_file is the client certificate sent to the internal
+// routes of a service that ask one. The client certificate is read again on
+// server.Reload. DefaultTransport when nothing is configured
+func TLSTransport(Service string) (http.RoundTripper, error) {
+	Prefix := "services." + Service + ".tls."
+	CAFile := viper.GetString(Prefix + "ca_file")
+	CertFile := viper.GetString(Prefix + "cert_file")
+	KeyFile := viper.GetString(Prefix + "key_file")
+	if CAFile == "" && CertFile == "" {
+		return DefaultTransport, nil
+	}
+
+	TLSConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if CAFile != "" {
+		Pool, err := server.LoadCertPool(CAFile)
+		if err != nil {
+			return nil, err
+		}
+		TLSConfig.RootCAs = Pool
+	}
+	if CertFile != "" {
+		KeyPair, err := server.LoadKeyPair(CertFile, KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		TLSConfig.GetClientCertificate = KeyPair.GetClientCertificate
+	}
+
+	Transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if Default, ok := DefaultTransport.(*http.Transport); ok {
+		Transport = Default.Clone()
+	}
+	Transport.TLSClientConfig = TLSConfig
+	return Transport, nil
+}
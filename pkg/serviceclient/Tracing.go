@@ -0,0 +1,34 @@
+package serviceclient
+
+import (
+	"net/http"
+
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+)
+
+// Tracing func start a client span for every call to Service and send its
+// traceparent, so the spans of Service are children of it
+func Tracing(Service string) Middleware {
+	return func(Next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, Span := tracing.StartKind(req.Context(), req.Method+" "+Service+" services", tracing.KindClient)
+			defer Span.End()
+			Span.SetAttribute("http.method", req.Method)
+			Span.SetAttribute("http.url", req.URL.Path)
+			Span.SetAttribute("peer.service", Service)
+
+			// a round tripper must not modify the request it was given
+			req = req.Clone(ctx)
+			tracing.Inject(ctx, req.Header)
+
+			res, err := Next.RoundTrip(req)
+			if err != nil {
+				Span.RecordError(err)
+				return nil, err
+			}
+
+			Span.SetAttribute("http.status_code", res.StatusCode)
+			return res, nil
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Exporter interface, where the ended spans go. Export is called by End on
+// the goroutine of the request so it must be quick
+type Exporter interface {
+	Export(Span *Span) error
+}
+
+// ExporterFunc type
+type ExporterFunc func(Span *Span) error
+
+// Export func
+func (f ExporterFunc) Export(Span *Span) error {
+	return f(Span)
+}
+
+// Initialize Variable
+var (
+	exporters = map[string]func() (Exporter, error){
+		"none":   func() (Exporter, error) { return nil, nil },
+		"stdout": func() (Exporter, error) { return NewWriterExporter(os.Stdout), nil },
+		"file": func() (Exporter, error) {
+			File := viper.GetString("tracing.file")
+			if File == "" {
+				File = "traces.jsonl"
+			}
+			return NewFileExporter(File)
+		},
+	}
+	current     Exporter
+	currentSet  bool
+	exporterMu  sync.RWMutex
+	exportersMu sync.Mutex
+)
+
+// RegisterExporter func make the exporter Name available to tracing.exporter,
+// New is called once on the first span
+func RegisterExporter(Name string, New func() (Exporter, error)) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[Name] = New
+}
+
+// SetExporter func replace the exporter of this process, nil stop the export
+func SetExporter(Exporter Exporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	current = Exporter
+	currentSet = true
+}
+
+// exporter func, the exporter of tracing.exporter built on the first span.
+// An unknown exporter is logged and nothing is exported
+func exporter() Exporter {
+	exporterMu.RLock()
+	if currentSet {
+		defer exporterMu.RUnlock()
+		return current
+	}
+	exporterMu.RUnlock()
+
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	if currentSet {
+		return current
+	}
+	currentSet = true
+
+	Name := viper.GetString("tracing.exporter")
+	if Name == "" {
+		Name = "none"
+	}
+
+	exportersMu.Lock()
+	New, ok := exporters[Name]
+	exportersMu.Unlock()
+	if !ok {
+		log.WithFields(log.Fields{
+			"event": "error when build tracing exporter",
+		}).Error(fmt.Errorf("unknown tracing exporter %q", Name))
+		return nil
+	}
+
+	Exporter, err := New()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"event":    "error when build tracing exporter",
+			"exporter": Name,
+		}).Error(err)
+		return nil
+	}
+	current = Exporter
+	return current
+}
+
+// export func
+func export(Span *Span) {
+	Exporter := exporter()
+	if Exporter == nil {
+		return
+	}
+
+	if err := Exporter.Export(Span); err != nil {
+		log.WithFields(log.Fields{
+			"event": "error when export span",
+		}).Error(err)
+	}
+}
+
+// sampleRatio func, tracing.sample_ratio (1 when not configured) of the new traces
+func sampleRatio() float64 {
+	if !viper.IsSet("tracing.sample_ratio") {
+		return 1
+	}
+	return viper.GetFloat64("tracing.sample_ratio")
+}
+
+// WriterExporter struct write every span as a JSON line to Writer
+type WriterExporter struct {
+	Writer io.Writer
+	mu     sync.Mutex
+}
+
+// NewWriterExporter func
+func NewWriterExporter(Writer io.Writer) *WriterExporter {
+	return &WriterExporter{Writer: Writer}
+}
+
+// Export func
+func (e *WriterExporter) Export(Span *Span) error {
+	Line, err := json.Marshal(Span)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.Writer.Write(append(Line, '\n'))
+	return err
+}
+
+// NewFileExporter func append the spans as JSON lines to File
+func NewFileExporter(File string) (*WriterExporter, error) {
+	Writer, err := os.OpenFile(File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterExporter(Writer), nil
+}
+
+// MemoryExporter struct keep the spans, for the tests
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewMemoryExporter func
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+// Export func
+func (e *MemoryExporter) Export(Span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, Span)
+	return nil
+}
+
+// Spans func, the spans of the trace TraceID (every span when it is empty)
+func (e *MemoryExporter) Spans(TraceID string) (Spans []*Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, Span := range e.spans {
+		if TraceID == "" || Span.TraceID == TraceID {
+			Spans = append(Spans, Span)
+		}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/gorilla/mux"
+)
+
+// TraceparentHeader of the W3C trace context
+const TraceparentHeader = "traceparent"
+
+// Middleware func start a server span for every request of the router of
+// Service, child of the traceparent of the caller when there is one. The
+// span is named by the route template so the traces group by endpoint
+func Middleware(Service string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			ctx := WithService(req.Context(), Service)
+			if Parent, ok := ParseTraceparent(req.Header.Get(TraceparentHeader)); ok {
+				ctx = WithRemote(ctx, Parent)
+			}
+
+			Route := req.URL.Path
+			if Current := mux.CurrentRoute(req); Current != nil {
+				if Template, err := Current.GetPathTemplate(); err == nil {
+					Route = Template
+				}
+			}
+
+			ctx, Span := StartKind(ctx, req.Method+" "+Route, KindServer)
+			defer Span.End()
+			Span.SetAttribute("http.method", req.Method)
+			Span.SetAttribute("http.route", Route)
+
+			Recorder := &statusRecorder{ResponseWriter: res, Status: http.StatusOK}
+			next.ServeHTTP(Recorder, req.WithContext(ctx))
+
+			Span.SetAttribute("http.status_code", Recorder.Status)
+			if Recorder.Status >= 500 {
+				Span.RecordError(errors.New(http.StatusText(Recorder.Status)))
+			}
+		})
+	}
+}
+
+// Inject func set the traceparent of the span of ctx on Header
+func Inject(ctx context.Context, Header http.Header) {
+	if Span := FromContext(ctx); Span != nil {
+		Header.Set(TraceparentHeader, Span.Context().Traceparent())
+	}
+}
+
+// statusRecorder struct keep the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// WriteHeader func
+func (r *statusRecorder) WriteHeader(Status int) {
+	r.Status = Status
+	r.ResponseWriter.WriteHeader(Status)
+}
+
+// DBReceiver struct, a dbr.EventReceiver that start a span for every query
+// and give the events to Next. The values of the query are not recorded, only
+// its operation, as they can hold personal data
+type DBReceiver struct {
+	dbr.EventReceiver
+}
+
+// NewDBReceiver func, Next receive the events (e.g. the metrics), can be nil
+func NewDBReceiver(Next dbr.EventReceiver) *DBReceiver {
+	if Next == nil {
+		Next = &dbr.NullEventReceiver{}
+	}
+	return &DBReceiver{EventReceiver: Next}
+}
+
+// SpanStart func
+func (r *DBReceiver) SpanStart(ctx context.Context, EventName string, Query string) context.Context {
+	ctx, Span := StartKind(ctx, EventName, KindClient)
+	Span.SetAttribute("db.system", "postgresql")
+	if Fields := strings.Fields(Query); len(Fields) > 0 {
+		Span.SetAttribute("db.operation", strings.ToUpper(Fields[0]))
+	}
+	return ctx
+}
+
+// SpanError func
+func (r *DBReceiver) SpanError(ctx context.Context, err error) {
+	if Span := FromContext(ctx); Span != nil {
+		Span.RecordError(err)
+	}
+}
+
+// SpanFinish func
+func (r *DBReceiver) SpanFinish(ctx context.Context) {
+	if Span := FromContext(ctx); Span != nil {
+		Span.End()
+	}
+}
@@ -0,0 +1,255 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds
+const (
+	KindServer   = "server"
+	KindClient   = "client"
+	KindInternal = "internal"
+)
+
+// Span status
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// TraceID [16]byte
+type TraceID [16]byte
+
+// String func
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid func, an all zero trace id is invalid
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID [8]byte
+type SpanID [8]byte
+
+// String func
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid func, an all zero span id is invalid
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext struct, what is sent to the other services in traceparent
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid func
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// Traceparent func, the W3C trace context header e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (c SpanContext) Traceparent() string {
+	Flags := "00"
+	if c.Sampled {
+		Flags = "01"
+	}
+	return "00-" + c.TraceID.String() + "-" + c.SpanID.String() + "-" + Flags
+}
+
+// ParseTraceparent func read a W3C traceparent header, ok is false when it is
+// missing or invalid and a new trace must start
+func ParseTraceparent(Header string) (c SpanContext, ok bool) {
+	Parts := strings.Split(strings.TrimSpace(Header), "-")
+	if len(Parts) < 4 || len(Parts[0]) != 2 || Parts[0] == "ff" {
+		return c, false
+	}
+	// version 00 has exactly four parts, later versions may add some
+	if Parts[0] == "00" && len(Parts) != 4 {
+		return c, false
+	}
+
+	if len(Parts[1]) != 32 || len(Parts[2]) != 16 || len(Parts[3]) != 2 {
+		return c, false
+	}
+	if _, err := hex.Decode(c.TraceID[:], []byte(Parts[1])); err != nil {
+		return c, false
+	}
+	if _, err := hex.Decode(c.SpanID[:], []byte(Parts[2])); err != nil {
+		return c, false
+	}
+	Flags, err := hex.DecodeString(Parts[3])
+	if err != nil {
+		return c, false
+	}
+
+	c.Sampled = Flags[0]&0x01 == 0x01
+	return c, c.IsValid()
+}
+
+// Span struct, one operation of a trace. It is exported by End
+type Span struct {
+	Name       string            `json:"name"`
+	Service    string            `json:"service"`
+	Kind       string            `json:"kind"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	StartedAt  time.Time         `json:"start"`
+	EndedAt    time.Time         `json:"end"`
+	DurationMs float64           `json:"duration_ms"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	context SpanContext
+	mu      sync.Mutex
+	ended   bool
+}
+
+// Context func
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetAttribute func
+func (s *Span) SetAttribute(Key string, Value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = map[string]string{}
+	}
+	s.Attributes[Key] = fmt.Sprint(Value)
+}
+
+// RecordError func mark the span as failed with err
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = StatusError
+	s.Error = err.Error()
+}
+
+// Finish func, for a defer: record *err when it is set then end the span
+func (s *Span) Finish(err *error) {
+	if err != nil {
+		s.RecordError(*err)
+	}
+	s.End()
+}
+
+// End func end the span and export it when it is sampled, a span end once
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndedAt = time.Now()
+	s.DurationMs = float64(s.EndedAt.Sub(s.StartedAt)) / float64(time.Millisecond)
+	s.mu.Unlock()
+
+	if s.context.Sampled {
+		export(s)
+	}
+}
+
+// contextKey type
+type contextKey int
+
+// Context keys
+const (
+	spanKey contextKey = iota
+	remoteKey
+	serviceKey
+)
+
+// WithService func, the spans started from ctx are of Service
+func WithService(ctx context.Context, Service string) context.Context {
+	return context.WithValue(ctx, serviceKey, Service)
+}
+
+// WithRemote func, the spans started from ctx are children of the span of
+// another service (read from its traceparent)
+func WithRemote(ctx context.Context, Parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, Parent)
+}
+
+// FromContext func, the current span of ctx or nil
+func FromContext(ctx context.Context) *Span {
+	Span, _ := ctx.Value(spanKey).(*Span)
+	return Span
+}
+
+// Start func start an internal span, child of the span of ctx
+func Start(ctx context.Context, Name string) (context.Context, *Span) {
+	return StartKind(ctx, Name, KindInternal)
+}
+
+// StartKind func start a span of Kind, child of the span of ctx or of its
+// remote parent. Without a parent a new trace start, sampled with
+// tracing.sample_ratio
+func StartKind(ctx context.Context, Name string, Kind string) (context.Context, *Span) {
+	Span := &Span{
+		Name:      Name,
+		Kind:      Kind,
+		StartedAt: time.Now(),
+		Status:    StatusOK,
+	}
+	Span.Service, _ = ctx.Value(serviceKey).(string)
+
+	var Parent SpanContext
+	if ParentSpan := FromContext(ctx); ParentSpan != nil {
+		Parent = ParentSpan.context
+		if Span.Service == "" {
+			Span.Service = ParentSpan.Service
+		}
+	} else if Remote, ok := ctx.Value(remoteKey).(SpanContext); ok {
+		Parent = Remote
+	}
+
+	if Parent.IsValid() {
+		Span.context.TraceID = Parent.TraceID
+		Span.context.Sampled = Parent.Sampled
+		Span.ParentID = Parent.SpanID.String()
+	} else {
+		rand.Read(Span.context.TraceID[:])
+		Span.context.Sampled = sample(Span.context.TraceID)
+	}
+	rand.Read(Span.context.SpanID[:])
+
+	Span.TraceID = Span.context.TraceID.String()
+	Span.SpanID = Span.context.SpanID.String()
+	return context.WithValue(ctx, spanKey, Span), Span
+}
+
+// sample func, the decision is made from the trace id so it is stable
+func sample(Trace TraceID) bool {
+	Ratio := sampleRatio()
+	if Ratio >= 1 {
+		return true
+	}
+	if Ratio <= 0 {
+		return false
+	}
+	return float64(binary.BigEndian.Uint64(Trace[8:])>>1)/float64(1<<63) < Ratio
+}
@@ -0,0 +1,93 @@
+package transaction
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	dbr "github.com/gocraft/dbr/v2"
+)
+
+// Tx interface, a transaction begun by a repository. The usecases only commit
+// or roll it back and hand it to the repository calls that must be in it, what
+// is inside belong to the repositories: a *dbr.Tx for the postgres ones and a
+// *Memory for the in-memory ones
+type Tx interface {
+	Commit() error
+	Rollback() error
+	RollbackUnlessCommitted()
+}
+
+// Dbr func, the *dbr.Tx of a transaction begun by a postgres repository.
+// Mixing the transaction of an in-memory repository with a postgres one is a
+// bug so it panic
+func Dbr(Tx Tx) *dbr.Tx {
+	db, ok := Tx.(*dbr.Tx)
+	if !ok {
+		panic(fmt.Sprintf("transaction: %T is not a postgres transaction", Tx))
+	}
+	return db
+}
+
+// Memory struct, the transaction of the in-memory repositories. Their writes
+// are applied right away and register how to undo them with OnRollback, a
+// rollback undo them from the last one
+type Memory struct {
+	mu   sync.Mutex
+	undo []func()
+	done bool
+}
+
+// NewMemory func
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// OnRollback func
+func (m *Memory) OnRollback(Undo func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.undo = append(m.undo, Undo)
+}
+
+// Commit func
+func (m *Memory) Commit() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return sql.ErrTxDone
+	}
+	m.done = true
+	m.undo = nil
+	return nil
+}
+
+// Rollback func
+func (m *Memory) Rollback() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return sql.ErrTxDone
+	}
+	m.done = true
+	for i := len(m.undo) - 1; i >= 0; i-- {
+		m.undo[i]()
+	}
+	m.undo = nil
+	return nil
+}
+
+// RollbackUnlessCommitted func
+func (m *Memory) RollbackUnlessCommitted() {
+	m.Rollback()
+}
+
+// AsMemory func, the *Memory of a transaction begun by an in-memory repository,
+// see Dbr
+func AsMemory(Tx Tx) *Memory {
+	m, ok := Tx.(*Memory)
+	if !ok {
+		panic(fmt.Sprintf("transaction: %T is not an in-memory transaction", Tx))
+	}
+	return m
+}
@@ -0,0 +1,636 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+)
+
+// ordersResponse struct, the order as sent by orders services
+type ordersResponse struct {
+	ID         int         `json:"id"`
+	UserID     int         `json:"user_id"`
+	TotalPrice json.Number `json:"total_price"`
+	Status     int         `json:"status"`
+	Items      []struct {
+		ProductID     int         `json:"product_id"`
+		ReservationID string      `json:"reservation_id"`
+		ProductName   string      `json:"product_name"`
+		Price         json.Number `json:"price"`
+		Qty           int         `json:"qty"`
+		Status        int         `json:"status"`
+	} `json:"items"`
+}
+
+// order func create an order of Qty of the product, it return the order
+func order(t *testing.T, Token string, ProductID int, Qty int) *ordersResponse {
+	t.Helper()
+	Response := expect(t, 200, http.MethodPost, services.Orders.URL+"/orders/", Token, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": Qty}},
+	})
+
+	Orders := &ordersResponse{}
+	Response.Data(t, Orders)
+	return Orders
+}
+
+// ordersOf func, the orders of the users of Token
+func ordersOf(t *testing.T, Token string) (Orders []*ordersResponse) {
+	t.Helper()
+	expect(t, 200, http.MethodGet, services.Orders.URL+"/orders/?limit=10&offset=0", Token, nil).Data(t, &Orders)
+	return
+}
+
+func TestOrderApproveFlow(t *testing.T) {
+	Admin := users(t, "admin.approve@mail.com", "ADMIN")
+	Customer := users(t, "customer.approve@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Approve", 10)
+
+	// browse
+	var Products []struct {
+		ID    int         `json:"id"`
+		Name  string      `json:"name"`
+		Price json.Number `json:"price"`
+	}
+	expect(t, 200, http.MethodGet, services.Products.URL+"/products/?limit=100&offset=0", "", nil).Data(t, &Products)
+	Found := false
+	for _, Product := range Products {
+		Found = Found || Product.ID == ProductID
+	}
+	if !Found {
+		t.Fatalf("product %d is not listed", ProductID)
+	}
+
+	// order, the name and price come from products services
+	Orders := order(t, Customer, ProductID, 3)
+	if Orders.Status != 1 || len(Orders.Items) != 1 || Orders.TotalPrice != "45000.00" {
+		t.Fatalf("order = %+v", Orders)
+	}
+	if Item := Orders.Items[0]; Item.ProductName != "Kopi Approve" || Item.Price != "15000.00" || Item.ReservationID == "" {
+		t.Fatalf("order item = %+v", Item)
+	}
+	if Qty := stock(t, ProductID); Qty != 7 {
+		t.Fatalf("stock after order = %d, want 7", Qty)
+	}
+
+	// a customer is not an admin
+	expect(t, 403, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/approve", services.Orders.URL, Orders.ID), Customer, nil)
+
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/approve", services.Orders.URL, Orders.ID), Admin, nil)
+	expect(t, 422, http.MethodPut, fmt.Sprintf("%s/orders/%d/cancel", services.Orders.URL, Orders.ID), Customer, nil)
+
+	if Qty := stock(t, ProductID); Qty != 7 {
+		t.Fatalf("stock after approve = %d, want 7", Qty)
+	}
+	Listed := ordersOf(t, Customer)
+	if len(Listed) != 1 || Listed[0].Status != 2 || Listed[0].Items[0].Status != 2 {
+		t.Fatalf("orders of customer = %+v", Listed)
+	}
+
+	// the reservation of the approved order is kept for good
+	Reservation := services.StockReservationsRepository.StockReservations[Orders.Items[0].ReservationID]
+	if Reservation == nil || Reservation.Qty != 3 || Reservation.Status != 2 {
+		t.Fatalf("reservation = %+v", Reservation)
+	}
+}
+
+func TestOrderCancelFlow(t *testing.T) {
+	Admin := users(t, "admin.cancel@mail.com", "ADMIN")
+	Customer := users(t, "customer.cancel@mail.com", "CUSTOMER")
+	Other := users(t, "other.cancel@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Cancel", 10)
+
+	Orders := order(t, Customer, ProductID, 4)
+	if Qty := stock(t, ProductID); Qty != 6 {
+		t.Fatalf("stock after order = %d, want 6", Qty)
+	}
+
+	// more than the stock left
+	expect(t, 422, http.MethodPost, services.Orders.URL+"/orders/", Other, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": 7}},
+	})
+
+	// the update move the reservation
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/%d", services.Orders.URL, Orders.ID), Customer, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": 5}},
+	})
+	if Qty := stock(t, ProductID); Qty != 5 {
+		t.Fatalf("stock after update = %d, want 5", Qty)
+	}
+
+	expect(t, 403, http.MethodPut, fmt.Sprintf("%s/orders/%d/cancel", services.Orders.URL, Orders.ID), Other, nil)
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/%d/cancel", services.Orders.URL, Orders.ID), Customer, nil)
+	expect(t, 422, http.MethodPut, fmt.Sprintf("%s/orders/%d/cancel", services.Orders.URL, Orders.ID), Customer, nil)
+	expect(t, 422, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/approve", services.Orders.URL, Orders.ID), Admin, nil)
+
+	if Qty := stock(t, ProductID); Qty != 10 {
+		t.Fatalf("stock after cancel = %d, want 10", Qty)
+	}
+	if Listed := ordersOf(t, Customer); len(Listed) != 1 || Listed[0].Status != 4 {
+		t.Fatalf("orders of customer = %+v", Listed)
+	}
+}
+
+func TestOrderRejectFlow(t *testing.T) {
+	Admin := users(t, "admin.reject@mail.com", "ADMIN")
+	Customer := users(t, "customer.reject@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Reject", 10)
+
+	First := order(t, Customer, ProductID, 2)
+	Second := order(t, Customer, ProductID, 3)
+	if Qty := stock(t, ProductID); Qty != 5 {
+		t.Fatalf("stock after orders = %d, want 5", Qty)
+	}
+
+	// the other services only list the orders, they never approve or reject one
+	Service, err := auth.IssueServiceToken("products", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ServiceHeader := http.Header{"Authorization": {Service}}
+	for _, Event := range []string{"approve", "reject"} {
+		Response := call(t, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/%s", services.Orders.URL, First.ID, Event), "", nil, ServiceHeader)
+		if Response.Status != 403 {
+			t.Fatalf("%s by a service = %d, want 403", Event, Response.Status)
+		}
+	}
+	if Response := call(t, http.MethodGet, services.Orders.URL+"/orders/internal/?limit=10&offset=0", "", nil, ServiceHeader); Response.Status != 200 {
+		t.Fatalf("list by a service = %d %q, want 200", Response.Status, Response.Message())
+	}
+
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/reject", services.Orders.URL, First.ID), Admin, nil)
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/approve", services.Orders.URL, Second.ID), Admin, nil)
+
+	if Qty := stock(t, ProductID); Qty != 7 {
+		t.Fatalf("stock after reject and approve = %d, want 7", Qty)
+	}
+}
+
+func TestProductDeactivateFlow(t *testing.T) {
+	Admin := users(t, "admin.deactivate@mail.com", "ADMIN")
+	Customer := users(t, "customer.deactivate@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Deactivate", 10)
+	URL := fmt.Sprintf("%s/products/internal/%d", services.Products.URL, ProductID)
+
+	// the other services only reserve the stock, they never add or update a product
+	Service, err := auth.IssueServiceToken("orders", "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ServiceHeader := http.Header{"Authorization": {Service}}
+	if Response := call(t, http.MethodPut, URL, "", map[string]interface{}{"qty": 100}, ServiceHeader); Response.Status != 403 {
+		t.Fatalf("update by a service = %d, want 403", Response.Status)
+	}
+	if Response := call(t, http.MethodPost, services.Products.URL+"/products/internal/add", "", map[string]interface{}{"name": "Kopi Service", "price": 1000, "qty": 1}, ServiceHeader); Response.Status != 403 {
+		t.Fatalf("add by a service = %d, want 403", Response.Status)
+	}
+	if Response := call(t, http.MethodGet, services.Products.URL+"/products/internal/reservations/unknown", "", nil, ServiceHeader); Response.Status != 404 {
+		t.Fatalf("reservation by a service = %d %q, want 404", Response.Status, Response.Message())
+	}
+
+	// products services ask orders services for the pending orders
+	Orders := order(t, Customer, ProductID, 1)
+	expect(t, 422, http.MethodPut, URL, Admin, map[string]interface{}{"status": 2})
+
+	expect(t, 200, http.MethodPut, fmt.Sprintf("%s/orders/internal/%d/approve", services.Orders.URL, Orders.ID), Admin, nil)
+	expect(t, 200, http.MethodPut, URL, Admin, map[string]interface{}{"status": 2})
+
+	// an inactive product can not be ordered
+	expect(t, 422, http.MethodPost, services.Orders.URL+"/orders/", Customer, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": 1}},
+	})
+	if Qty := stock(t, ProductID); Qty != 9 {
+		t.Fatalf("stock = %d, want 9", Qty)
+	}
+}
+
+func TestErrorsFlow(t *testing.T) {
+	Admin := users(t, "admin.errors@mail.com", "ADMIN")
+	Customer := users(t, "customer.errors@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Errors", 10)
+
+	// a token signed by users services but without jti can not be revoked
+	SigningKeys, err := auth.DefaultSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithoutID, err := SigningKeys.Sign(jwt.MapClaims{
+		"user_id":   1,
+		"user_role": "ADMIN",
+		"exp":       time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Tests := []struct {
+		Name      string
+		Method    string
+		URL       string
+		Token     string
+		Payload   interface{}
+		Status    int
+		ErrorCode string
+	}{
+		{
+			Name:      "unknown product",
+			Method:    http.MethodGet,
+			URL:       services.Products.URL + "/products/999999",
+			Status:    404,
+			ErrorCode: "product_not_found",
+		},
+		{
+			Name:   "order of an unknown product",
+			Method: http.MethodPost,
+			URL:    services.Orders.URL + "/orders/",
+			Token:  Customer,
+			Payload: map[string]interface{}{
+				"items": []map[string]interface{}{{"product_id": ProductID, "qty": 1}, {"product_id": 999999, "qty": 1}},
+			},
+			Status:    404,
+			ErrorCode: "product_not_found",
+		},
+		{
+			Name:      "unknown order",
+			Method:    http.MethodPut,
+			URL:       services.Orders.URL + "/orders/999999/cancel",
+			Token:     Customer,
+			Status:    404,
+			ErrorCode: "order_not_found",
+		},
+		{
+			Name:   "email already registered",
+			Method: http.MethodPost,
+			URL:    services.Users.URL + "/users/register",
+			Payload: map[string]interface{}{
+				"email":     "customer.errors@mail.com",
+				"full_name": "Budi",
+				"gender":    1,
+				"role":      "CUSTOMER",
+				"password":  "secret",
+			},
+			Status:    409,
+			ErrorCode: "email_registered",
+		},
+		{
+			Name:      "customer on an internal route",
+			Method:    http.MethodPut,
+			URL:       fmt.Sprintf("%s/products/internal/%d", services.Products.URL, ProductID),
+			Token:     Customer,
+			Payload:   map[string]interface{}{"qty": 1},
+			Status:    403,
+			ErrorCode: "admin_only",
+		},
+		{
+			Name:      "token without id",
+			Method:    http.MethodGet,
+			URL:       services.Orders.URL + "/orders/internal/?limit=10&offset=0",
+			Token:     WithoutID,
+			Status:    401,
+			ErrorCode: "token_invalid",
+		},
+		{
+			Name:      "no token",
+			Method:    http.MethodGet,
+			URL:       services.Users.URL + "/users/profile",
+			Status:    401,
+			ErrorCode: "token_invalid",
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			Response := expect(t, Test.Status, Test.Method, Test.URL, Test.Token, Test.Payload)
+			if ErrorCode := Response.ErrorCode(); ErrorCode != Test.ErrorCode {
+				t.Fatalf("error_code = %q, want %q", ErrorCode, Test.ErrorCode)
+			}
+		})
+	}
+
+	if Qty := stock(t, ProductID); Qty != 10 {
+		t.Fatalf("stock after the failed order = %d, want 10", Qty)
+	}
+
+	// the validation errors say which field is wrong
+	Response := expect(t, 422, http.MethodPost, services.Users.URL+"/users/login", "", map[string]interface{}{
+		"email": "customer.errors@mail.com",
+	})
+	var Details []struct {
+		Field string `json:"field"`
+		Code  string `json:"code"`
+	}
+	json.Unmarshal(Response.Body["details"], &Details)
+	if Response.ErrorCode() != "payload_invalid" || len(Details) != 1 || Details[0].Field != "password" || Details[0].Code != "required" {
+		t.Fatalf("validation error = %q %+v", Response.ErrorCode(), Details)
+	}
+}
+
+func TestI18nFlow(t *testing.T) {
+	Admin := users(t, "admin.i18n@mail.com", "ADMIN")
+	Customer := users(t, "customer.i18n@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi I18n", 10)
+
+	English := http.Header{"Accept-Language": {"en-US,en;q=0.9,id;q=0.8"}}
+	Indonesian := http.Header{"Accept-Language": {"fr, id;q=0.5"}}
+
+	Tests := []struct {
+		Name    string
+		Method  string
+		URL     string
+		Token   string
+		Payload interface{}
+		Headers []http.Header
+		Status  int
+		Locale  string
+		Message string
+		Details string
+	}{
+		{
+			Name:    "english success",
+			Method:  http.MethodPost,
+			URL:     services.Users.URL + "/users/login",
+			Payload: map[string]interface{}{"email": "customer.i18n@mail.com", "password": "secret"},
+			Headers: []http.Header{English},
+			Status:  200,
+			Locale:  "en",
+			Message: "Logged in successfully",
+		},
+		{
+			Name:    "english error",
+			Method:  http.MethodPost,
+			URL:     services.Users.URL + "/users/login",
+			Payload: map[string]interface{}{"email": "customer.i18n@mail.com", "password": "wrong"},
+			Headers: []http.Header{English},
+			Status:  403,
+			Locale:  "en",
+			Message: "The password you entered is wrong",
+		},
+		{
+			Name:    "indonesian by default",
+			Method:  http.MethodPost,
+			URL:     services.Users.URL + "/users/login",
+			Payload: map[string]interface{}{"email": "customer.i18n@mail.com", "password": "wrong"},
+			Status:  403,
+			Locale:  "id",
+			Message: "Password yang anda masukkan salah",
+		},
+		{
+			Name:    "english validation",
+			Method:  http.MethodPost,
+			URL:     services.Users.URL + "/users/login",
+			Payload: map[string]interface{}{"email": "customer.i18n@mail.com"},
+			Headers: []http.Header{English},
+			Status:  422,
+			Locale:  "en",
+			Message: "The payload is not valid",
+			Details: "password is a required field",
+		},
+		{
+			Name:    "indonesian validation",
+			Method:  http.MethodPost,
+			URL:     services.Users.URL + "/users/login",
+			Payload: map[string]interface{}{"email": "customer.i18n@mail.com"},
+			Headers: []http.Header{Indonesian},
+			Status:  422,
+			Locale:  "id",
+			Message: "Payload tidak sesuai",
+			Details: "password wajib diisi",
+		},
+		{
+			// the locale is asked to products services too
+			Name:   "english message of another service",
+			Method: http.MethodPost,
+			URL:    services.Orders.URL + "/orders/",
+			Token:  Customer,
+			Payload: map[string]interface{}{
+				"items": []map[string]interface{}{{"product_id": ProductID, "qty": 1}, {"product_id": 999999, "qty": 1}},
+			},
+			Headers: []http.Header{English},
+			Status:  404,
+			Locale:  "en",
+			Message: "Product with ID 999999 not found",
+		},
+	}
+
+	for _, Test := range Tests {
+		t.Run(Test.Name, func(t *testing.T) {
+			Response := call(t, Test.Method, Test.URL, Test.Token, Test.Payload, Test.Headers...)
+			if Response.Status != Test.Status {
+				t.Fatalf("status = %d %q, want %d", Response.Status, Response.Message(), Test.Status)
+			}
+			if Locale := Response.Header.Get("Content-Language"); Locale != Test.Locale {
+				t.Errorf("Content-Language = %q, want %q", Locale, Test.Locale)
+			}
+			if Message := Response.Message(); Message != Test.Message {
+				t.Errorf("message = %q, want %q", Message, Test.Message)
+			}
+
+			if Test.Details == "" {
+				return
+			}
+			var Details []struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(Response.Body["details"], &Details)
+			if len(Details) != 1 || Details[0].Message != Test.Details {
+				t.Errorf("details = %+v, want %q", Details, Test.Details)
+			}
+		})
+	}
+}
+
+func TestLogoutFlow(t *testing.T) {
+	Customer := users(t, "customer.logout@mail.com", "CUSTOMER")
+
+	expect(t, 200, http.MethodGet, services.Users.URL+"/users/profile", Customer, nil)
+	expect(t, 200, http.MethodGet, services.Orders.URL+"/orders/?limit=10&offset=0", Customer, nil)
+	expect(t, 200, http.MethodPost, services.Users.URL+"/users/logout", Customer, nil)
+
+	// the revocation is seen by every service
+	expect(t, 401, http.MethodGet, services.Users.URL+"/users/profile", Customer, nil)
+	expect(t, 401, http.MethodGet, services.Orders.URL+"/orders/?limit=10&offset=0", Customer, nil)
+	expect(t, 401, http.MethodGet, services.Orders.URL+"/orders/?limit=10&offset=0", "", nil)
+}
+
+func TestHealthFlow(t *testing.T) {
+	for _, URL := range []string{services.Users.URL, services.Products.URL, services.Orders.URL} {
+		expect(t, 200, http.MethodGet, URL+"/healthz", "", nil)
+	}
+
+	// orders services is ready when products services is reachable, the checks
+	// only tell up or down
+	Ready := struct {
+		Status string                       `json:"status"`
+		Checks map[string]map[string]string `json:"checks"`
+	}{}
+	expect(t, 200, http.MethodGet, services.Orders.URL+"/readyz", "", nil).Data(t, &Ready)
+	if Products := Ready.Checks["products"]; Ready.Status != "up" || len(Products) != 1 || Products["status"] != "up" {
+		t.Fatalf("readyz = %+v, want products up", Ready)
+	}
+
+	// a draining service is not ready anymore but still alive
+	health.SetDraining(true)
+	defer health.SetDraining(false)
+	expect(t, 503, http.MethodGet, services.Products.URL+"/readyz", "", nil)
+	expect(t, 200, http.MethodGet, services.Products.URL+"/healthz", "", nil)
+
+	// in the language of the client
+	Response := call(t, http.MethodGet, services.Products.URL+"/readyz", "", nil, http.Header{"Accept-Language": {"en"}})
+	if Message := Response.Message(); Response.Status != 503 || Message != "The service is shutting down" {
+		t.Fatalf("readyz while draining = %d %q", Response.Status, Message)
+	}
+}
+
+func TestMetricsFlow(t *testing.T) {
+	Admin := users(t, "admin.metrics@mail.com", "ADMIN")
+	Customer := users(t, "customer.metrics@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Metrics", 10)
+	order(t, Customer, ProductID, 2)
+
+	res, err := http.Get(services.Orders.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	Body, _ := ioutil.ReadAll(res.Body)
+
+	for _, Series := range []string{
+		`http_requests_total{code="200",method="POST",route="/orders/",service="orders"}`,
+		`peer_requests_total{caller="orders",code="200",method="POST",service="products"}`,
+		`orders_events_total{event="create"}`,
+		`stock_changes_total{change="reserved"}`,
+	} {
+		if !strings.Contains(string(Body), Series) {
+			t.Errorf("/metrics has no %s", Series)
+		}
+	}
+}
+
+func TestTracingFlow(t *testing.T) {
+	Admin := users(t, "admin.tracing@mail.com", "ADMIN")
+	Customer := users(t, "customer.tracing@mail.com", "CUSTOMER")
+	ProductID := product(t, Admin, "Kopi Tracing", 10)
+
+	// orders services call products services within the trace of the caller
+	TraceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	Traceparent := http.Header{"Traceparent": {"00-" + TraceID + "-00f067aa0ba902b7-01"}}
+	Response := call(t, http.MethodPost, services.Orders.URL+"/orders/", Customer, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": 1}},
+	}, Traceparent)
+	if Response.Status != 200 {
+		t.Fatalf("create order = %d %q", Response.Status, Response.Message())
+	}
+
+	Span := tracedCall(t, TraceID, "products", "POST /products/internal/reservations", "orders")
+	if Span.Attributes["http.status_code"] != "200" {
+		t.Fatalf("reservation span = %+v", Span)
+	}
+
+	// and products services call orders services back
+	TraceID = "5bf92f3577b34da6a3ce929d0e0e4737"
+	Traceparent = http.Header{"Traceparent": {"00-" + TraceID + "-00f067aa0ba902b7-01"}}
+	Response = call(t, http.MethodPut, fmt.Sprintf("%s/products/internal/%d", services.Products.URL, ProductID), Admin, map[string]interface{}{"status": 2}, Traceparent)
+	if Response.Status != 422 {
+		t.Fatalf("deactivate with a pending order = %d %q", Response.Status, Response.Message())
+	}
+	tracedCall(t, TraceID, "orders", "GET /orders/internal/", "products")
+}
+
+// tracedCall func find the server span Name of Service in the trace, it must
+// be the child of a client span of Caller
+func tracedCall(t *testing.T, TraceID string, Service string, Name string, Caller string) *tracing.Span {
+	t.Helper()
+	Spans := spans.Spans(TraceID)
+	for _, Span := range Spans {
+		if Span.Service != Service || Span.Name != Name || Span.Kind != tracing.KindServer {
+			continue
+		}
+		for _, Parent := range Spans {
+			if Parent.SpanID == Span.ParentID && Parent.Service == Caller && Parent.Kind == tracing.KindClient {
+				return Span
+			}
+		}
+		t.Fatalf("span %s of %s is not the child of a client span of %s", Name, Service, Caller)
+	}
+	t.Fatalf("no span %s of %s in the trace %s (%d spans)", Name, Service, TraceID, len(Spans))
+	return nil
+}
+
+func TestLoggingFlow(t *testing.T) {
+	Password := "rahasia-logging-123"
+	RequestID := http.Header{"X-Request-Id": {"logging-register"}}
+	Response := call(t, http.MethodPost, services.Users.URL+"/users/register", "", map[string]interface{}{
+		"email":     "customer.logging@mail.com",
+		"full_name": "Budi",
+		"gender":    1,
+		"role":      "CUSTOMER",
+		"password":  Password,
+	}, RequestID)
+	if Response.Status != 200 {
+		t.Fatalf("register = %d %q", Response.Status, Response.Message())
+	}
+	if ID := Response.Header.Get("X-Request-ID"); ID != "logging-register" {
+		t.Fatalf("X-Request-ID = %q, want the one of the request", ID)
+	}
+
+	// the hash of the password is never sent
+	Users := map[string]json.RawMessage{}
+	Response.Data(t, &Users)
+	if _, ok := Users["password"]; ok {
+		t.Fatalf("register data has a password: %v", Users)
+	}
+
+	Response = expect(t, 200, http.MethodPost, services.Users.URL+"/users/login", "", map[string]interface{}{
+		"email":    "customer.logging@mail.com",
+		"password": Password,
+	})
+	if Response.Header.Get("X-Request-ID") == "" {
+		t.Fatal("no X-Request-ID given to a request without one")
+	}
+	Tokens := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	Response.Data(t, &Tokens)
+
+	Admin := users(t, "admin.logging@mail.com", "ADMIN")
+	ProductID := product(t, Admin, "Kopi Logging", 10)
+	Response = call(t, http.MethodPost, services.Orders.URL+"/orders/", Tokens.AccessToken, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": ProductID, "qty": 1}},
+	}, http.Header{"X-Request-Id": {"logging-order"}})
+	if Response.Status != 200 {
+		t.Fatalf("create order = %d %q", Response.Status, Response.Message())
+	}
+
+	Redacted, Services := false, map[string]bool{}
+	for _, Entry := range logs.AllEntries() {
+		Line, _ := Entry.String()
+		if strings.Contains(Line, Password) {
+			t.Fatalf("the password is logged: %s", Line)
+		}
+		switch Entry.Data["request_id"] {
+		case "logging-register":
+			if Body, ok := Entry.Data["body"].(string); ok && strings.Contains(Body, `"password":"[REDACTED]"`) {
+				Redacted = true
+			}
+		case "logging-order":
+			Services[fmt.Sprint(Entry.Data["service"])] = true
+		}
+	}
+	if !Redacted {
+		t.Error("no redacted body logged for the register request")
+	}
+
+	// the request id follow the call from orders to products services
+	if !Services["orders"] || !Services["products"] {
+		t.Errorf("services logging the order request = %v, want orders and products", Services)
+	}
+}
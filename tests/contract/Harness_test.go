@@ -0,0 +1,286 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	OrdersHttp "github.com/mrdhira/warpin-test/api/Orders/deliveries/http"
+	OrdersMemory "github.com/mrdhira/warpin-test/api/Orders/infrastructures/memory"
+	OrdersRepositories "github.com/mrdhira/warpin-test/api/Orders/infrastructures/repositories"
+	OrdersUsecases "github.com/mrdhira/warpin-test/api/Orders/usecases"
+	ProductsHttp "github.com/mrdhira/warpin-test/api/Products/deliveries/http"
+	ProductsMemory "github.com/mrdhira/warpin-test/api/Products/infrastructures/memory"
+	ProductsRepositories "github.com/mrdhira/warpin-test/api/Products/infrastructures/repositories"
+	ProductsUsecases "github.com/mrdhira/warpin-test/api/Products/usecases"
+	UsersHttp "github.com/mrdhira/warpin-test/api/Users/deliveries/http"
+	UsersMemory "github.com/mrdhira/warpin-test/api/Users/infrastructures/memory"
+	UsersUsecases "github.com/mrdhira/warpin-test/api/Users/usecases"
+	"github.com/mrdhira/warpin-test/pkg/auth"
+	"github.com/mrdhira/warpin-test/pkg/health"
+	"github.com/mrdhira/warpin-test/pkg/serviceclient"
+	"github.com/mrdhira/warpin-test/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+)
+
+// Services struct, the three services booted from their Route.Init() on
+// httptest servers with their storage in memory. They call each other through
+// services.<service>.url like in docker-compose
+type Services struct {
+	Users    *httptest.Server
+	Products *httptest.Server
+	Orders   *httptest.Server
+
+	UsersRepository             *UsersMemory.UsersRepository
+	ProductsRepository          *ProductsMemory.ProductsRepository
+	StockReservationsRepository *ProductsMemory.StockReservationsRepository
+	OrdersRepository            *OrdersMemory.OrdersRepository
+	SagasRepository             *OrdersMemory.SagasRepository
+}
+
+// spans exported by the three services
+var spans = tracing.NewMemoryExporter()
+
+// logs of the three services, they are not printed
+var logs *test.Hook
+
+// services is shared by every test, the JWKS of users services is fetched once per process
+var services *Services
+
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+	logs = test.NewGlobal()
+
+	services = bootServices()
+	Code := m.Run()
+	services.Close()
+
+	os.Exit(Code)
+}
+
+// bootServices func start the servers first so every url is known before the
+// routers and the clients between the services are built
+func bootServices() *Services {
+	s := &Services{}
+	UsersHandler, ProductsHandler, OrdersHandler := &handler{}, &handler{}, &handler{}
+	s.Users = httptest.NewServer(UsersHandler)
+	s.Products = httptest.NewServer(ProductsHandler)
+	s.Orders = httptest.NewServer(OrdersHandler)
+
+	viper.Set("services.users.url", s.Users.URL)
+	viper.Set("services.products.url", s.Products.URL)
+	viper.Set("services.orders.url", s.Orders.URL)
+	viper.Set("auth.services.products.secret", "products-services-secret")
+	viper.Set("auth.services.products.allowed_callers", []string{"orders"})
+	viper.Set("auth.services.orders.secret", "orders-services-secret")
+	viper.Set("auth.services.orders.allowed_callers", []string{"products"})
+	viper.Set("money.currency", "IDR")
+	tracing.SetExporter(spans)
+
+	// one revocation list for the three services, as the redis of auth.redis
+	Revocations := auth.NewRevocations(auth.NewMemoryRevocations())
+
+	// Users Services
+	s.UsersRepository = UsersMemory.NewUsersRepository()
+	UsersRoute := &UsersHttp.Route{
+		Verifier:     auth.NewLocalVerifier(),
+		Revocations:  Revocations,
+		UsersUsecase: UsersUsecases.InitUsersUsecases(s.UsersRepository, UsersMemory.NewTokensRepository(), Revocations),
+	}
+	UsersHandler.Handler = UsersRoute.Init()
+
+	// Products Services
+	s.ProductsRepository = ProductsMemory.NewProductsRepository()
+	s.StockReservationsRepository = ProductsMemory.NewStockReservationsRepository()
+	ProductsRoute := &ProductsHttp.Route{
+		Health:          health.New("products").Add("orders", health.Service(serviceclient.New("orders"))),
+		Verifier:        auth.NewDefaultVerifier(),
+		Revocations:     Revocations,
+		ProductsUsecase: ProductsUsecases.InitProductsUsecases(s.ProductsRepository, ProductsRepositories.InitOrdersRepository(), s.StockReservationsRepository),
+	}
+	ProductsHandler.Handler = ProductsRoute.Init()
+
+	// Orders Services
+	s.OrdersRepository = OrdersMemory.NewOrdersRepository()
+	s.SagasRepository = OrdersMemory.NewSagasRepository()
+	ProductsRepository := OrdersRepositories.InitProductsRepository()
+	OrdersRoute := &OrdersHttp.Route{
+		Health:             health.New("orders").Add("products", health.Service(serviceclient.New("products"))),
+		Verifier:           auth.NewDefaultVerifier(),
+		Revocations:        Revocations,
+		OrdersUsecase:      OrdersUsecases.InitOrdersUsecases(s.OrdersRepository, ProductsRepository, OrdersUsecases.InitSagasUsecases(s.SagasRepository, ProductsRepository)),
+		IdempotencyUsecase: OrdersUsecases.InitIdempotencyUsecases(OrdersMemory.NewIdempotencyRepository()),
+	}
+	OrdersHandler.Handler = OrdersRoute.Init()
+
+	return s
+}
+
+// Close func
+func (s *Services) Close() {
+	s.Users.Close()
+	s.Products.Close()
+	s.Orders.Close()
+}
+
+// handler struct, the router is set once every server is listening
+type handler struct {
+	Handler http.Handler
+}
+
+// ServeHTTP func
+func (h *handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	h.Handler.ServeHTTP(res, req)
+}
+
+// Response struct, the JSONResponse every endpoint answer with. The fields are
+// raw so the test see the exact keys that were sent
+type Response struct {
+	Status int
+	Header http.Header
+	Body   map[string]json.RawMessage
+}
+
+// Code func
+func (r *Response) Code() (Code int) {
+	json.Unmarshal(r.Body["code"], &Code)
+	return
+}
+
+// Message func
+func (r *Response) Message() (Message string) {
+	json.Unmarshal(r.Body["message"], &Message)
+	return
+}
+
+// ErrorCode func
+func (r *Response) ErrorCode() (ErrorCode string) {
+	json.Unmarshal(r.Body["error_code"], &ErrorCode)
+	return
+}
+
+// Data func decode the data into Data
+func (r *Response) Data(t *testing.T, Data interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body["data"], Data); err != nil {
+		t.Fatalf("data %s: %v", r.Body["data"], err)
+	}
+}
+
+// call func send Payload as JSON with Token (and Headers) and check the
+// response is a JSONResponse with the code of the HTTP status
+func call(t *testing.T, Method string, URL string, Token string, Payload interface{}, Headers ...http.Header) *Response {
+	t.Helper()
+
+	var Body []byte
+	if Payload != nil {
+		Body, _ = json.Marshal(Payload)
+	}
+
+	Request, err := http.NewRequest(Method, URL, bytes.NewReader(Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Request.Header.Set("Content-Type", "application/json")
+	if Token != "" {
+		Request.Header.Set("Authorization", "Bearer "+Token)
+	}
+	for _, Header := range Headers {
+		for Key := range Header {
+			Request.Header.Set(Key, Header.Get(Key))
+		}
+	}
+
+	ResponseHTTP, err := http.DefaultClient.Do(Request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ResponseHTTP.Body.Close()
+
+	Response := &Response{Status: ResponseHTTP.StatusCode, Header: ResponseHTTP.Header}
+	if ContentType := ResponseHTTP.Header.Get("Content-Type"); ContentType != "application/json" {
+		t.Fatalf("%s %s: content type %q", Method, URL, ContentType)
+	}
+	if err = json.NewDecoder(ResponseHTTP.Body).Decode(&Response.Body); err != nil {
+		t.Fatalf("%s %s: body is not a JSON object: %v", Method, URL, err)
+	}
+
+	for _, Key := range []string{"code", "message", "error", "data"} {
+		if _, ok := Response.Body[Key]; !ok {
+			t.Fatalf("%s %s: JSONResponse without %q: %v", Method, URL, Key, Response.Body)
+		}
+	}
+	if Response.Code() != Response.Status {
+		t.Fatalf("%s %s: code %d in a %d response", Method, URL, Response.Code(), Response.Status)
+	}
+
+	return Response
+}
+
+// expect func call and fail when the response is not Status
+func expect(t *testing.T, Status int, Method string, URL string, Token string, Payload interface{}) *Response {
+	t.Helper()
+	Response := call(t, Method, URL, Token, Payload)
+	if Response.Status != Status {
+		t.Fatalf("%s %s = %d %q, want %d", Method, URL, Response.Status, Response.Message(), Status)
+	}
+	return Response
+}
+
+// users func register and login a new users of Role, it return the access token
+func users(t *testing.T, Email string, Role string) (Token string) {
+	t.Helper()
+	expect(t, 200, http.MethodPost, services.Users.URL+"/users/register", "", map[string]interface{}{
+		"email":     Email,
+		"full_name": "Budi",
+		"gender":    1,
+		"role":      Role,
+		"password":  "secret",
+	})
+
+	Response := expect(t, 200, http.MethodPost, services.Users.URL+"/users/login", "", map[string]interface{}{
+		"email":    Email,
+		"password": "secret",
+	})
+
+	var Tokens struct {
+		AccessToken string `json:"access_token"`
+	}
+	Response.Data(t, &Tokens)
+	return Tokens.AccessToken
+}
+
+// product func add a product with Qty stock as admin, it return its id
+func product(t *testing.T, AdminToken string, Name string, Qty int) int {
+	t.Helper()
+	Response := expect(t, 200, http.MethodPost, services.Products.URL+"/products/internal/add", AdminToken, map[string]interface{}{
+		"name":  Name,
+		"price": "15000.00",
+		"qty":   Qty,
+	})
+
+	var Products struct {
+		ID int `json:"id"`
+	}
+	Response.Data(t, &Products)
+	return Products.ID
+}
+
+// stock func, the stock of the product seen by the public products endpoint
+func stock(t *testing.T, ProductID int) int {
+	t.Helper()
+	Response := expect(t, 200, http.MethodGet, fmt.Sprintf("%s/products/%d", services.Products.URL, ProductID), "", nil)
+
+	var Products struct {
+		Qty int `json:"qty"`
+	}
+	Response.Data(t, &Products)
+	return Products.Qty
+}